@@ -0,0 +1,704 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-common/util"
+	"github.com/frkr-io/frkr-tools/pkg/db"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// manifest is the desired state read by 'frkrcfg apply -f' and
+// written by 'frkrcfg export'
+type manifest struct {
+	Tenants []tenantSpec `json:"tenants" yaml:"tenants"`
+}
+
+type tenantSpec struct {
+	Name    string       `json:"name" yaml:"name"`
+	Streams []streamSpec `json:"streams,omitempty" yaml:"streams,omitempty"`
+	Users   []userSpec   `json:"users,omitempty" yaml:"users,omitempty"`
+	Clients []clientSpec `json:"clients,omitempty" yaml:"clients,omitempty"`
+}
+
+// streamSpec fields that are omitted from the file are not managed:
+// an existing stream keeps its current description, retention or status.
+type streamSpec struct {
+	Name          string  `json:"name" yaml:"name"`
+	Description   *string `json:"description,omitempty" yaml:"description,omitempty"`
+	RetentionDays *int    `json:"retention_days,omitempty" yaml:"retention_days,omitempty"`
+	Status        *string `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// userSpec.Password is only used when the user is created
+type userSpec struct {
+	Username string `json:"username" yaml:"username"`
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+// clientSpec.Secret is only used when the client is created
+type clientSpec struct {
+	ClientID string `json:"client_id" yaml:"client_id"`
+	Stream   string `json:"stream,omitempty" yaml:"stream,omitempty"`
+	Secret   string `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// Plan actions
+const (
+	actionCreate = "create"
+	actionUpdate = "update"
+	actionDelete = "delete"
+)
+
+// change is one step of a plan. run performs it inside the transaction
+// that applies the plan and returns a secret when one was generated.
+type change struct {
+	Action string
+	Kind   string
+	Tenant string
+	Name   string
+	Detail []string
+	Secret string
+	run    func(ctx context.Context, tx db.Store) (string, error)
+}
+
+// tenantState is what the database currently holds for one tenant.
+// tenant is nil when the tenant does not exist yet. Soft-deleted streams
+// and revoked clients still hold their names, so apply restores them
+// rather than creating them again.
+type tenantState struct {
+	tenant         *models.Tenant
+	streams        []*models.Stream
+	deletedStreams []*models.Stream
+	users          []*models.TenantUser
+	clients        []*models.ClientCredential
+	revokedClients []*models.ClientCredential
+}
+
+var applyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Reconcile the database against a YAML file",
+	Long: `Create or update tenants, streams, users and clients so the database matches a YAML file.
+
+Only the tenants listed in the file are touched. With --prune, streams, users
+and clients of those tenants that are missing from the file are soft-deleted,
+disabled and revoked respectively; declaring them again restores them. Use
+--dry-run (or 'frkrcfg plan') to see the changes without applying them.
+
+All changes are applied in one transaction, so a failure leaves the database
+as it was.
+
+Passwords and client secrets in the file are only used on create; when
+omitted, one is generated and shown once.`,
+	Example: `  frkrcfg apply -f frkr.yaml --dry-run
+  frkrcfg apply -f frkr.yaml --prune`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		return runApply(cmd, dryRun)
+	},
+}
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Show what 'frkrcfg apply' would change",
+	Long:  `Compare a YAML file with the database and show what would be created, updated or deleted. Equivalent to 'frkrcfg apply --dry-run'.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runApply(cmd, true)
+	},
+}
+
+func runApply(cmd *cobra.Command, dryRun bool) error {
+	file, _ := cmd.Flags().GetString("file")
+	prune, _ := cmd.Flags().GetBool("prune")
+	if file == "" {
+		return fmt.Errorf("--file is required")
+	}
+
+	m, err := loadManifest(file)
+	if err != nil {
+		return err
+	}
+
+	conn, err := getDB()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ctx := cmd.Context()
+	store := db.NewStore(conn)
+	var changes []*change
+	for _, spec := range m.Tenants {
+		state, err := readTenantState(ctx, store, spec.Name)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, diffTenant(spec, state, prune)...)
+	}
+
+	if dryRun {
+		return renderChanges(cmd, changes, func(w io.Writer) {
+			printPlan(w, file, changes)
+			if len(changes) > 0 {
+				fmt.Fprintln(w, "\nDry run: no changes applied.")
+			}
+		})
+	}
+
+	if err := applyChanges(ctx, store, changes); err != nil {
+		return err
+	}
+
+	generated := false
+	for _, c := range changes {
+		if c.Secret != "" {
+			generated = true
+		}
+	}
+	warnSecretHidden(cmd, generated)
+
+	return renderChanges(cmd, changes, func(w io.Writer) {
+		printPlan(w, file, changes)
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n✅ Applied %d change(s)\n", len(changes))
+		if generated {
+			fmt.Fprintf(w, "\nGenerated secrets:\n")
+			for _, c := range changes {
+				if c.Secret != "" {
+					fmt.Fprintf(w, "  %s %s/%s: %s\n", c.Kind, c.Tenant, c.Name, c.Secret)
+				}
+			}
+			fmt.Fprintf(w, "\n⚠️  Save these secrets - they won't be shown again!\n")
+		}
+	})
+}
+
+// applyChanges runs changes in one transaction, recording generated
+// secrets on them. If any change fails, none of them are applied.
+func applyChanges(ctx context.Context, store db.Store, changes []*change) error {
+	err := store.WithTx(ctx, func(tx db.Store) error {
+		for _, c := range changes {
+			secret, err := c.run(ctx, tx)
+			if err != nil {
+				return fmt.Errorf("failed to %s %s '%s/%s' (no changes applied): %w",
+					c.Action, c.Kind, c.Tenant, c.Name, err)
+			}
+			c.Secret = secret
+		}
+		return nil
+	})
+	if err != nil {
+		for _, c := range changes {
+			c.Secret = ""
+		}
+	}
+	return err
+}
+
+// loadManifest reads and validates a manifest. A path of "-" reads stdin.
+func loadManifest(path string) (*manifest, error) {
+	var r io.Reader
+	if path == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", path, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var m manifest
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&m); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	return &m, nil
+}
+
+// validate rejects anything the database would reject, so that a plan
+// never fails half way through apply on bad input.
+func (m *manifest) validate() error {
+	tenants := make(map[string]bool)
+	for _, t := range m.Tenants {
+		if t.Name == "" {
+			return fmt.Errorf("tenant name cannot be empty")
+		}
+		if len(t.Name) > 100 {
+			return fmt.Errorf("tenant name cannot exceed 100 characters")
+		}
+		if tenants[t.Name] {
+			return fmt.Errorf("tenant '%s' is declared more than once", t.Name)
+		}
+		tenants[t.Name] = true
+
+		streams := make(map[string]bool)
+		for _, s := range t.Streams {
+			if err := util.ValidateStreamName(s.Name); err != nil {
+				return fmt.Errorf("tenant '%s': %w", t.Name, err)
+			}
+			if streams[s.Name] {
+				return fmt.Errorf("tenant '%s': stream '%s' is declared more than once", t.Name, s.Name)
+			}
+			streams[s.Name] = true
+			if s.RetentionDays != nil {
+				if *s.RetentionDays < 1 {
+					return fmt.Errorf("tenant '%s': stream '%s': retention_days must be at least 1, got %d (leave it out for the default)", t.Name, s.Name, *s.RetentionDays)
+				}
+				if _, err := util.NormalizeRetentionDays(*s.RetentionDays); err != nil {
+					return fmt.Errorf("tenant '%s': stream '%s': %w", t.Name, s.Name, err)
+				}
+			}
+			if s.Status != nil && *s.Status != db.StreamStatusActive && *s.Status != db.StreamStatusPaused {
+				return fmt.Errorf("tenant '%s': stream '%s': invalid status '%s' (expected %s or %s)",
+					t.Name, s.Name, *s.Status, db.StreamStatusActive, db.StreamStatusPaused)
+			}
+		}
+
+		users := make(map[string]bool)
+		for _, u := range t.Users {
+			if err := util.ValidateUsername(u.Username); err != nil {
+				return fmt.Errorf("tenant '%s': %w", t.Name, err)
+			}
+			if users[u.Username] {
+				return fmt.Errorf("tenant '%s': user '%s' is declared more than once", t.Name, u.Username)
+			}
+			users[u.Username] = true
+			if u.Password != "" && len(u.Password) < 8 {
+				return fmt.Errorf("tenant '%s': user '%s': password must be at least 8 characters", t.Name, u.Username)
+			}
+		}
+
+		clients := make(map[string]bool)
+		for _, c := range t.Clients {
+			if err := validateClientID(c.ClientID); err != nil {
+				return fmt.Errorf("tenant '%s': %w", t.Name, err)
+			}
+			if clients[c.ClientID] {
+				return fmt.Errorf("tenant '%s': client '%s' is declared more than once", t.Name, c.ClientID)
+			}
+			clients[c.ClientID] = true
+			if c.Stream != "" && !streams[c.Stream] {
+				return fmt.Errorf("tenant '%s': client '%s' references stream '%s', which is not declared", t.Name, c.ClientID, c.Stream)
+			}
+			if c.Secret != "" && len(c.Secret) < 8 {
+				return fmt.Errorf("tenant '%s': client '%s': secret must be at least 8 characters", t.Name, c.ClientID)
+			}
+		}
+	}
+	return nil
+}
+
+// readTenantState loads a tenant and its objects without creating anything
+func readTenantState(ctx context.Context, store db.Store, name string) (*tenantState, error) {
+	tenant, err := store.GetTenantByName(ctx, name)
+	if errors.Is(err, db.ErrNotFound) {
+		return &tenantState{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &tenantState{tenant: tenant}
+	if state.streams, err = store.ListStreams(ctx, tenant.ID); err != nil {
+		return nil, fmt.Errorf("failed to list streams for tenant '%s': %w", name, err)
+	}
+	if state.deletedStreams, err = store.ListDeletedStreams(ctx, tenant.ID); err != nil {
+		return nil, fmt.Errorf("failed to list deleted streams for tenant '%s': %w", name, err)
+	}
+	if state.users, err = store.ListAllUsers(ctx, tenant.ID); err != nil {
+		return nil, fmt.Errorf("failed to list users for tenant '%s': %w", name, err)
+	}
+	if state.clients, err = store.ListClients(ctx, tenant.ID, nil); err != nil {
+		return nil, fmt.Errorf("failed to list clients for tenant '%s': %w", name, err)
+	}
+	if state.revokedClients, err = store.ListRevokedClients(ctx, tenant.ID); err != nil {
+		return nil, fmt.Errorf("failed to list revoked clients for tenant '%s': %w", name, err)
+	}
+	return state, nil
+}
+
+// diffTenant returns the changes that bring state in line with spec.
+// Creates and updates come first, deletes last, so that clients can
+// be moved to a new stream before the old one goes away.
+func diffTenant(spec tenantSpec, state *tenantState, prune bool) []*change {
+	var changes []*change
+	name := spec.Name
+
+	if state.tenant == nil {
+		changes = append(changes, &change{
+			Action: actionCreate, Kind: "tenant", Tenant: name, Name: name,
+			run: func(ctx context.Context, tx db.Store) (string, error) {
+				tenant, err := tx.CreateOrGetTenant(ctx, name)
+				state.tenant = tenant
+				return "", err
+			},
+		})
+	}
+
+	streamsByName := make(map[string]*models.Stream)
+	streamNames := make(map[string]string)
+	for _, s := range state.streams {
+		streamsByName[s.Name] = s
+		streamNames[s.ID] = s.Name
+	}
+	deletedStreams := make(map[string]*models.Stream)
+	for _, s := range state.deletedStreams {
+		deletedStreams[s.Name] = s
+		streamNames[s.ID] = s.Name
+	}
+
+	declaredStreams := make(map[string]bool)
+	for _, s := range spec.Streams {
+		declaredStreams[s.Name] = true
+
+		current, ok := streamsByName[s.Name]
+		restore := false
+		if !ok {
+			current, restore = deletedStreams[s.Name]
+		}
+		if current == nil {
+			detail := []string{}
+			if s.Description != nil && *s.Description != "" {
+				detail = append(detail, fmt.Sprintf("description: %q", *s.Description))
+			}
+			days := 0
+			if s.RetentionDays != nil {
+				days = *s.RetentionDays
+			}
+			days, _ = util.NormalizeRetentionDays(days)
+			detail = append(detail, fmt.Sprintf("retention_days: %d", days))
+			// Streams are created active
+			paused := s.Status != nil && *s.Status == db.StreamStatusPaused
+			if paused {
+				detail = append(detail, "status: "+db.StreamStatusPaused)
+			}
+
+			changes = append(changes, &change{
+				Action: actionCreate, Kind: "stream", Tenant: name, Name: s.Name, Detail: detail,
+				run: func(ctx context.Context, tx db.Store) (string, error) {
+					description := ""
+					if s.Description != nil {
+						description = *s.Description
+					}
+					if _, err := tx.CreateStream(ctx, state.tenant.ID, s.Name, description, days); err != nil {
+						return "", err
+					}
+					if !paused {
+						return "", nil
+					}
+					_, err := tx.UpdateStream(ctx, state.tenant.ID, s.Name, db.StreamUpdate{Status: s.Status})
+					return "", err
+				},
+			})
+			continue
+		}
+
+		var detail []string
+		if restore {
+			detail = append(detail, "restore")
+		}
+		var update db.StreamUpdate
+		if s.Description != nil && *s.Description != current.Description {
+			update.Description = s.Description
+			detail = append(detail, fmt.Sprintf("description: %q -> %q", current.Description, *s.Description))
+		}
+		if s.RetentionDays != nil {
+			days, _ := util.NormalizeRetentionDays(*s.RetentionDays)
+			if days != current.RetentionDays {
+				update.RetentionDays = &days
+				detail = append(detail, fmt.Sprintf("retention_days: %d -> %d", current.RetentionDays, days))
+			}
+		}
+		if s.Status != nil && *s.Status != current.Status {
+			update.Status = s.Status
+			detail = append(detail, fmt.Sprintf("status: %s -> %s", current.Status, *s.Status))
+		}
+		if len(detail) > 0 {
+			changes = append(changes, &change{
+				Action: actionUpdate, Kind: "stream", Tenant: name, Name: s.Name, Detail: detail,
+				run: func(ctx context.Context, tx db.Store) (string, error) {
+					if restore {
+						if _, err := tx.RestoreStream(ctx, state.tenant.ID, s.Name); err != nil {
+							return "", err
+						}
+					}
+					if update.Description == nil && update.RetentionDays == nil && update.Status == nil {
+						return "", nil
+					}
+					_, err := tx.UpdateStream(ctx, state.tenant.ID, s.Name, update)
+					return "", err
+				},
+			})
+		}
+	}
+
+	usersByName := make(map[string]*models.TenantUser)
+	for _, u := range state.users {
+		usersByName[u.Username] = u
+	}
+
+	declaredUsers := make(map[string]bool)
+	for _, u := range spec.Users {
+		declaredUsers[u.Username] = true
+
+		current, ok := usersByName[u.Username]
+		if !ok {
+			changes = append(changes, &change{
+				Action: actionCreate, Kind: "user", Tenant: name, Name: u.Username,
+				run: func(ctx context.Context, tx db.Store) (string, error) {
+					password, generated := u.Password, false
+					if password == "" {
+						var err error
+						if password, err = util.GeneratePassword(); err != nil {
+							return "", fmt.Errorf("failed to generate password: %w", err)
+						}
+						generated = true
+					}
+					if _, err := tx.CreateUser(ctx, state.tenant.ID, u.Username, password); err != nil {
+						return "", err
+					}
+					if generated {
+						return password, nil
+					}
+					return "", nil
+				},
+			})
+			continue
+		}
+
+		if userStatus(current) == "disabled" {
+			changes = append(changes, &change{
+				Action: actionUpdate, Kind: "user", Tenant: name, Name: u.Username,
+				Detail: []string{"status: disabled -> active"},
+				run: func(ctx context.Context, tx db.Store) (string, error) {
+					_, err := tx.EnableUser(ctx, state.tenant.ID, u.Username)
+					return "", err
+				},
+			})
+		}
+	}
+
+	clientsByID := make(map[string]*models.ClientCredential)
+	for _, c := range state.clients {
+		clientsByID[c.ClientID] = c
+	}
+	revokedClients := make(map[string]*models.ClientCredential)
+	for _, c := range state.revokedClients {
+		revokedClients[c.ClientID] = c
+	}
+
+	declaredClients := make(map[string]bool)
+	for _, c := range spec.Clients {
+		declaredClients[c.ClientID] = true
+
+		current, ok := clientsByID[c.ClientID]
+		restore := false
+		if !ok {
+			current, restore = revokedClients[c.ClientID]
+		}
+		if current == nil {
+			var detail []string
+			if c.Stream != "" {
+				detail = append(detail, "stream: "+c.Stream)
+			}
+			changes = append(changes, &change{
+				Action: actionCreate, Kind: "client", Tenant: name, Name: c.ClientID, Detail: detail,
+				run: func(ctx context.Context, tx db.Store) (string, error) {
+					streamID, err := resolveStreamID(ctx, tx, state.tenant.ID, c.Stream)
+					if err != nil {
+						return "", err
+					}
+					secret, generated := c.Secret, false
+					if secret == "" {
+						if secret, err = util.GeneratePassword(); err != nil {
+							return "", fmt.Errorf("failed to generate client secret: %w", err)
+						}
+						generated = true
+					}
+					if _, err := tx.CreateClient(ctx, state.tenant.ID, c.ClientID, secret, streamID); err != nil {
+						return "", err
+					}
+					if generated {
+						return secret, nil
+					}
+					return "", nil
+				},
+			})
+			continue
+		}
+
+		var detail []string
+		if restore {
+			detail = append(detail, "status: revoked -> active (keeps its previous secret)")
+		}
+		currentStream := ""
+		if current.StreamID.Valid {
+			currentStream = streamNames[current.StreamID.String]
+		}
+		moveStream := currentStream != c.Stream
+		if moveStream {
+			detail = append(detail, fmt.Sprintf("stream: %s -> %s", streamLabel(currentStream), streamLabel(c.Stream)))
+		}
+		if len(detail) > 0 {
+			changes = append(changes, &change{
+				Action: actionUpdate, Kind: "client", Tenant: name, Name: c.ClientID, Detail: detail,
+				run: func(ctx context.Context, tx db.Store) (string, error) {
+					if restore {
+						if _, err := tx.RestoreClient(ctx, state.tenant.ID, c.ClientID); err != nil {
+							return "", err
+						}
+					}
+					if !moveStream {
+						return "", nil
+					}
+					streamID, err := resolveStreamID(ctx, tx, state.tenant.ID, c.Stream)
+					if err != nil {
+						return "", err
+					}
+					_, err = tx.SetClientStream(ctx, state.tenant.ID, c.ClientID, streamID)
+					return "", err
+				},
+			})
+		}
+	}
+
+	if !prune {
+		return changes
+	}
+
+	for _, c := range state.clients {
+		if declaredClients[c.ClientID] {
+			continue
+		}
+		clientID := c.ClientID
+		changes = append(changes, &change{
+			Action: actionDelete, Kind: "client", Tenant: name, Name: clientID,
+			Detail: []string{"revoke"},
+			run: func(ctx context.Context, tx db.Store) (string, error) {
+				_, err := tx.RevokeClient(ctx, state.tenant.ID, clientID)
+				return "", err
+			},
+		})
+	}
+	for _, u := range state.users {
+		if declaredUsers[u.Username] || userStatus(u) == "disabled" {
+			continue
+		}
+		username := u.Username
+		changes = append(changes, &change{
+			Action: actionDelete, Kind: "user", Tenant: name, Name: username,
+			Detail: []string{"disable"},
+			run: func(ctx context.Context, tx db.Store) (string, error) {
+				_, err := tx.DisableUser(ctx, state.tenant.ID, username)
+				return "", err
+			},
+		})
+	}
+	for _, s := range state.streams {
+		if declaredStreams[s.Name] {
+			continue
+		}
+		streamName := s.Name
+		changes = append(changes, &change{
+			Action: actionDelete, Kind: "stream", Tenant: name, Name: streamName,
+			Detail: []string{"soft-delete"},
+			run: func(ctx context.Context, tx db.Store) (string, error) {
+				return "", tx.DeleteStream(ctx, state.tenant.ID, streamName)
+			},
+		})
+	}
+
+	return changes
+}
+
+// resolveStreamID looks up a stream by name; an empty name means no stream
+func resolveStreamID(ctx context.Context, tx db.Store, tenantID, streamName string) (*string, error) {
+	if streamName == "" {
+		return nil, nil
+	}
+	stream, err := tx.GetStream(ctx, tenantID, streamName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream '%s': %w", streamName, err)
+	}
+	return &stream.ID, nil
+}
+
+func streamLabel(name string) string {
+	if name == "" {
+		return "(none)"
+	}
+	return name
+}
+
+// printPlan writes a plan in the table format
+func printPlan(w io.Writer, file string, changes []*change) {
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "No changes. The database matches %s.\n", file)
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, c := range changes {
+		symbol := map[string]string{actionCreate: "+", actionUpdate: "~", actionDelete: "-"}[c.Action]
+		fmt.Fprintf(w, "  %s %s %s/%s\n", symbol, c.Kind, c.Tenant, c.Name)
+		for _, d := range c.Detail {
+			fmt.Fprintf(w, "      %s\n", d)
+		}
+		counts[c.Action]++
+	}
+	fmt.Fprintf(w, "\nPlan: %d to create, %d to update, %d to delete.\n",
+		counts[actionCreate], counts[actionUpdate], counts[actionDelete])
+}
+
+// changeView is the rendered form of a plan entry
+type changeView struct {
+	Action  string   `json:"action" yaml:"action"`
+	Kind    string   `json:"kind" yaml:"kind"`
+	Tenant  string   `json:"tenant" yaml:"tenant"`
+	Name    string   `json:"name" yaml:"name"`
+	Changes []string `json:"changes,omitempty" yaml:"changes,omitempty"`
+	Secret  string   `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+func (v changeView) resourceName() string { return v.Kind + "/" + v.Name }
+
+func renderChanges(cmd *cobra.Command, changes []*change, printTable func(w io.Writer)) error {
+	views := make([]changeView, 0, len(changes))
+	for _, c := range changes {
+		views = append(views, changeView{
+			Action:  c.Action,
+			Kind:    c.Kind,
+			Tenant:  c.Tenant,
+			Name:    c.Name,
+			Changes: c.Detail,
+			Secret:  c.Secret,
+		})
+	}
+	return renderList(cmd, views, printTable)
+}
+
+func init() {
+	applyCmd.Flags().StringP("file", "f", "", "YAML file describing the desired state ('-' for stdin)")
+	applyCmd.Flags().Bool("dry-run", false, "Show the plan without applying it")
+	applyCmd.Flags().Bool("prune", false, "Soft-delete, disable or revoke objects missing from the file")
+
+	planCmd.Flags().StringP("file", "f", "", "YAML file describing the desired state ('-' for stdin)")
+	planCmd.Flags().Bool("prune", false, "Include objects missing from the file in the plan")
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-tools/pkg/db"
+	"github.com/stretchr/testify/require"
+)
+
+func writeManifest(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "frkr.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestLoadManifest(t *testing.T) {
+	t.Run("valid manifest", func(t *testing.T) {
+		path := writeManifest(t, `
+tenants:
+  - name: default
+    streams:
+      - name: my-api
+        description: My API
+        retention_days: 14
+    users:
+      - username: alice
+    clients:
+      - client_id: my-api-sdk
+        stream: my-api
+`)
+		m, err := loadManifest(path)
+		require.NoError(t, err)
+		require.Len(t, m.Tenants, 1)
+		require.Equal(t, 14, *m.Tenants[0].Streams[0].RetentionDays)
+		require.Equal(t, "my-api", m.Tenants[0].Clients[0].Stream)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		path := writeManifest(t, "tenants:\n  - name: default\n    stream: []\n")
+		_, err := loadManifest(path)
+		require.Error(t, err)
+	})
+
+	t.Run("client references undeclared stream", func(t *testing.T) {
+		path := writeManifest(t, "tenants:\n  - name: default\n    clients:\n      - client_id: sdk\n        stream: missing\n")
+		_, err := loadManifest(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not declared")
+	})
+
+	t.Run("duplicate stream", func(t *testing.T) {
+		path := writeManifest(t, "tenants:\n  - name: default\n    streams:\n      - name: a\n      - name: a\n")
+		_, err := loadManifest(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "more than once")
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		path := writeManifest(t, "tenants:\n  - name: default\n    streams:\n      - name: a\n        status: stopped\n")
+		_, err := loadManifest(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "invalid status")
+	})
+
+	t.Run("retention out of range", func(t *testing.T) {
+		path := writeManifest(t, "tenants:\n  - name: default\n    streams:\n      - name: a\n        retention_days: 400\n")
+		_, err := loadManifest(path)
+		require.Error(t, err)
+	})
+
+	t.Run("zero retention", func(t *testing.T) {
+		path := writeManifest(t, "tenants:\n  - name: default\n    streams:\n      - name: a\n        retention_days: 0\n")
+		_, err := loadManifest(path)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least 1")
+	})
+}
+
+func TestDiffTenant(t *testing.T) {
+	description := "New description"
+	days := 30
+
+	state := &tenantState{
+		tenant: &models.Tenant{ID: "tenant-1", Name: "default"},
+		streams: []*models.Stream{
+			{ID: "stream-1", Name: "kept", Description: "Old description", RetentionDays: 7},
+			{ID: "stream-2", Name: "orphan", RetentionDays: 7},
+		},
+		users: []*models.TenantUser{
+			{ID: "user-1", Username: "alice"},
+			{ID: "user-2", Username: "bob", DeletedAt: &sql.NullTime{Valid: true}},
+			{ID: "user-3", Username: "carol"},
+		},
+		clients: []*models.ClientCredential{
+			{ID: "client-1", ClientID: "sdk", StreamID: sql.NullString{String: "stream-2", Valid: true}},
+			{ID: "client-2", ClientID: "old-sdk"},
+		},
+	}
+
+	spec := tenantSpec{
+		Name: "default",
+		Streams: []streamSpec{
+			{Name: "kept", Description: &description, RetentionDays: &days},
+			{Name: "new"},
+		},
+		Users: []userSpec{
+			{Username: "alice"},
+			{Username: "bob"},
+			{Username: "dave"},
+		},
+		Clients: []clientSpec{
+			{ClientID: "sdk", Stream: "kept"},
+		},
+	}
+
+	summarize := func(changes []*change) []string {
+		var out []string
+		for _, c := range changes {
+			out = append(out, c.Action+" "+c.Kind+"/"+c.Name)
+		}
+		return out
+	}
+
+	t.Run("without prune", func(t *testing.T) {
+		changes := diffTenant(spec, state, false)
+		require.Equal(t, []string{
+			"update stream/kept",
+			"create stream/new",
+			"update user/bob",
+			"create user/dave",
+			"update client/sdk",
+		}, summarize(changes))
+		require.Equal(t, []string{
+			`description: "Old description" -> "New description"`,
+			"retention_days: 7 -> 30",
+		}, changes[0].Detail)
+		require.Equal(t, []string{"stream: orphan -> kept"}, changes[4].Detail)
+	})
+
+	t.Run("with prune", func(t *testing.T) {
+		changes := diffTenant(spec, state, true)
+		require.Equal(t, []string{
+			"update stream/kept",
+			"create stream/new",
+			"update user/bob",
+			"create user/dave",
+			"update client/sdk",
+			"delete client/old-sdk",
+			"delete user/carol",
+			"delete stream/orphan",
+		}, summarize(changes))
+	})
+
+	t.Run("missing tenant creates everything", func(t *testing.T) {
+		changes := diffTenant(spec, &tenantState{}, true)
+		require.Equal(t, []string{
+			"create tenant/default",
+			"create stream/kept",
+			"create stream/new",
+			"create user/alice",
+			"create user/bob",
+			"create user/dave",
+			"create client/sdk",
+		}, summarize(changes))
+	})
+
+	t.Run("pruned objects are restored", func(t *testing.T) {
+		pruned := &tenantState{
+			tenant:         state.tenant,
+			deletedStreams: []*models.Stream{{ID: "stream-3", Name: "gone", RetentionDays: 7}},
+			revokedClients: []*models.ClientCredential{{ID: "client-3", ClientID: "revoked-sdk", StreamID: sql.NullString{String: "stream-3", Valid: true}}},
+		}
+		changes := diffTenant(tenantSpec{
+			Name:    "default",
+			Streams: []streamSpec{{Name: "gone", RetentionDays: &days}},
+			Clients: []clientSpec{{ClientID: "revoked-sdk", Stream: "gone"}},
+		}, pruned, false)
+		require.Equal(t, []string{"update stream/gone", "update client/revoked-sdk"}, summarize(changes))
+		require.Equal(t, []string{"restore", "retention_days: 7 -> 30"}, changes[0].Detail)
+		require.Equal(t, []string{"status: revoked -> active (keeps its previous secret)"}, changes[1].Detail)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		paused := "paused"
+		withStatus := &tenantState{
+			tenant:  state.tenant,
+			streams: []*models.Stream{{ID: "stream-1", Name: "kept", Status: "active", RetentionDays: 7}},
+		}
+		changes := diffTenant(tenantSpec{
+			Name:    "default",
+			Streams: []streamSpec{{Name: "kept", Status: &paused}, {Name: "new", Status: &paused}},
+		}, withStatus, false)
+		require.Equal(t, []string{"update stream/kept", "create stream/new"}, summarize(changes))
+		require.Equal(t, []string{"status: active -> paused"}, changes[0].Detail)
+		require.Equal(t, []string{"retention_days: 7", "status: paused"}, changes[1].Detail)
+	})
+
+	t.Run("unmanaged fields are left alone", func(t *testing.T) {
+		changes := diffTenant(tenantSpec{
+			Name:    "default",
+			Streams: []streamSpec{{Name: "kept"}, {Name: "orphan"}},
+		}, state, false)
+		require.Empty(t, changes)
+	})
+}
+
+func TestApplyChanges(t *testing.T) {
+	ctx := context.Background()
+	store := db.NewMemoryStore()
+
+	apply := func(spec tenantSpec, prune bool) []*change {
+		t.Helper()
+		state, err := readTenantState(ctx, store, spec.Name)
+		require.NoError(t, err)
+		changes := diffTenant(spec, state, prune)
+		require.NoError(t, applyChanges(ctx, store, changes))
+		return changes
+	}
+
+	paused := "paused"
+	full := tenantSpec{
+		Name:    "default",
+		Streams: []streamSpec{{Name: "orders"}, {Name: "payments", Status: &paused}},
+		Users:   []userSpec{{Username: "alice"}},
+		Clients: []clientSpec{{ClientID: "orders-sdk", Stream: "orders", Secret: "secret-123"}},
+	}
+	apply(full, false)
+
+	t.Run("streams are created with their status", func(t *testing.T) {
+		state, err := readTenantState(ctx, store, "default")
+		require.NoError(t, err)
+		stream, err := store.GetStream(ctx, state.tenant.ID, "payments")
+		require.NoError(t, err)
+		require.Equal(t, "paused", stream.Status)
+	})
+
+	t.Run("prune then re-declare restores", func(t *testing.T) {
+		apply(tenantSpec{Name: "default", Streams: []streamSpec{{Name: "payments"}}}, true)
+
+		state, err := readTenantState(ctx, store, "default")
+		require.NoError(t, err)
+		require.Len(t, state.streams, 1)
+		require.Len(t, state.deletedStreams, 1)
+		require.Empty(t, state.clients)
+		require.Len(t, state.revokedClients, 1)
+
+		changes := apply(full, false)
+		require.Len(t, changes, 3)
+
+		client, err := store.GetClient(ctx, state.tenant.ID, "orders-sdk")
+		require.NoError(t, err)
+		require.Equal(t, "secret-123", client.ClientSecret)
+		_, err = store.GetStream(ctx, state.tenant.ID, "orders")
+		require.NoError(t, err)
+
+		require.Empty(t, apply(full, true))
+	})
+
+	t.Run("a failed change applies nothing", func(t *testing.T) {
+		state, err := readTenantState(ctx, store, "default")
+		require.NoError(t, err)
+		changes := diffTenant(tenantSpec{
+			Name:    "default",
+			Streams: []streamSpec{{Name: "orders"}, {Name: "payments"}, {Name: "refunds"}},
+			Users:   []userSpec{{Username: "alice"}, {Username: "bob"}},
+		}, state, false)
+		changes = append(changes, &change{
+			Action: actionCreate, Kind: "stream", Tenant: "default", Name: "orders",
+			run: func(ctx context.Context, tx db.Store) (string, error) {
+				_, err := tx.CreateStream(ctx, state.tenant.ID, "orders", "", 7)
+				return "", err
+			},
+		})
+
+		err = applyChanges(ctx, store, changes)
+		require.ErrorIs(t, err, db.ErrAlreadyExists)
+		require.Contains(t, err.Error(), "no changes applied")
+		for _, c := range changes {
+			require.Empty(t, c.Secret)
+		}
+
+		_, err = store.GetStream(ctx, state.tenant.ID, "refunds")
+		require.ErrorIs(t, err, db.ErrNotFound)
+		_, err = store.GetUser(ctx, state.tenant.ID, "bob")
+		require.ErrorIs(t, err, db.ErrNotFound)
+	})
+}
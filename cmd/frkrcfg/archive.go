@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/frkr-io/frkr-tools/pkg/traffic"
+	"github.com/spf13/cobra"
+)
+
+// Traffic file formats for stream export and import
+const (
+	formatNDJSON = "ndjson"
+	formatHAR    = "har"
+)
+
+// trafficFileView is the rendered result of a stream export or import
+type trafficFileView struct {
+	Action   string `json:"action" yaml:"action"`
+	Stream   string `json:"stream" yaml:"stream"`
+	File     string `json:"file" yaml:"file"`
+	Format   string `json:"format" yaml:"format"`
+	Requests int    `json:"requests" yaml:"requests"`
+	DryRun   bool   `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+}
+
+func (v trafficFileView) resourceName() string { return v.Action + "/" + v.Stream }
+
+var streamExportCmd = &cobra.Command{
+	Use:   "export [stream-name-or-id]",
+	Short: "Archive a stream's mirrored requests as NDJSON or HAR",
+	Long: `Read the requests mirrored into a stream between --since and --until and
+write them to --file, as NDJSON (one request per line, as printed by
+'stream tail -o json') or as a HAR document that browsers and HTTP tools
+can open. The format follows the file extension unless --format is given.
+
+Without --since, the export starts at the oldest retained message; without
+--until, it ends at the current end of the topic. --filter selects requests
+the same way as 'stream tail'. --rules rewrites them before they are
+written, e.g. to redact personal data or strip credentials.`,
+	Example: `  frkrcfg stream export my-api --since 1h -f traffic.ndjson --broker-url localhost:19092
+  frkrcfg stream export my-api --since 2024-01-02T15:00:00Z --until 2024-01-02T16:00:00Z -f repro.har`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		format, err := trafficFormat(cmd, file)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		opts := broker.ReadOptions{FromBeginning: true}
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			if opts.Since, err = parseTimeFlag(since, now); err != nil {
+				return err
+			}
+			opts.FromBeginning = false
+		}
+		if until, _ := cmd.Flags().GetString("until"); until != "" {
+			if opts.Until, err = parseTimeFlag(until, now); err != nil {
+				return err
+			}
+		}
+
+		filterExprs, _ := cmd.Flags().GetStringArray("filter")
+		filters, err := traffic.ParseFilters(filterExprs)
+		if err != nil {
+			return err
+		}
+		baseURL, _ := cmd.Flags().GetString("base-url")
+		rules, err := loadRules(cmd)
+		if err != nil {
+			return err
+		}
+
+		stream, err := resolveStreamTopic(args[0])
+		if err != nil {
+			return err
+		}
+		rewriter, err := rules.Rewriter(stream.Name)
+		if err != nil {
+			return err
+		}
+
+		out := cmd.OutOrStdout()
+		var dest *exportFile
+		if file != "-" {
+			if dest, err = createExportFile(file); err != nil {
+				return err
+			}
+			defer dest.discard()
+			out = dest
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		var records []traffic.Record
+		count := 0
+		bm, err := newBrokerManager()
+		if err != nil {
+			return err
+		}
+		err = bm.ReadTopic(ctx, stream.Topic, opts, func(msg broker.Message) error {
+			rec, err := traffic.FromMessage(msg)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  Skipping message: %v\n", err)
+				return nil
+			}
+			if !traffic.MatchAll(filters, rec.Request) {
+				return nil
+			}
+			if err := rewriter.Apply(ctx, &rec.Request); err != nil {
+				return err
+			}
+			count++
+			if format == formatHAR {
+				records = append(records, rec)
+				return nil
+			}
+			return traffic.WriteNDJSON(out, rec)
+		})
+		if err != nil {
+			return err
+		}
+		if format == formatHAR {
+			if err := traffic.WriteHAR(out, records, baseURL); err != nil {
+				return err
+			}
+		}
+		if err := dest.commit(); err != nil {
+			return err
+		}
+
+		view := trafficFileView{Action: "export", Stream: stream.Name, File: file, Format: format, Requests: count}
+		if file == "-" {
+			// stdout holds the export itself
+			fmt.Fprintf(cmd.ErrOrStderr(), "✅ Exported %d request(s) from stream '%s'\n", count, stream.Name)
+			return nil
+		}
+		return renderOne(cmd, view, func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Exported %d request(s) from stream '%s' to %s (%s)\n", count, stream.Name, file, format)
+		})
+	},
+}
+
+var streamImportCmd = &cobra.Command{
+	Use:   "import [stream-name-or-id]",
+	Short: "Publish recorded requests from an NDJSON or HAR file into a stream",
+	Long: `Read requests from --file and publish them to the stream's topic in the
+envelope the ingest gateway writes, so that tail, replay and the streaming
+gateway see them like mirrored traffic. HAR files recorded by browsers or
+proxies, and NDJSON written by 'stream export', are accepted. The format
+follows the file extension unless --format is given.
+
+Imported requests are stamped with the current time on the topic, so they
+are not removed early by the topic's retention; the original time is kept
+in the request's timestamp_ns. --filter selects requests the same way as
+'stream tail'.`,
+	Example: `  frkrcfg stream import dev-api -f capture.har --filter 'path=/api/*' --broker-url localhost:19092
+  frkrcfg stream export prod-api --since 1h -f - --context prod | frkrcfg stream import dev-api -f - --format ndjson`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, _ := cmd.Flags().GetString("file")
+		if file == "" {
+			return fmt.Errorf("--file is required ('-' for stdin)")
+		}
+		format, err := trafficFormat(cmd, file)
+		if err != nil {
+			return err
+		}
+
+		filterExprs, _ := cmd.Flags().GetStringArray("filter")
+		filters, err := traffic.ParseFilters(filterExprs)
+		if err != nil {
+			return err
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		records, err := readTrafficFile(cmd, file, format)
+		if err != nil {
+			return err
+		}
+
+		stream, err := resolveStreamTopic(args[0])
+		if err != nil {
+			return err
+		}
+
+		msgs, err := importMessages(stream.Name, records, filters)
+		if err != nil {
+			return err
+		}
+
+		if !dryRun && len(msgs) > 0 {
+			bm, err := newBrokerManager()
+			if err != nil {
+				return err
+			}
+			if err := bm.Publish(cmd.Context(), stream.Topic, msgs); err != nil {
+				return err
+			}
+		}
+
+		view := trafficFileView{Action: "import", Stream: stream.Name, File: file, Format: format, Requests: len(msgs), DryRun: dryRun}
+		return renderOne(cmd, view, func(w io.Writer) {
+			if dryRun {
+				fmt.Fprintf(w, "Would import %d of %d request(s) into stream '%s' (dry run)\n", len(msgs), len(records), stream.Name)
+				return
+			}
+			fmt.Fprintf(w, "✅ Imported %d of %d request(s) into stream '%s' (topic %s)\n", len(msgs), len(records), stream.Name, stream.Topic)
+		})
+	},
+}
+
+func init() {
+	streamExportCmd.Flags().StringP("file", "f", "-", "File to write ('-' for stdout)")
+	streamExportCmd.Flags().String("format", "", "ndjson or har (default: from the file extension, else ndjson)")
+	streamExportCmd.Flags().String("since", "", "Export requests captured at or after this time (RFC 3339 or an age, e.g. 1h)")
+	streamExportCmd.Flags().String("until", "", "Export requests captured up to this time (RFC 3339 or an age)")
+	streamExportCmd.Flags().StringArray("filter", nil, "Only export requests matching field=pattern (repeatable)")
+	streamExportCmd.Flags().String("base-url", "http://localhost", "Scheme and host for HAR URLs of requests without a Host header")
+	streamExportCmd.Flags().String("rules", "", "Rules file for rewriting requests before they are written")
+
+	streamImportCmd.Flags().StringP("file", "f", "", "NDJSON or HAR file to read ('-' for stdin)")
+	streamImportCmd.Flags().String("format", "", "ndjson or har (default: from the file extension, else ndjson)")
+	streamImportCmd.Flags().StringArray("filter", nil, "Only import requests matching field=pattern (repeatable)")
+	streamImportCmd.Flags().Bool("dry-run", false, "Read and count the requests without publishing them")
+
+	streamCmd.AddCommand(streamExportCmd)
+	streamCmd.AddCommand(streamImportCmd)
+}
+
+// trafficFormat returns --format, or the format implied by the file's
+// extension
+func trafficFormat(cmd *cobra.Command, file string) (string, error) {
+	format, _ := cmd.Flags().GetString("format")
+	switch format {
+	case formatNDJSON, formatHAR:
+		return format, nil
+	case "":
+		if strings.EqualFold(filepath.Ext(file), ".har") {
+			return formatHAR, nil
+		}
+		return formatNDJSON, nil
+	default:
+		return "", fmt.Errorf("unknown format '%s' (expected ndjson or har)", format)
+	}
+}
+
+// exportFile is a stream export being written to a temporary file next to
+// its target. The target is only replaced by commit, so a failed or
+// interrupted export never leaves a truncated file that looks complete.
+type exportFile struct {
+	*os.File
+	path string
+	done bool
+}
+
+// createExportFile starts an export to path. Nothing at path changes until
+// commit.
+func createExportFile(path string) (*exportFile, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a file next to %s: %w", path, err)
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("failed to set permissions on %s: %w", f.Name(), err)
+	}
+	return &exportFile{File: f, path: path}, nil
+}
+
+// commit closes the temporary file and moves it over the target. A nil
+// exportFile commits nothing.
+func (f *exportFile) commit() error {
+	if f == nil || f.done {
+		return nil
+	}
+	f.done = true
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	if err := os.Rename(f.Name(), f.path); err != nil {
+		os.Remove(f.Name())
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// discard removes the temporary file if it has not been committed, leaving
+// the target as it was. A nil exportFile discards nothing.
+func (f *exportFile) discard() {
+	if f == nil || f.done {
+		return
+	}
+	f.done = true
+	f.Close()
+	os.Remove(f.Name())
+}
+
+// readTrafficFile reads the records of an NDJSON or HAR file, or of stdin
+func readTrafficFile(cmd *cobra.Command, file, format string) ([]traffic.Record, error) {
+	in := cmd.InOrStdin()
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", file, err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	if format == formatHAR {
+		return traffic.ReadHAR(in)
+	}
+	return traffic.ReadNDJSON(in)
+}
+
+// importMessages turns the records that match filters into messages for a
+// stream's topic
+func importMessages(streamName string, records []traffic.Record, filters []traffic.Filter) ([]broker.Message, error) {
+	var msgs []broker.Message
+	for _, rec := range records {
+		if !traffic.MatchAll(filters, rec.Request) {
+			continue
+		}
+
+		req := rec.Request
+		if req.TimestampNs == 0 && !rec.Time.IsZero() {
+			req.TimestampNs = rec.Time.UnixNano()
+		}
+		value, err := traffic.Encode(traffic.Envelope{StreamID: streamName, Request: req})
+		if err != nil {
+			return nil, err
+		}
+
+		msg := broker.Message{Value: value}
+		if req.RequestID != "" {
+			msg.Key = []byte(req.RequestID)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, nil
+}
@@ -1,18 +1,20 @@
 package main
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
-	"github.com/frkr-io/frkr-common/db"
-	"github.com/frkr-io/frkr-common/util"
+	"github.com/frkr-io/frkr-tools/pkg/db"
 	"github.com/spf13/cobra"
 )
 
 var clientCmd = &cobra.Command{
 	Use:   "client",
 	Short: "Manage client credentials",
-	Long:  `Create, list, and manage OAuth client credentials for SDK authentication.`,
+	Long:  `Create, list, revoke, and delete OAuth client credentials for SDK authentication.`,
 }
 
 var clientCreateCmd = &cobra.Command{
@@ -23,16 +25,8 @@ var clientCreateCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		clientID := args[0]
 
-		if clientID == "" {
-			return fmt.Errorf("client ID cannot be empty")
-		}
-		if len(clientID) > 255 {
-			return fmt.Errorf("client ID cannot exceed 255 characters")
-		}
-		for _, r := range clientID {
-			if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_') {
-				return fmt.Errorf("client ID can only contain alphanumeric characters, dashes, and underscores")
-			}
+		if err := validateClientID(clientID); err != nil {
+			return err
 		}
 
 		conn, err := getDB()
@@ -41,9 +35,9 @@ var clientCreateCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		tenant, err := db.CreateOrGetTenant(conn, tenantName)
+		tenant, err := resolveTenant(conn)
 		if err != nil {
-			return fmt.Errorf("failed to get tenant: %w", err)
+			return err
 		}
 
 		streamName, _ := cmd.Flags().GetString("stream")
@@ -54,41 +48,62 @@ var clientCreateCmd = &cobra.Command{
 				return fmt.Errorf("failed to get stream '%s': %w", streamName, err)
 			}
 			streamID = &stream.ID
+			streamName = stream.Name
 		}
 
-		clientSecret, _ := cmd.Flags().GetString("secret")
-		if clientSecret == "" {
-			var err error
-			clientSecret, err = util.GeneratePassword()
-			if err != nil {
-				return fmt.Errorf("failed to generate client secret: %w", err)
-			}
+		clientSecret, generated, err := resolveSecret(cmd, "secret")
+		if err != nil {
+			return err
 		}
+		emitted, err := emitCredential(cmd, credential{Kind: "client", ID: clientID, Secret: clientSecret, Tenant: tenant.Name, Stream: streamName})
+		if err != nil {
+			return err
+		}
+		secretOut, staged, err := secretOutput(cmd, cmp.Or(emitted, clientSecret))
+		if err != nil {
+			return err
+		}
+		defer staged.discard()
 
 		client, err := db.CreateClient(conn, tenant.ID, clientID, clientSecret, streamID)
 		if err != nil {
-			if strings.Contains(err.Error(), "does not exist") {
+			if errors.Is(err, db.ErrSchemaMissing) {
 				return fmt.Errorf("clients table does not exist - please run migrations first: %w", err)
 			}
 			return fmt.Errorf("failed to create client: %w", err)
 		}
-
-		fmt.Printf("✅ Client credential created successfully!\n\n")
-		fmt.Printf("Client ID:     %s\n", client.ClientID)
-		fmt.Printf("Client Secret: %s\n", clientSecret)
-		fmt.Printf("Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
-		if client.StreamID.Valid {
-			fmt.Printf("Stream:        %s\n", client.StreamID.String)
-		} else {
-			fmt.Printf("Stream:        (not scoped to any stream)\n")
+		if err := staged.commit(); err != nil {
+			return err
+		}
+		if emitted != "" && secretOut == "" {
+			fmt.Fprint(cmd.OutOrStdout(), emitted)
+			return nil
 		}
-		fmt.Printf("Client UUID:   %s\n\n", client.ID)
-		fmt.Printf("⚠️  Save this client secret - it won't be shown again!\n")
-		fmt.Printf("\nUse in your SDK:\n")
-		fmt.Printf("  clientId: '%s'\n", client.ClientID)
-		fmt.Printf("  clientSecret: '%s'\n", clientSecret)
 
-		return nil
+		view := newClientView(client, tenant)
+		if secretOut == "" {
+			view.ClientSecret = clientSecret
+			warnSecretHidden(cmd, generated)
+		}
+		return renderOne(cmd, view, func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Client credential created successfully!\n\n")
+			fmt.Fprintf(w, "Client ID:     %s\n", client.ClientID)
+			fmt.Fprintf(w, "Client Secret: %s\n", shownSecret(clientSecret, secretOut))
+			fmt.Fprintf(w, "Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
+			if client.StreamID.Valid {
+				fmt.Fprintf(w, "Stream:        %s\n", client.StreamID.String)
+			} else {
+				fmt.Fprintf(w, "Stream:        (not scoped to any stream)\n")
+			}
+			fmt.Fprintf(w, "Client UUID:   %s\n", client.ID)
+			if secretOut != "" {
+				return
+			}
+			fmt.Fprintf(w, "\n⚠️  Save this client secret - it won't be shown again!\n")
+			fmt.Fprintf(w, "\nUse in your SDK:\n")
+			fmt.Fprintf(w, "  clientId: '%s'\n", client.ClientID)
+			fmt.Fprintf(w, "  clientSecret: '%s'\n", clientSecret)
+		})
 	},
 }
 
@@ -103,9 +118,9 @@ var clientListCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		tenant, err := db.CreateOrGetTenant(conn, tenantName)
+		tenant, err := resolveTenant(conn)
 		if err != nil {
-			return fmt.Errorf("failed to get tenant: %w", err)
+			return err
 		}
 
 		streamName, _ := cmd.Flags().GetString("stream")
@@ -120,45 +135,46 @@ var clientListCmd = &cobra.Command{
 
 		clients, err := db.ListClients(conn, tenant.ID, streamID)
 		if err != nil {
-			if strings.Contains(err.Error(), "does not exist") {
+			if errors.Is(err, db.ErrSchemaMissing) {
 				return fmt.Errorf("clients table does not exist - please run migrations first: %w", err)
 			}
 			return fmt.Errorf("failed to list clients: %w", err)
 		}
 
-		if len(clients) == 0 {
-			streamFilter := ""
-			if streamName != "" {
-				streamFilter = fmt.Sprintf(" for stream '%s'", streamName)
-			}
-			fmt.Printf("No clients found for tenant '%s'%s\n", tenantName, streamFilter)
-			return nil
+		views := make([]clientView, 0, len(clients))
+		for _, client := range clients {
+			views = append(views, newClientView(client, tenant))
 		}
 
-		streamFilter := ""
-		if streamName != "" {
-			streamFilter = fmt.Sprintf(" (filtered by stream '%s')", streamName)
-		}
-		fmt.Printf("Clients for tenant '%s'%s:\n\n", tenantName, streamFilter)
-		fmt.Printf("%-36s %-30s %-30s %-20s\n", "UUID", "Client ID", "Stream", "Created")
-		fmt.Printf("%s\n", strings.Repeat("-", 120))
-		for _, client := range clients {
-			streamDisplay := "(not scoped)"
-			if client.StreamID.Valid {
-				streamDisplay = client.StreamID.String
-			}
-			createdAt := "N/A"
-			if client.CreatedAt.Valid {
-				createdAt = client.CreatedAt.Time.Format("2006-01-02 15:04:05")
+		return renderList(cmd, views, func(w io.Writer) {
+			if len(clients) == 0 {
+				streamFilter := ""
+				if streamName != "" {
+					streamFilter = fmt.Sprintf(" for stream '%s'", streamName)
+				}
+				fmt.Fprintf(w, "No clients found for tenant '%s'%s\n", tenantName, streamFilter)
+				return
 			}
-			fmt.Printf("%-36s %-30s %-30s %-20s\n",
-				client.ID,
-				client.ClientID,
-				streamDisplay,
-				createdAt)
-		}
 
-		return nil
+			streamFilter := ""
+			if streamName != "" {
+				streamFilter = fmt.Sprintf(" (filtered by stream '%s')", streamName)
+			}
+			fmt.Fprintf(w, "Clients for tenant '%s'%s:\n\n", tenantName, streamFilter)
+			fmt.Fprintf(w, "%-36s %-30s %-30s %-20s\n", "UUID", "Client ID", "Stream", "Created")
+			fmt.Fprintf(w, "%s\n", strings.Repeat("-", 120))
+			for _, client := range clients {
+				streamDisplay := "(not scoped)"
+				if client.StreamID.Valid {
+					streamDisplay = client.StreamID.String
+				}
+				fmt.Fprintf(w, "%-36s %-30s %-30s %-20s\n",
+					client.ID,
+					client.ClientID,
+					streamDisplay,
+					formatNullTime(client.CreatedAt.Time, client.CreatedAt.Valid))
+			}
+		})
 	},
 }
 
@@ -180,43 +196,128 @@ var clientGetCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		tenant, err := db.CreateOrGetTenant(conn, tenantName)
+		tenant, err := resolveTenant(conn)
 		if err != nil {
-			return fmt.Errorf("failed to get tenant: %w", err)
+			return err
 		}
 
 		client, err := db.GetClient(conn, tenant.ID, clientIdentifier)
 		if err != nil {
-			if strings.Contains(err.Error(), "does not exist") {
+			if errors.Is(err, db.ErrSchemaMissing) {
 				return fmt.Errorf("clients table does not exist - please run migrations first: %w", err)
 			}
 			return fmt.Errorf("failed to get client: %w", err)
 		}
 
-		fmt.Printf("Client Details:\n\n")
-		fmt.Printf("UUID:          %s\n", client.ID)
-		fmt.Printf("Client ID:     %s\n", client.ClientID)
-		fmt.Printf("Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
-		if client.StreamID.Valid {
-			fmt.Printf("Stream:        %s\n", client.StreamID.String)
-		} else {
-			fmt.Printf("Stream:        (not scoped to any stream)\n")
+		return renderOne(cmd, newClientView(client, tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "Client Details:\n\n")
+			fmt.Fprintf(w, "UUID:          %s\n", client.ID)
+			fmt.Fprintf(w, "Client ID:     %s\n", client.ClientID)
+			fmt.Fprintf(w, "Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
+			if client.StreamID.Valid {
+				fmt.Fprintf(w, "Stream:        %s\n", client.StreamID.String)
+			} else {
+				fmt.Fprintf(w, "Stream:        (not scoped to any stream)\n")
+			}
+			fmt.Fprintf(w, "Created:       %s\n", client.CreatedAt.Time.Format("2006-01-02 15:04:05"))
+			fmt.Fprintf(w, "\n⚠️  Client secret is not displayed for security reasons.\n")
+			fmt.Fprintf(w, "If you need a new secret, create a new client and revoke '%s' once your SDKs have moved over.\n", client.ClientID)
+		})
+	},
+}
+
+var clientRevokeCmd = &cobra.Command{
+	Use:   "revoke [client-id-or-uuid]",
+	Short: "Revoke a client credential",
+	Long:  `Revoke a client so the gateways stop accepting it. The row is kept for auditing; use 'client delete' to remove it.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		client, err := db.RevokeClient(conn, tenant.ID, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to revoke client: %w", err)
 		}
-		fmt.Printf("Created:       %s\n", client.CreatedAt.Time.Format("2006-01-02 15:04:05"))
-		fmt.Printf("\n⚠️  Client secret is not displayed for security reasons.\n")
-		fmt.Printf("If you need to retrieve the secret, you'll need to create a new client.\n")
 
-		return nil
+		return renderOne(cmd, newClientView(client, tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Client '%s' revoked\n", client.ClientID)
+		})
+	},
+}
+
+var clientDeleteCmd = &cobra.Command{
+	Use:   "delete [client-id-or-uuid]",
+	Short: "Delete a client credential",
+	Long:  `Permanently delete a client, whether active or revoked. This frees the client ID for reuse.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		if !force {
+			return fmt.Errorf("deletion requires --force flag for safety")
+		}
+
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		client, err := db.DeleteClient(conn, tenant.ID, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to delete client: %w", err)
+		}
+
+		return renderOne(cmd, newClientView(client, tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Client deleted successfully!\n\n")
+			fmt.Fprintf(w, "Deleted client:\n")
+			fmt.Fprintf(w, "  Client ID: %s\n", client.ClientID)
+			fmt.Fprintf(w, "  UUID:      %s\n", client.ID)
+		})
 	},
 }
 
 func init() {
-	clientCreateCmd.Flags().String("secret", "", "Client secret (if not provided, a random secret will be generated)")
+	addSecretFlags(clientCreateCmd, "secret", "Client secret (if not provided, a random secret will be generated)")
+	addEmitFlags(clientCreateCmd)
 	clientCreateCmd.Flags().String("stream", "", "Stream name to scope this client to (optional)")
 
 	clientListCmd.Flags().String("stream", "", "Filter clients by stream name (optional)")
 
+	clientDeleteCmd.Flags().Bool("force", false, "Force deletion (required for safety)")
+
 	clientCmd.AddCommand(clientCreateCmd)
 	clientCmd.AddCommand(clientListCmd)
 	clientCmd.AddCommand(clientGetCmd)
+	clientCmd.AddCommand(clientRevokeCmd)
+	clientCmd.AddCommand(clientDeleteCmd)
+}
+
+// validateClientID enforces the client ID length limit and character set
+func validateClientID(clientID string) error {
+	if clientID == "" {
+		return fmt.Errorf("client ID cannot be empty")
+	}
+	if len(clientID) > 255 {
+		return fmt.Errorf("client ID cannot exceed 255 characters")
+	}
+	for _, r := range clientID {
+		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_') {
+			return fmt.Errorf("client ID can only contain alphanumeric characters, dashes, and underscores")
+		}
+	}
+	return nil
 }
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Environment variables consulted when the matching flag is not set
+const (
+	envConfig     = "FRKR_CONFIG"
+	envDBURL      = "FRKR_DB_URL"
+	envTenant     = "FRKR_TENANT"
+	envDBPassword = "FRKR_DB_PASSWORD"
+
+	envBrokerPassword = "FRKR_BROKER_PASSWORD"
+)
+
+// frkrConfig is the frkrcfg configuration file, ~/.config/frkr/config.yaml
+type frkrConfig struct {
+	CurrentContext string           `yaml:"current_context,omitempty"`
+	Contexts       []*contextConfig `yaml:"contexts,omitempty"`
+}
+
+// contextConfig is a named set of connection settings. The database
+// password can live outside the URL, in a file or an environment variable,
+// and so can the broker SASL password.
+type contextConfig struct {
+	Name         string `yaml:"name"`
+	DBURL        string `yaml:"db_url,omitempty"`
+	BrokerURL    string `yaml:"broker_url,omitempty"`
+	Tenant       string `yaml:"tenant,omitempty"`
+	PasswordFile string `yaml:"password_file,omitempty"`
+	PasswordEnv  string `yaml:"password_env,omitempty"`
+
+	BrokerSASLMechanism string `yaml:"broker_sasl_mechanism,omitempty"`
+	BrokerUser          string `yaml:"broker_user,omitempty"`
+	BrokerPasswordFile  string `yaml:"broker_password_file,omitempty"`
+	BrokerPasswordEnv   string `yaml:"broker_password_env,omitempty"`
+	BrokerTLS           bool   `yaml:"broker_tls,omitempty"`
+	BrokerCAFile        string `yaml:"broker_ca_file,omitempty"`
+	BrokerCertFile      string `yaml:"broker_cert_file,omitempty"`
+	BrokerKeyFile       string `yaml:"broker_key_file,omitempty"`
+}
+
+// configPath returns the location of the configuration file: $FRKR_CONFIG,
+// else $XDG_CONFIG_HOME/frkr/config.yaml, else ~/.config/frkr/config.yaml
+func configPath() (string, error) {
+	if path := os.Getenv(envConfig); path != "" {
+		return path, nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "frkr", "config.yaml"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config file: %w", err)
+	}
+	return filepath.Join(home, ".config", "frkr", "config.yaml"), nil
+}
+
+// loadConfig reads the configuration file. A missing file is an empty config.
+func loadConfig(path string) (*frkrConfig, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &frkrConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg frkrConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// save writes the configuration file. It is only readable by its owner since
+// contexts may hold database URLs with passwords.
+func (c *frkrConfig) save(path string) error {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// context returns the named context, or nil if there is none
+func (c *frkrConfig) context(name string) *contextConfig {
+	for _, ctx := range c.Contexts {
+		if ctx.Name == name {
+			return ctx
+		}
+	}
+	return nil
+}
+
+// selectedContext returns the context named by --context, else the current
+// context of the configuration file, else nil
+func selectedContext() (*contextConfig, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if contextName != "" {
+		ctx := cfg.context(contextName)
+		if ctx == nil {
+			return nil, fmt.Errorf("context '%s' not found in %s", contextName, path)
+		}
+		return ctx, nil
+	}
+	if cfg.CurrentContext == "" {
+		return nil, nil
+	}
+	ctx := cfg.context(cfg.CurrentContext)
+	if ctx == nil {
+		return nil, fmt.Errorf("current context '%s' not found in %s", cfg.CurrentContext, path)
+	}
+	return ctx, nil
+}
+
+// resolveSettings fills in the connection settings that were not given as
+// flags, first from FRKR_* environment variables and then from the selected
+// context. It then adds the database password to the URL if one is
+// configured outside of it, and resolves the broker SASL password.
+func resolveSettings(cmd *cobra.Command) error {
+	ctx, err := selectedContext()
+	if err != nil {
+		return err
+	}
+
+	dbURLFromFlag := dbURL != ""
+	dbURLFromContext := false
+	if dbURL == "" {
+		dbURL = os.Getenv(envDBURL)
+	}
+	if dbURL == "" && ctx != nil {
+		dbURL = ctx.DBURL
+		dbURLFromContext = true
+	}
+
+	brokerURLFromContext := false
+	if brokerURL == "" && ctx != nil {
+		brokerURL = ctx.BrokerURL
+		brokerURLFromContext = true
+	}
+
+	if !cmd.Flags().Changed("tenant") {
+		if env := os.Getenv(envTenant); env != "" {
+			tenantName = env
+		} else if ctx != nil && ctx.Tenant != "" {
+			tenantName = ctx.Tenant
+		}
+	}
+
+	// A context's password source only applies to the context's own URL.
+	// FRKR_DB_PASSWORD never overrides a URL given as a flag, nor a password
+	// already in FRKR_DB_URL.
+	var passwordCtx *contextConfig
+	if dbURLFromContext {
+		passwordCtx = ctx
+	}
+	useEnvPassword := dbURLFromContext || (!dbURLFromFlag && !hasPassword(dbURL))
+	password, err := dbPassword(passwordCtx, useEnvPassword)
+	if err != nil {
+		return err
+	}
+	if password != "" && dbURL != "" {
+		if dbURL, err = withPassword(dbURL, password); err != nil {
+			return err
+		}
+	}
+
+	// Likewise, a context's broker security settings only apply to the
+	// context's own broker URL
+	if brokerURLFromContext {
+		applyContextBrokerSecurity(ctx)
+	}
+	if brokerURL != "" && brokerSecurity.SASLMechanism != "" {
+		var brokerCtx *contextConfig
+		if brokerURLFromContext {
+			brokerCtx = ctx
+		}
+		if brokerSecurity.Password, err = brokerPassword(brokerCtx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyContextBrokerSecurity fills in the broker security settings that were
+// not given as flags from the context
+func applyContextBrokerSecurity(ctx *contextConfig) {
+	if brokerSecurity.SASLMechanism == "" {
+		brokerSecurity.SASLMechanism = ctx.BrokerSASLMechanism
+	}
+	if brokerSecurity.Username == "" {
+		brokerSecurity.Username = ctx.BrokerUser
+	}
+	brokerSecurity.TLS = brokerSecurity.TLS || ctx.BrokerTLS
+	if brokerSecurity.CAFile == "" {
+		brokerSecurity.CAFile = ctx.BrokerCAFile
+	}
+	if brokerSecurity.CertFile == "" && brokerSecurity.KeyFile == "" {
+		brokerSecurity.CertFile = ctx.BrokerCertFile
+		brokerSecurity.KeyFile = ctx.BrokerKeyFile
+	}
+}
+
+// brokerPassword returns the broker SASL password: --broker-password-file,
+// then FRKR_BROKER_PASSWORD, then the context's password file or environment
+// variable. It returns "" when none is configured.
+func brokerPassword(ctx *contextConfig) (string, error) {
+	if brokerPasswordFile != "" {
+		return readSecretFile(brokerPasswordFile)
+	}
+	if password := os.Getenv(envBrokerPassword); password != "" {
+		return password, nil
+	}
+	if ctx == nil {
+		return "", nil
+	}
+	if ctx.BrokerPasswordFile != "" {
+		return readSecretFile(ctx.BrokerPasswordFile)
+	}
+	if ctx.BrokerPasswordEnv != "" {
+		password := os.Getenv(ctx.BrokerPasswordEnv)
+		if password == "" {
+			return "", fmt.Errorf("context '%s' reads the broker password from $%s, which is not set", ctx.Name, ctx.BrokerPasswordEnv)
+		}
+		return password, nil
+	}
+	return "", nil
+}
+
+// dbPassword returns the database password configured outside of the URL:
+// --db-password-file, then FRKR_DB_PASSWORD if useEnv is set, then the
+// context's password file or environment variable. It returns "" when none
+// is configured.
+func dbPassword(ctx *contextConfig, useEnv bool) (string, error) {
+	if dbPasswordFile != "" {
+		return readSecretFile(dbPasswordFile)
+	}
+	if password := os.Getenv(envDBPassword); password != "" && useEnv {
+		return password, nil
+	}
+	if ctx == nil {
+		return "", nil
+	}
+	if ctx.PasswordFile != "" {
+		return readSecretFile(ctx.PasswordFile)
+	}
+	if ctx.PasswordEnv != "" {
+		password := os.Getenv(ctx.PasswordEnv)
+		if password == "" {
+			return "", fmt.Errorf("context '%s' reads the database password from $%s, which is not set", ctx.Name, ctx.PasswordEnv)
+		}
+		return password, nil
+	}
+	return "", nil
+}
+
+// hasPassword reports whether rawURL carries a password
+func hasPassword(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return false
+	}
+	_, ok := u.User.Password()
+	return ok
+}
+
+// withPassword returns rawURL with its password set to password, replacing
+// any password already in the URL
+func withPassword(rawURL, password string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// url.Parse errors quote the URL, which may hold a password
+		return "", fmt.Errorf("invalid database URL")
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return "", fmt.Errorf("database URL needs a user name to go with the configured password")
+	}
+	u.User = url.UserPassword(u.User.Username(), password)
+	return u.String(), nil
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage named connection contexts",
+	Long: `Manage named connection contexts in ~/.config/frkr/config.yaml ($FRKR_CONFIG overrides the path).
+
+A context holds a database URL, a broker URL and a default tenant. Commands use
+the current context for any of these that are not given as flags or through
+FRKR_DB_URL / FRKR_TENANT. Select another context for a single command with --context.`,
+	// Context commands edit the config file and never connect, so they skip
+	// the settings resolution done by the root command
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return validateOutputFormat()
+	},
+}
+
+var contextAddCmd = &cobra.Command{
+	Use:   "add [name]",
+	Short: "Add a context",
+	Long: `Add a context built from --db-url, --broker-url and --tenant.
+
+Keep the database password out of the URL with --password-file or
+--password-env; it is added to the URL when the context is used.
+The first context added becomes the current context.
+
+The broker SASL and TLS flags (--broker-sasl-mechanism, --broker-user,
+--broker-tls, --broker-ca-file, --broker-cert-file, --broker-key-file) are
+saved with the context too. The broker password is read from
+--broker-password-file or --broker-password-env when the context is used.`,
+	Example: `  frkrcfg context add local --db-url "postgres://root@localhost:26257/frkrdb?sslmode=disable" --broker-url localhost:19092
+  frkrcfg context add prod --db-url "postgres://frkr@db.internal:26257/frkrdb?sslmode=verify-full" \
+    --password-env FRKR_PROD_DB_PASSWORD --tenant acme \
+    --broker-url redpanda.internal:9093 --broker-tls --broker-sasl-mechanism SCRAM-SHA-256 \
+    --broker-user frkr --broker-password-env FRKR_PROD_BROKER_PASSWORD`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		passwordFile, _ := cmd.Flags().GetString("password-file")
+		passwordEnv, _ := cmd.Flags().GetString("password-env")
+		brokerPasswordEnv, _ := cmd.Flags().GetString("broker-password-env")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if name == "" {
+			return fmt.Errorf("context name cannot be empty")
+		}
+		if dbURL == "" {
+			return fmt.Errorf("--db-url is required")
+		}
+		if brokerPasswordFile != "" && brokerPasswordEnv != "" {
+			return fmt.Errorf("--broker-password-file and --broker-password-env are mutually exclusive")
+		}
+		if err := brokerSecurity.Validate(); err != nil {
+			return fmt.Errorf("invalid broker settings: %w", err)
+		}
+		u, err := url.Parse(dbURL)
+		if err != nil {
+			return fmt.Errorf("invalid database URL")
+		}
+		passwordInURL := false
+		if u.User != nil {
+			_, passwordInURL = u.User.Password()
+		}
+
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return err
+		}
+
+		ctx := &contextConfig{
+			Name:         name,
+			DBURL:        dbURL,
+			BrokerURL:    brokerURL,
+			PasswordFile: passwordFile,
+			PasswordEnv:  passwordEnv,
+
+			BrokerSASLMechanism: brokerSecurity.SASLMechanism,
+			BrokerUser:          brokerSecurity.Username,
+			BrokerPasswordFile:  brokerPasswordFile,
+			BrokerPasswordEnv:   brokerPasswordEnv,
+			BrokerTLS:           brokerSecurity.TLS,
+			BrokerCAFile:        brokerSecurity.CAFile,
+			BrokerCertFile:      brokerSecurity.CertFile,
+			BrokerKeyFile:       brokerSecurity.KeyFile,
+		}
+		if cmd.Flags().Changed("tenant") {
+			ctx.Tenant = tenantName
+		}
+
+		if existing := cfg.context(name); existing != nil {
+			if !force {
+				return fmt.Errorf("context '%s' already exists (use --force to replace it)", name)
+			}
+			*existing = *ctx
+		} else {
+			cfg.Contexts = append(cfg.Contexts, ctx)
+		}
+		if cfg.CurrentContext == "" {
+			cfg.CurrentContext = name
+		}
+
+		if err := cfg.save(path); err != nil {
+			return err
+		}
+
+		if passwordInURL {
+			fmt.Fprintln(cmd.ErrOrStderr(), "⚠️  The database URL contains a password. Consider --password-file or --password-env instead.")
+		}
+		return renderOne(cmd, newContextView(ctx, cfg.CurrentContext), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Context '%s' saved to %s\n", name, path)
+			if cfg.CurrentContext == name {
+				fmt.Fprintf(w, "Current context: %s\n", name)
+			}
+		})
+	},
+}
+
+var contextUseCmd = &cobra.Command{
+	Use:   "use [name]",
+	Short: "Set the current context",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return err
+		}
+
+		ctx := cfg.context(name)
+		if ctx == nil {
+			return fmt.Errorf("context '%s' not found in %s", name, path)
+		}
+		cfg.CurrentContext = name
+		if err := cfg.save(path); err != nil {
+			return err
+		}
+
+		return renderOne(cmd, newContextView(ctx, name), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Switched to context '%s'\n", name)
+		})
+	},
+}
+
+var contextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List contexts",
+	Long:  `List the contexts in the config file. Passwords in database URLs are masked.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		cfg, err := loadConfig(path)
+		if err != nil {
+			return err
+		}
+
+		views := make([]contextView, 0, len(cfg.Contexts))
+		for _, ctx := range cfg.Contexts {
+			views = append(views, newContextView(ctx, cfg.CurrentContext))
+		}
+
+		return renderList(cmd, views, func(w io.Writer) {
+			if len(views) == 0 {
+				fmt.Fprintf(w, "No contexts found in %s\n", path)
+				return
+			}
+
+			fmt.Fprintf(w, "%-8s %-20s %-60s %-25s %-20s\n", "Current", "Name", "DB URL", "Broker URL", "Tenant")
+			fmt.Fprintf(w, "%s\n", "------------------------------------------------------------------------------------------------------------------------------------")
+			for _, v := range views {
+				current := ""
+				if v.Current {
+					current = "*"
+				}
+				fmt.Fprintf(w, "%-8s %-20s %-60s %-25s %-20s\n", current, v.Name, v.DBURL, v.BrokerURL, v.Tenant)
+			}
+		})
+	},
+}
+
+func init() {
+	contextAddCmd.Flags().String("password-file", "", "File holding the database password")
+	contextAddCmd.Flags().String("password-env", "", "Environment variable holding the database password")
+	contextAddCmd.Flags().String("broker-password-env", "", "Environment variable holding the broker SASL password")
+	contextAddCmd.Flags().Bool("force", false, "Replace an existing context with the same name")
+	contextAddCmd.MarkFlagsMutuallyExclusive("password-file", "password-env")
+
+	contextCmd.AddCommand(contextAddCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextListCmd)
+}
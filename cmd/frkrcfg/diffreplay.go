@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/frkr-io/frkr-tools/pkg/traffic"
+	"github.com/spf13/cobra"
+)
+
+// diffMismatchView is a request whose responses differ
+type diffMismatchView struct {
+	Partition   int                  `json:"partition" yaml:"partition"`
+	Offset      int64                `json:"offset" yaml:"offset"`
+	RequestID   string               `json:"request_id,omitempty" yaml:"request_id,omitempty"`
+	Method      string               `json:"method" yaml:"method"`
+	URL         string               `json:"url" yaml:"url"`
+	Differences []traffic.Difference `json:"differences" yaml:"differences"`
+}
+
+// diffReplayView is the rendered result of a diff replay
+type diffReplayView struct {
+	Stream      string             `json:"stream" yaml:"stream"`
+	Baseline    string             `json:"baseline" yaml:"baseline"`
+	Candidate   string             `json:"candidate" yaml:"candidate"`
+	Compared    int                `json:"compared" yaml:"compared"`
+	Matched     int                `json:"matched" yaml:"matched"`
+	Mismatched  int                `json:"mismatched" yaml:"mismatched"`
+	Skipped     int                `json:"skipped" yaml:"skipped"`
+	ByKind      map[string]int     `json:"by_kind" yaml:"by_kind"`
+	Mismatches  []diffMismatchView `json:"mismatches" yaml:"mismatches"`
+	Interrupted bool               `json:"interrupted,omitempty" yaml:"interrupted,omitempty"`
+}
+
+func (v diffReplayView) resourceName() string { return "diff-replay/" + v.Stream }
+
+// add records the differences found for one request
+func (v *diffReplayView) add(rec traffic.Record, diffs []traffic.Difference) {
+	v.Compared++
+	if len(diffs) == 0 {
+		v.Matched++
+		return
+	}
+
+	v.Mismatched++
+	kinds := make(map[string]bool)
+	for _, d := range diffs {
+		kinds[d.Kind] = true
+	}
+	for kind := range kinds {
+		v.ByKind[kind]++
+	}
+	v.Mismatches = append(v.Mismatches, diffMismatchView{
+		Partition:   rec.Partition,
+		Offset:      rec.Offset,
+		RequestID:   rec.Request.RequestID,
+		Method:      rec.Request.Method,
+		URL:         rec.Request.URL(),
+		Differences: diffs,
+	})
+}
+
+var streamDiffReplayCmd = &cobra.Command{
+	Use:   "diff-replay [stream-name-or-id]",
+	Short: "Replay a stream to two targets and compare their responses",
+	Long: `Send each request mirrored into a stream to both --baseline and --candidate
+and compare the responses: status codes, headers and bodies. JSON bodies are
+compared field by field; other bodies byte for byte.
+
+Volatile values can be excluded: --ignore-header adds to the headers that are
+never compared (Date, Etag, Set-Cookie, X-Request-Id, ...), and
+--ignore-field removes JSON body fields by JSONPath, e.g. $..id or
+$.items[*].created_at. --no-headers skips header comparison altogether.
+
+Both targets receive every request, including ones that change data.
+Requests are selected with --from, --to and --filter like 'stream replay',
+and sent as fast as --concurrency allows unless --speed is given, after
+being rewritten by --rules. The command exits non-zero if any request
+differs.`,
+	Example: `  frkrcfg stream diff-replay my-api --from 1h \
+    --baseline http://old-service:8080 --candidate http://new-service:8080 \
+    --ignore-field '$..updated_at' --ignore-field '$.request_id' --broker-url localhost:19092`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		baselineURL, _ := cmd.Flags().GetString("baseline")
+		baseline, err := traffic.NewTarget(baselineURL, timeout)
+		if err != nil {
+			return err
+		}
+		candidateURL, _ := cmd.Flags().GetString("candidate")
+		candidate, err := traffic.NewTarget(candidateURL, timeout)
+		if err != nil {
+			return err
+		}
+
+		diffOpts, err := diffOptions(cmd)
+		if err != nil {
+			return err
+		}
+		readOpts, err := replayWindow(cmd, time.Now())
+		if err != nil {
+			return err
+		}
+		speedFlag, _ := cmd.Flags().GetString("speed")
+		speed, err := parseSpeed(speedFlag)
+		if err != nil {
+			return err
+		}
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+		filterExprs, _ := cmd.Flags().GetStringArray("filter")
+		filters, err := traffic.ParseFilters(filterExprs)
+		if err != nil {
+			return err
+		}
+		rules, err := loadRules(cmd)
+		if err != nil {
+			return err
+		}
+
+		stream, err := resolveStreamTopic(args[0])
+		if err != nil {
+			return err
+		}
+		rewriter, err := rules.Rewriter(stream.Name)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		view := diffReplayView{
+			Stream:    stream.Name,
+			Baseline:  baselineURL,
+			Candidate: candidateURL,
+			ByKind:    make(map[string]int),
+		}
+		var mu sync.Mutex
+		replayer := traffic.NewReplayer(ctx, traffic.ReplayOptions{Speed: speed, Concurrency: concurrency},
+			func(ctx context.Context, rec traffic.Record) {
+				var b, c traffic.Result
+				var wg sync.WaitGroup
+				wg.Add(2)
+				go func() { defer wg.Done(); b = baseline.Do(ctx, rec.Request) }()
+				go func() { defer wg.Done(); c = candidate.Do(ctx, rec.Request) }()
+				wg.Wait()
+				if ctx.Err() != nil {
+					return
+				}
+
+				diffs := traffic.Compare(b, c, diffOpts)
+				mu.Lock()
+				defer mu.Unlock()
+				view.add(rec, diffs)
+			})
+
+		bm, err := newBrokerManager()
+		if err != nil {
+			return err
+		}
+		err = bm.ReadTopic(ctx, stream.Topic, readOpts, func(msg broker.Message) error {
+			rec, err := traffic.FromMessage(msg)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  Skipping message: %v\n", err)
+				view.Skipped++
+				return nil
+			}
+			if !traffic.MatchAll(filters, rec.Request) {
+				return nil
+			}
+			if err := rewriter.Apply(ctx, &rec.Request); err != nil {
+				return err
+			}
+			return replayer.Submit(ctx, rec)
+		})
+		replayer.Wait()
+
+		view.Interrupted = errors.Is(err, context.Canceled) || ctx.Err() != nil
+		if err != nil && !view.Interrupted {
+			return err
+		}
+
+		// Workers finish out of order; report mismatches in topic order
+		sort.Slice(view.Mismatches, func(i, j int) bool {
+			a, b := view.Mismatches[i], view.Mismatches[j]
+			if a.Partition != b.Partition {
+				return a.Partition < b.Partition
+			}
+			return a.Offset < b.Offset
+		})
+		if view.Mismatches == nil {
+			view.Mismatches = []diffMismatchView{}
+		}
+
+		if err := renderOne(cmd, view, func(w io.Writer) {
+			printDiffReplay(w, view)
+		}); err != nil {
+			return err
+		}
+		if view.Mismatched > 0 {
+			return fmt.Errorf("%d of %d request(s) differ", view.Mismatched, view.Compared)
+		}
+		return nil
+	},
+}
+
+func init() {
+	streamDiffReplayCmd.Flags().String("baseline", "", "Base URL of the reference service (required)")
+	streamDiffReplayCmd.Flags().String("candidate", "", "Base URL of the service under test (required)")
+	streamDiffReplayCmd.Flags().String("from", "", "Replay requests captured at or after this time (RFC 3339 or an age, e.g. 1h); default: the oldest message")
+	streamDiffReplayCmd.Flags().String("to", "", "Replay requests captured up to this time (RFC 3339 or an age); default: the current end of the topic")
+	streamDiffReplayCmd.Flags().StringArray("ignore-header", nil, "Response header not to compare (repeatable)")
+	streamDiffReplayCmd.Flags().StringArray("ignore-field", nil, "JSONPath of a body field not to compare, e.g. $..id (repeatable)")
+	streamDiffReplayCmd.Flags().Bool("no-headers", false, "Do not compare response headers")
+	streamDiffReplayCmd.Flags().String("speed", "max", "Pacing: realtime, Nx (e.g. 10x) or max")
+	streamDiffReplayCmd.Flags().Int("concurrency", 1, "Number of requests in flight at most (each goes to both targets)")
+	streamDiffReplayCmd.Flags().Duration("timeout", 10*time.Second, "Timeout for each request")
+	streamDiffReplayCmd.Flags().StringArray("filter", nil, "Only replay requests matching field=pattern (repeatable)")
+	streamDiffReplayCmd.Flags().String("rules", "", "Rules file for rewriting requests before they are sent")
+	streamDiffReplayCmd.MarkFlagRequired("baseline")
+	streamDiffReplayCmd.MarkFlagRequired("candidate")
+
+	streamCmd.AddCommand(streamDiffReplayCmd)
+}
+
+// diffOptions reads the comparison flags
+func diffOptions(cmd *cobra.Command) (traffic.DiffOptions, error) {
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+	opts := traffic.DiffOptions{CompareHeaders: !noHeaders}
+	opts.IgnoreHeaders, _ = cmd.Flags().GetStringArray("ignore-header")
+
+	fields, _ := cmd.Flags().GetStringArray("ignore-field")
+	for _, expr := range fields {
+		path, err := traffic.ParseJSONPath(expr)
+		if err != nil {
+			return opts, err
+		}
+		opts.IgnoreFields = append(opts.IgnoreFields, path)
+	}
+	return opts, nil
+}
+
+func printDiffReplay(w io.Writer, view diffReplayView) {
+	for _, m := range view.Mismatches {
+		fmt.Fprintf(w, "❌ %s %s  (partition %d offset %d", m.Method, m.URL, m.Partition, m.Offset)
+		if m.RequestID != "" {
+			fmt.Fprintf(w, ", %s", m.RequestID)
+		}
+		fmt.Fprintln(w, ")")
+		for _, d := range m.Differences {
+			fmt.Fprintf(w, "     %s\n", d)
+		}
+	}
+	if len(view.Mismatches) > 0 {
+		fmt.Fprintln(w)
+	}
+
+	if view.Interrupted {
+		fmt.Fprintf(w, "⚠️  Diff replay interrupted\n")
+	}
+	fmt.Fprintf(w, "Compared %d request(s) from stream '%s': %d matched, %d differ\n",
+		view.Compared, view.Stream, view.Matched, view.Mismatched)
+	for _, kind := range traffic.SortedKeys(view.ByKind) {
+		fmt.Fprintf(w, "  %s: %d\n", kind, view.ByKind[kind])
+	}
+	if view.Skipped > 0 {
+		fmt.Fprintf(w, "  skipped (not a mirrored request): %d\n", view.Skipped)
+	}
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/frkr-io/frkr-tools/pkg/db"
+	"github.com/frkr-io/frkr-tools/pkg/schema"
+	"github.com/spf13/cobra"
+)
+
+// Check statuses, from best to worst
+const (
+	checkOK      = "ok"
+	checkSkipped = "skipped"
+	checkWarning = "warning"
+	checkProblem = "problem"
+)
+
+var checkSymbols = map[string]string{
+	checkOK:      "✅",
+	checkSkipped: "➖",
+	checkWarning: "⚠️ ",
+	checkProblem: "❌",
+}
+
+// doctorCheck is the result of one consistency check
+type doctorCheck struct {
+	Name    string   `json:"name" yaml:"name"`
+	Status  string   `json:"status" yaml:"status"`
+	Summary string   `json:"summary" yaml:"summary"`
+	Items   []string `json:"items,omitempty" yaml:"items,omitempty"`
+}
+
+// doctorReport is the rendered form of 'frkrcfg doctor'
+type doctorReport struct {
+	Status   string        `json:"status" yaml:"status"`
+	Problems int           `json:"problems" yaml:"problems"`
+	Warnings int           `json:"warnings" yaml:"warnings"`
+	Checks   []doctorCheck `json:"checks" yaml:"checks"`
+}
+
+func (r doctorReport) resourceName() string { return "doctor/" + r.Status }
+
+func newDoctorReport(checks []doctorCheck) doctorReport {
+	report := doctorReport{Status: checkOK, Checks: checks}
+	for _, c := range checks {
+		switch c.Status {
+		case checkProblem:
+			report.Problems++
+			report.Status = checkProblem
+		case checkWarning:
+			report.Warnings++
+			if report.Status != checkProblem {
+				report.Status = checkWarning
+			}
+		}
+	}
+	return report
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the database and broker for drift",
+	Long: `Check a frkr deployment for drift between the database and the broker.
+
+Checks, across all tenants (--tenant is ignored):
+  schema            schema version, dirty flag and pending migrations
+  stream-topics     active streams whose topic does not exist on the broker
+  orphan-topics     stream topics on the broker with no stream row
+  client-streams    active clients scoped to a deleted stream
+  empty-tenants     active tenants without any active stream
+  user-tenants      active users of a deleted tenant
+
+The broker checks need --broker-url and are skipped without it. The command
+exits non-zero when a check finds a problem (or a warning, with
+--fail-on-warning); use -o json or -o yaml for a machine-readable report.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		checks := []doctorCheck{checkSchema()}
+		checks = append(checks, checkTopics(conn)...)
+		checks = append(checks,
+			checkClientStreams(conn),
+			checkEmptyTenants(conn),
+			checkUserTenants(conn),
+		)
+		report := newDoctorReport(checks)
+
+		err = renderOne(cmd, report, func(w io.Writer) {
+			for _, c := range report.Checks {
+				fmt.Fprintf(w, "%s %-15s %s\n", checkSymbols[c.Status], c.Name, c.Summary)
+				for _, item := range c.Items {
+					fmt.Fprintf(w, "   %-15s - %s\n", "", item)
+				}
+			}
+			fmt.Fprintf(w, "\n%d problem(s), %d warning(s)\n", report.Problems, report.Warnings)
+		})
+		if err != nil {
+			return err
+		}
+
+		failOnWarning, _ := cmd.Flags().GetBool("fail-on-warning")
+		if report.Problems > 0 || (failOnWarning && report.Warnings > 0) {
+			return fmt.Errorf("doctor found %d problem(s) and %d warning(s)", report.Problems, report.Warnings)
+		}
+		return nil
+	},
+}
+
+func init() {
+	doctorCmd.Flags().Bool("fail-on-warning", false, "Exit non-zero on warnings as well as problems")
+}
+
+// checkSchema reports the schema version and whether it needs attention
+func checkSchema() doctorCheck {
+	check := doctorCheck{Name: "schema"}
+
+	mg, err := openMigrator()
+	if err != nil {
+		return failedCheck(check, err)
+	}
+	defer mg.Close()
+
+	status, err := mg.Status()
+	if err != nil {
+		return failedCheck(check, err)
+	}
+	available, err := schema.Available()
+	if err != nil {
+		return failedCheck(check, err)
+	}
+
+	return schemaCheck(status, available)
+}
+
+// schemaCheck classifies a schema status against the available migrations
+func schemaCheck(status *schema.Status, available []schema.Migration) doctorCheck {
+	check := doctorCheck{Name: "schema", Status: checkOK, Summary: fmt.Sprintf("version %d", status.Version)}
+
+	switch {
+	case status.Dirty:
+		check.Status = checkProblem
+		check.Summary = fmt.Sprintf("migration %d failed part-way (dirty); see 'frkrcfg migrate status'", status.Version)
+	case len(status.Pending) > 0:
+		check.Status = checkProblem
+		check.Summary = fmt.Sprintf("version %d, %d pending migration(s); run 'frkrcfg migrate'", status.Version, len(status.Pending))
+		for _, m := range status.Pending {
+			check.Items = append(check.Items, fmt.Sprintf("%d %s", m.Version, m.Name))
+		}
+	case len(available) > 0 && status.Version > available[len(available)-1].Version:
+		check.Status = checkWarning
+		check.Summary = fmt.Sprintf("version %d is newer than the latest migration this frkrcfg knows (%d)",
+			status.Version, available[len(available)-1].Version)
+	}
+
+	return check
+}
+
+// checkTopics compares stream rows with the topics on the broker
+func checkTopics(conn *sql.DB) []doctorCheck {
+	missing := doctorCheck{Name: "stream-topics"}
+	orphans := doctorCheck{Name: "orphan-topics"}
+
+	if brokerURL == "" {
+		missing.Status, missing.Summary = checkSkipped, "no --broker-url given"
+		orphans.Status, orphans.Summary = checkSkipped, "no --broker-url given"
+		return []doctorCheck{missing, orphans}
+	}
+
+	streamTopics, err := db.ListStreamTopics(conn)
+	if err != nil {
+		return []doctorCheck{failedCheck(missing, err), failedCheck(orphans, err)}
+	}
+	bm, err := newBrokerManager()
+	if err != nil {
+		return []doctorCheck{failedCheck(missing, err), failedCheck(orphans, err)}
+	}
+	brokerTopics, err := bm.ListTopics()
+	if err != nil {
+		return []doctorCheck{failedCheck(missing, err), failedCheck(orphans, err)}
+	}
+
+	missing, orphans = topicChecks(streamTopics, brokerTopics)
+	return []doctorCheck{missing, orphans}
+}
+
+// topicChecks finds active streams without a topic and stream topics
+// without a stream row. Topics of soft-deleted streams are not orphans:
+// they are removed by 'stream purge --delete-topics'.
+func topicChecks(streamTopics []db.StreamTopic, brokerTopics []string) (missing, orphans doctorCheck) {
+	missing = doctorCheck{Name: "stream-topics", Status: checkOK}
+	orphans = doctorCheck{Name: "orphan-topics", Status: checkOK}
+
+	onBroker := make(map[string]bool, len(brokerTopics))
+	for _, topic := range brokerTopics {
+		onBroker[topic] = true
+	}
+	known := make(map[string]bool, len(streamTopics))
+	active := 0
+	for _, st := range streamTopics {
+		known[st.Topic] = true
+		if st.Deleted {
+			continue
+		}
+		active++
+		if !onBroker[st.Topic] {
+			missing.Items = append(missing.Items, fmt.Sprintf("%s/%s (%s)", st.TenantName, st.StreamName, st.Topic))
+		}
+	}
+	for _, topic := range brokerTopics {
+		if strings.HasPrefix(topic, db.TopicPrefix) && !known[topic] {
+			orphans.Items = append(orphans.Items, topic)
+		}
+	}
+
+	if len(missing.Items) > 0 {
+		missing.Status = checkProblem
+		missing.Summary = fmt.Sprintf("%d active stream(s) have no topic on the broker; recreate the topic or delete the stream", len(missing.Items))
+	} else {
+		missing.Summary = fmt.Sprintf("all %d active stream(s) have a topic", active)
+	}
+	if len(orphans.Items) > 0 {
+		orphans.Status = checkWarning
+		orphans.Summary = fmt.Sprintf("%d stream topic(s) on the broker have no stream row", len(orphans.Items))
+	} else {
+		orphans.Summary = "no orphan topics"
+	}
+
+	return missing, orphans
+}
+
+// checkClientStreams finds active clients scoped to a deleted stream
+func checkClientStreams(conn *sql.DB) doctorCheck {
+	check := doctorCheck{Name: "client-streams", Status: checkOK, Summary: "no clients scoped to deleted streams"}
+
+	clients, err := db.ListClientsOnDeletedStreams(conn)
+	if err != nil {
+		return failedCheck(check, err)
+	}
+	for _, c := range clients {
+		check.Items = append(check.Items, fmt.Sprintf("%s/%s (stream %s)", c.TenantName, c.ClientID, c.StreamName))
+	}
+	if len(check.Items) > 0 {
+		check.Status = checkProblem
+		check.Summary = fmt.Sprintf("%d active client(s) are scoped to a deleted stream; restore the stream or revoke the client", len(check.Items))
+	}
+	return check
+}
+
+// checkEmptyTenants finds active tenants without active streams
+func checkEmptyTenants(conn *sql.DB) doctorCheck {
+	check := doctorCheck{Name: "empty-tenants", Status: checkOK, Summary: "every tenant has a stream"}
+
+	names, err := db.ListTenantNamesWithoutStreams(conn)
+	if err != nil {
+		return failedCheck(check, err)
+	}
+	if len(names) > 0 {
+		check.Status = checkWarning
+		check.Summary = fmt.Sprintf("%d tenant(s) have no streams", len(names))
+		check.Items = names
+	}
+	return check
+}
+
+// checkUserTenants finds active users of deleted tenants
+func checkUserTenants(conn *sql.DB) doctorCheck {
+	check := doctorCheck{Name: "user-tenants", Status: checkOK, Summary: "no users in deleted tenants"}
+
+	users, err := db.ListUsersInMissingTenants(conn)
+	if err != nil {
+		return failedCheck(check, err)
+	}
+	for _, u := range users {
+		check.Items = append(check.Items, fmt.Sprintf("%s/%s (tenant %s)", u.TenantName, u.Username, u.TenantID))
+	}
+	if len(check.Items) > 0 {
+		check.Status = checkProblem
+		check.Summary = fmt.Sprintf("%d active user(s) belong to a deleted tenant", len(check.Items))
+	}
+	return check
+}
+
+// failedCheck marks a check as a problem because it could not run
+func failedCheck(check doctorCheck, err error) doctorCheck {
+	check.Status = checkProblem
+	check.Summary = "check failed: " + err.Error()
+	check.Items = nil
+	return check
+}
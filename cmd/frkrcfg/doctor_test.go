@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/frkr-io/frkr-tools/pkg/db"
+	"github.com/frkr-io/frkr-tools/pkg/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchemaCheck(t *testing.T) {
+	available := []schema.Migration{{Version: 1, Name: "init"}, {Version: 2, Name: "users"}}
+
+	check := schemaCheck(&schema.Status{Version: 2}, available)
+	require.Equal(t, checkOK, check.Status)
+
+	check = schemaCheck(&schema.Status{Version: 2, Dirty: true}, available)
+	require.Equal(t, checkProblem, check.Status)
+	require.Contains(t, check.Summary, "dirty")
+
+	check = schemaCheck(&schema.Status{Version: 1, Pending: available[1:]}, available)
+	require.Equal(t, checkProblem, check.Status)
+	require.Equal(t, []string{"2 users"}, check.Items)
+
+	check = schemaCheck(&schema.Status{Version: 3}, available)
+	require.Equal(t, checkWarning, check.Status)
+}
+
+func TestTopicChecks(t *testing.T) {
+	streams := []db.StreamTopic{
+		{TenantName: "default", StreamName: "orders", Topic: "stream-abc-orders"},
+		{TenantName: "default", StreamName: "users", Topic: "stream-abc-users"},
+		{TenantName: "default", StreamName: "old", Topic: "stream-abc-old", Deleted: true},
+	}
+
+	missing, orphans := topicChecks(streams, []string{
+		"stream-abc-orders",
+		"stream-abc-old",
+		"stream-abc-leftover",
+		"unrelated",
+	})
+	require.Equal(t, checkProblem, missing.Status)
+	require.Equal(t, []string{"default/users (stream-abc-users)"}, missing.Items)
+	require.Equal(t, checkWarning, orphans.Status)
+	require.Equal(t, []string{"stream-abc-leftover"}, orphans.Items)
+
+	missing, orphans = topicChecks(streams[:1], []string{"stream-abc-orders"})
+	require.Equal(t, checkOK, missing.Status)
+	require.Equal(t, checkOK, orphans.Status)
+}
+
+func TestNewDoctorReport(t *testing.T) {
+	report := newDoctorReport([]doctorCheck{{Status: checkOK}, {Status: checkSkipped}})
+	require.Equal(t, checkOK, report.Status)
+
+	report = newDoctorReport([]doctorCheck{{Status: checkWarning}, {Status: checkOK}})
+	require.Equal(t, checkWarning, report.Status)
+	require.Equal(t, 1, report.Warnings)
+
+	report = newDoctorReport([]doctorCheck{{Status: checkProblem}, {Status: checkWarning}})
+	require.Equal(t, checkProblem, report.Status)
+	require.Equal(t, 1, report.Problems)
+	require.Equal(t, 1, report.Warnings)
+	require.Equal(t, "doctor/problem", report.resourceName())
+}
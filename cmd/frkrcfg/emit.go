@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Formats accepted by --emit
+const (
+	emitK8sSecret = "k8s-secret"
+	emitDotenv    = "dotenv"
+	emitJSONSDK   = "json-sdk"
+)
+
+// credential is a newly issued client or user credential, in the shape
+// --emit writes it out
+type credential struct {
+	// Kind is "client" or "user"
+	Kind string
+	// ID is the client ID or the username
+	ID           string
+	Secret       string
+	Tenant       string
+	Stream       string
+	IngestURL    string
+	StreamingURL string
+}
+
+// envVar is one variable of an emitted credential
+type envVar struct {
+	Key   string
+	Value string
+}
+
+// env lists the credential as environment variables, in the order they are
+// emitted. The same names are used for dotenv files and Secret keys, so a
+// Secret can be consumed with envFrom.
+func (c credential) env() []envVar {
+	var vars []envVar
+	if c.Kind == "client" {
+		vars = append(vars, envVar{"FRKR_CLIENT_ID", c.ID}, envVar{"FRKR_CLIENT_SECRET", c.Secret})
+	} else {
+		vars = append(vars, envVar{"FRKR_USERNAME", c.ID}, envVar{"FRKR_PASSWORD", c.Secret})
+	}
+	vars = append(vars, envVar{"FRKR_TENANT", c.Tenant})
+	if c.Stream != "" {
+		vars = append(vars, envVar{"FRKR_STREAM", c.Stream})
+	}
+	if c.IngestURL != "" {
+		vars = append(vars, envVar{"FRKR_INGEST_URL", c.IngestURL})
+	}
+	if c.StreamingURL != "" {
+		vars = append(vars, envVar{"FRKR_STREAMING_URL", c.StreamingURL})
+	}
+	return vars
+}
+
+// addEmitFlags registers --emit and the gateway endpoint flags it uses
+func addEmitFlags(cmd *cobra.Command) {
+	cmd.Flags().String("emit", "", "Print the new credential as k8s-secret, dotenv or json-sdk instead of the usual output (written to --secret-out if set)")
+	cmd.Flags().String("ingest-url", "", "Ingest gateway URL to include in --emit output")
+	cmd.Flags().String("streaming-url", "", "Streaming gateway URL to include in --emit output")
+	cmd.Flags().String("k8s-name", "", "Name of the Secret for --emit k8s-secret (default: frkr-<kind>-<id>)")
+}
+
+// emitCredential renders cred in the --emit format, or returns "" when
+// --emit is not set. The gateway URLs are taken from the command's flags.
+func emitCredential(cmd *cobra.Command, cred credential) (string, error) {
+	format, _ := cmd.Flags().GetString("emit")
+	if format == "" {
+		return "", nil
+	}
+	if outputFormat != outputTable {
+		return "", fmt.Errorf("--emit cannot be combined with -o %s", outputFormat)
+	}
+
+	cred.IngestURL, _ = cmd.Flags().GetString("ingest-url")
+	cred.StreamingURL, _ = cmd.Flags().GetString("streaming-url")
+
+	switch format {
+	case emitK8sSecret:
+		name, _ := cmd.Flags().GetString("k8s-name")
+		return renderK8sSecret(cred, name)
+	case emitDotenv:
+		return renderDotenv(cred), nil
+	case emitJSONSDK:
+		return renderSDKConfig(cred)
+	default:
+		return "", fmt.Errorf("unknown --emit format '%s' (expected %s, %s, or %s)", format, emitK8sSecret, emitDotenv, emitJSONSDK)
+	}
+}
+
+// k8sSecret is the subset of a Kubernetes Secret manifest that frkrcfg writes
+type k8sSecret struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type"`
+	StringData map[string]string `yaml:"stringData"`
+}
+
+type k8sMetadata struct {
+	Name   string            `yaml:"name"`
+	Labels map[string]string `yaml:"labels"`
+}
+
+var invalidK8sNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// k8sSecretName derives a valid Secret name (a DNS-1123 label) from a
+// credential's kind and ID
+func k8sSecretName(cred credential) string {
+	name := invalidK8sNameChars.ReplaceAllString(strings.ToLower("frkr-"+cred.Kind+"-"+cred.ID), "-")
+	if len(name) > 63 {
+		name = name[:63]
+	}
+	return strings.TrimRight(name, "-")
+}
+
+// renderK8sSecret renders cred as a Secret manifest ready for kubectl apply
+func renderK8sSecret(cred credential, name string) (string, error) {
+	if name == "" {
+		name = k8sSecretName(cred)
+	}
+
+	secret := k8sSecret{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata: k8sMetadata{
+			Name: name,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "frkrcfg",
+				"frkr.io/tenant":               cred.Tenant,
+			},
+		},
+		Type:       "Opaque",
+		StringData: make(map[string]string),
+	}
+	for _, v := range cred.env() {
+		secret.StringData[v.Key] = v.Value
+	}
+
+	data, err := yaml.Marshal(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode Secret: %w", err)
+	}
+	return string(data), nil
+}
+
+var bareDotenvValue = regexp.MustCompile(`^[A-Za-z0-9_./:@=+-]*$`)
+
+// renderDotenv renders cred as KEY=value lines
+func renderDotenv(cred credential) string {
+	var b strings.Builder
+	for _, v := range cred.env() {
+		fmt.Fprintf(&b, "%s=%s\n", v.Key, quoteDotenv(v.Value))
+	}
+	return b.String()
+}
+
+// quoteDotenv quotes a value only when it needs it: single quotes keep it
+// literal, and double quotes with escapes are used when it contains a
+// single quote itself
+func quoteDotenv(value string) string {
+	if bareDotenvValue.MatchString(value) {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n") {
+		return "'" + value + "'"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`)
+	return `"` + r.Replace(value) + `"`
+}
+
+// sdkConfig is the options object taken by the frkr SDKs
+type sdkConfig struct {
+	GatewayURL   string `json:"gatewayUrl,omitempty"`
+	StreamingURL string `json:"streamingUrl,omitempty"`
+	ClientID     string `json:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	Tenant       string `json:"tenant"`
+	StreamID     string `json:"streamId,omitempty"`
+}
+
+// renderSDKConfig renders cred as an SDK configuration block
+func renderSDKConfig(cred credential) (string, error) {
+	cfg := sdkConfig{
+		GatewayURL:   cred.IngestURL,
+		StreamingURL: cred.StreamingURL,
+		Tenant:       cred.Tenant,
+		StreamID:     cred.Stream,
+	}
+	if cred.Kind == "client" {
+		cfg.ClientID, cfg.ClientSecret = cred.ID, cred.Secret
+	} else {
+		cfg.Username, cfg.Password = cred.ID, cred.Secret
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode SDK config: %w", err)
+	}
+	return string(data) + "\n", nil
+}
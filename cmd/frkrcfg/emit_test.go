@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestEmitCredential(t *testing.T) {
+	client := credential{
+		Kind:      "client",
+		ID:        "My_SDK",
+		Secret:    "abc123==",
+		Tenant:    "default",
+		Stream:    "my-api",
+		IngestURL: "http://localhost:8082",
+	}
+
+	t.Run("k8s secret", func(t *testing.T) {
+		out, err := renderK8sSecret(client, "")
+		require.NoError(t, err)
+
+		var secret k8sSecret
+		require.NoError(t, yaml.Unmarshal([]byte(out), &secret))
+		require.Equal(t, "Secret", secret.Kind)
+		require.Equal(t, "frkr-client-my-sdk", secret.Metadata.Name)
+		require.Equal(t, map[string]string{
+			"FRKR_CLIENT_ID":     "My_SDK",
+			"FRKR_CLIENT_SECRET": "abc123==",
+			"FRKR_TENANT":        "default",
+			"FRKR_STREAM":        "my-api",
+			"FRKR_INGEST_URL":    "http://localhost:8082",
+		}, secret.StringData)
+
+		out, err = renderK8sSecret(client, "custom")
+		require.NoError(t, err)
+		require.Contains(t, out, "name: custom")
+	})
+
+	t.Run("dotenv", func(t *testing.T) {
+		user := credential{Kind: "user", ID: "alice", Secret: `it's a "pass" $word`, Tenant: "default"}
+		require.Equal(t, "FRKR_USERNAME=alice\n"+
+			`FRKR_PASSWORD="it's a \"pass\" \$word"`+"\n"+
+			"FRKR_TENANT=default\n", renderDotenv(user))
+
+		require.Equal(t, "'two words'", quoteDotenv("two words"))
+		require.Equal(t, "abc123==", quoteDotenv("abc123=="))
+	})
+
+	t.Run("json sdk", func(t *testing.T) {
+		out, err := renderSDKConfig(client)
+		require.NoError(t, err)
+
+		var cfg map[string]string
+		require.NoError(t, json.Unmarshal([]byte(out), &cfg))
+		require.Equal(t, map[string]string{
+			"gatewayUrl":   "http://localhost:8082",
+			"clientId":     "My_SDK",
+			"clientSecret": "abc123==",
+			"tenant":       "default",
+			"streamId":     "my-api",
+		}, cfg)
+	})
+}
+
+func TestK8sSecretName(t *testing.T) {
+	require.Equal(t, "frkr-user-bob-smith", k8sSecretName(credential{Kind: "user", ID: "Bob_Smith"}))
+
+	long := k8sSecretName(credential{Kind: "client", ID: "a-very-long-client-id-that-goes-on-and-on-and-on-past-the-limit"})
+	require.LessOrEqual(t, len(long), 63)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/frkr-io/frkr-tools/pkg/db"
+	"github.com/spf13/cobra"
+)
+
+// exportView wraps an exported manifest so it can go through the shared renderer
+type exportView struct {
+	manifest `yaml:",inline"`
+}
+
+func (v exportView) resourceName() string {
+	if len(v.Tenants) == 0 {
+		return "tenant/"
+	}
+	return "tenant/" + v.Tenants[0].Name
+}
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export a tenant's configuration as YAML",
+	Long: `Write a tenant's streams, active users and clients in the format read by 'frkrcfg apply'.
+
+Passwords and client secrets are never exported. Applying the output to a
+database with the same tenant is a no-op; applying it to another database
+creates the same streams, users and clients there with fresh secrets.`,
+	Example: `  frkrcfg export --tenant default > frkr.yaml
+  frkrcfg export --tenant dev --db-url="$DEV_DB" | frkrcfg apply -f - --db-url="$STAGING_DB"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		state, err := readTenantState(cmd.Context(), db.NewStore(conn), tenantName)
+		if err != nil {
+			return err
+		}
+		if state.tenant == nil {
+			return fmt.Errorf("tenant '%s' not found", tenantName)
+		}
+
+		spec, skipped := exportTenant(state)
+		for _, clientID := range skipped {
+			fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  Skipping client '%s': it is scoped to a deleted stream\n", clientID)
+		}
+
+		// The table form of an export is the manifest itself
+		if outputFormat == outputTable {
+			outputFormat = outputYAML
+		}
+		return renderOne(cmd, exportView{manifest{Tenants: []tenantSpec{spec}}}, nil)
+	},
+}
+
+// exportTenant turns the current state of a tenant into a tenantSpec.
+// Disabled users are left out, as are clients scoped to a stream that no
+// longer exists, since apply could not recreate them; their client IDs
+// are returned so the caller can report them.
+func exportTenant(state *tenantState) (tenantSpec, []string) {
+	spec := tenantSpec{Name: state.tenant.Name}
+
+	streamNames := make(map[string]string)
+	for _, s := range state.streams {
+		streamNames[s.ID] = s.Name
+
+		stream := streamSpec{Name: s.Name}
+		if s.Description != "" {
+			description := s.Description
+			stream.Description = &description
+		}
+		days := s.RetentionDays
+		stream.RetentionDays = &days
+		if s.Status != "" {
+			status := s.Status
+			stream.Status = &status
+		}
+		spec.Streams = append(spec.Streams, stream)
+	}
+
+	for _, u := range state.users {
+		if userStatus(u) == "disabled" {
+			continue
+		}
+		spec.Users = append(spec.Users, userSpec{Username: u.Username})
+	}
+
+	var skipped []string
+	for _, c := range state.clients {
+		client := clientSpec{ClientID: c.ClientID}
+		if c.StreamID.Valid {
+			name, ok := streamNames[c.StreamID.String]
+			if !ok {
+				skipped = append(skipped, c.ClientID)
+				continue
+			}
+			client.Stream = name
+		}
+		spec.Clients = append(spec.Clients, client)
+	}
+
+	return spec, skipped
+}
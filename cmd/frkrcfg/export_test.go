@@ -0,0 +1,54 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/frkr-io/frkr-common/models"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExportTenant(t *testing.T) {
+	state := &tenantState{
+		tenant: &models.Tenant{ID: "tenant-1", Name: "dev"},
+		streams: []*models.Stream{
+			{ID: "stream-1", Name: "orders", Description: "Order API", Status: "active", RetentionDays: 14},
+			{ID: "stream-2", Name: "payments", Status: "paused", RetentionDays: 7},
+		},
+		users: []*models.TenantUser{
+			{ID: "user-1", Username: "alice", PasswordHash: "$2a$10$hash"},
+			{ID: "user-2", Username: "bob", DeletedAt: &sql.NullTime{Valid: true}},
+		},
+		clients: []*models.ClientCredential{
+			{ID: "client-1", ClientID: "orders-sdk", ClientSecret: "secret-123", StreamID: sql.NullString{String: "stream-1", Valid: true}},
+			{ID: "client-2", ClientID: "global-sdk", ClientSecret: "secret-456"},
+			{ID: "client-3", ClientID: "stale-sdk", StreamID: sql.NullString{String: "stream-gone", Valid: true}},
+		},
+	}
+
+	spec, skipped := exportTenant(state)
+	require.Equal(t, []string{"stale-sdk"}, skipped)
+	require.Equal(t, "paused", *spec.Streams[1].Status)
+
+	t.Run("secrets are not exported", func(t *testing.T) {
+		out, err := yaml.Marshal(manifest{Tenants: []tenantSpec{spec}})
+		require.NoError(t, err)
+		require.NotContains(t, string(out), "secret")
+		require.NotContains(t, string(out), "password")
+		require.NotContains(t, string(out), "bob")
+	})
+
+	t.Run("round-trips through apply", func(t *testing.T) {
+		out, err := yaml.Marshal(manifest{Tenants: []tenantSpec{spec}})
+		require.NoError(t, err)
+
+		path := writeManifest(t, string(out))
+		m, err := loadManifest(path)
+		require.NoError(t, err)
+		require.Len(t, m.Tenants, 1)
+
+		// stale-sdk is not in the export, so only a prune would touch it
+		require.Empty(t, diffTenant(m.Tenants[0], state, false))
+	})
+}
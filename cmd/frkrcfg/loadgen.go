@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/traffic"
+	"github.com/spf13/cobra"
+)
+
+// loadgenLatencyView holds latency percentiles in milliseconds
+type loadgenLatencyView struct {
+	P50 float64 `json:"p50" yaml:"p50"`
+	P95 float64 `json:"p95" yaml:"p95"`
+	P99 float64 `json:"p99" yaml:"p99"`
+	Max float64 `json:"max" yaml:"max"`
+}
+
+// loadgenView is the rendered result of a load run
+type loadgenView struct {
+	Stream  string `json:"stream" yaml:"stream"`
+	Gateway string `json:"gateway" yaml:"gateway"`
+	// Rate is the requested rate per second; 0 means as fast as possible
+	Rate        float64            `json:"rate" yaml:"rate"`
+	Sent        int                `json:"sent" yaml:"sent"`
+	Accepted    int                `json:"accepted" yaml:"accepted"`
+	Failed      int                `json:"failed" yaml:"failed"`
+	DurationMs  int64              `json:"duration_ms" yaml:"duration_ms"`
+	Throughput  float64            `json:"throughput" yaml:"throughput"`
+	Statuses    map[string]int     `json:"statuses" yaml:"statuses"`
+	Errors      map[string]int     `json:"errors,omitempty" yaml:"errors,omitempty"`
+	LatencyMs   loadgenLatencyView `json:"latency_ms" yaml:"latency_ms"`
+	Interrupted bool               `json:"interrupted,omitempty" yaml:"interrupted,omitempty"`
+}
+
+func (v loadgenView) resourceName() string { return "loadgen/" + v.Stream }
+
+func newLoadgenView(stream, gateway string, rate float64, report *traffic.LoadReport) loadgenView {
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	view := loadgenView{
+		Stream:     stream,
+		Gateway:    gateway,
+		Rate:       rate,
+		Sent:       report.Sent(),
+		Failed:     report.Failed(),
+		DurationMs: report.Elapsed.Milliseconds(),
+		Throughput: report.Throughput(),
+		Statuses:   make(map[string]int),
+		Errors:     report.Errors(),
+		LatencyMs: loadgenLatencyView{
+			P50: ms(report.Percentile(50)),
+			P95: ms(report.Percentile(95)),
+			P99: ms(report.Percentile(99)),
+			Max: ms(report.Percentile(100)),
+		},
+	}
+	for status, count := range report.Statuses() {
+		view.Statuses[strconv.Itoa(status)] = count
+		if status >= 200 && status < 300 {
+			view.Accepted += count
+		}
+	}
+	return view
+}
+
+var loadgenCmd = &cobra.Command{
+	Use:   "loadgen",
+	Short: "Send mirrored requests to an ingest gateway at a fixed rate",
+	Long: `Post mirrored requests to the ingest gateway's /ingest endpoint at --rate
+for --duration (or until --requests were sent) and report the throughput,
+the response status codes and the p50/p95/p99 latency. Use it to size
+gateways and brokers before mirroring more services.
+
+Requests are synthetic, with a JSON body of about --body-size bytes, or
+taken in turn from --file (NDJSON or HAR, as for 'stream import'). Each gets
+a new request_id and timestamp.
+
+The gateway is called with basic auth as --username, or with a bearer token
+fetched from --token-url with the client credentials grant as --client-id.
+Defaults come from FRKR_INGEST_URL, FRKR_STREAM, FRKR_USERNAME,
+FRKR_PASSWORD, FRKR_CLIENT_ID and FRKR_CLIENT_SECRET, as written by
+'user create --emit dotenv' and 'client create --emit dotenv'.
+
+When every --concurrency slot is busy, the next request waits, so the
+achieved throughput shows what the gateway sustains.`,
+	Example: `  frkrcfg loadgen --ingest-url http://localhost:8082 --stream my-api --rate 500/s --duration 2m \
+    --username load-user --password-file ~/.frkr/load-user
+  frkrcfg loadgen --stream my-api --file capture.har --rate max --requests 10000 --concurrency 100 \
+    --token-url https://idp.example.com/oauth/token --client-id load-client --client-secret-file secret.txt`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		gatewayURL := flagOrEnv(cmd, "ingest-url", "FRKR_INGEST_URL")
+		if gatewayURL == "" {
+			return fmt.Errorf("--ingest-url is required (or set FRKR_INGEST_URL)")
+		}
+		streamName := flagOrEnv(cmd, "stream", "FRKR_STREAM")
+		if streamName == "" {
+			return fmt.Errorf("--stream is required (or set FRKR_STREAM)")
+		}
+
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		ingester, err := traffic.NewIngester(gatewayURL, timeout)
+		if err != nil {
+			return err
+		}
+		if err := loadgenAuth(cmd, ingester); err != nil {
+			return err
+		}
+
+		opts, err := loadOptions(cmd)
+		if err != nil {
+			return err
+		}
+		next, err := loadgenRequests(cmd)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		runID := strconv.FormatInt(time.Now().Unix(), 36)
+		report := traffic.RunLoad(ctx, opts, func(ctx context.Context, n int) traffic.Result {
+			req := next(n)
+			req.RequestID = fmt.Sprintf("loadgen-%s-%d", runID, n)
+			req.TimestampNs = time.Now().UnixNano()
+			return ingester.Send(ctx, traffic.Envelope{StreamID: streamName, Request: req})
+		})
+
+		view := newLoadgenView(streamName, gatewayURL, opts.Rate, report)
+		view.Interrupted = ctx.Err() != nil
+		return renderOne(cmd, view, func(w io.Writer) {
+			printLoadgen(w, view)
+		})
+	},
+}
+
+func init() {
+	loadgenCmd.Flags().String("ingest-url", "", "Ingest gateway URL, e.g. http://localhost:8082 (default: $FRKR_INGEST_URL)")
+	loadgenCmd.Flags().String("stream", "", "Stream to publish to (default: $FRKR_STREAM)")
+	loadgenCmd.Flags().String("rate", "100/s", "Requests to start per second (N, N/s or N/m), or max")
+	loadgenCmd.Flags().Duration("duration", time.Minute, "How long to send requests (0: until --requests were sent or Ctrl-C)")
+	loadgenCmd.Flags().Int("requests", 0, "Stop after this many requests (0: no limit)")
+	loadgenCmd.Flags().Int("concurrency", 50, "Number of requests in flight at most")
+	loadgenCmd.Flags().Duration("timeout", 10*time.Second, "Timeout for each request")
+	loadgenCmd.Flags().StringP("file", "f", "", "NDJSON or HAR file of requests to send in turn, instead of synthetic ones")
+	loadgenCmd.Flags().String("format", "", "ndjson or har (default: from the file extension, else ndjson)")
+	loadgenCmd.Flags().Int("body-size", 256, "Approximate size in bytes of the body of synthetic requests")
+	loadgenCmd.Flags().String("username", "", "User for basic auth (default: $FRKR_USERNAME)")
+	addSecretInputFlags(loadgenCmd, "password", "Password for basic auth (default: $FRKR_PASSWORD)")
+	loadgenCmd.Flags().String("client-id", "", "Client ID for the client credentials grant (default: $FRKR_CLIENT_ID)")
+	addSecretInputFlags(loadgenCmd, "client-secret", "Client secret for the client credentials grant (default: $FRKR_CLIENT_SECRET)")
+	loadgenCmd.Flags().String("token-url", "", "OAuth token endpoint for the client credentials grant")
+	loadgenCmd.Flags().String("scope", "", "Scope to request with the client credentials grant")
+	loadgenCmd.MarkFlagsMutuallyExclusive("username", "client-id")
+}
+
+// flagOrEnv returns a string flag, or the environment variable env if the
+// flag is empty
+func flagOrEnv(cmd *cobra.Command, name, env string) string {
+	if value, _ := cmd.Flags().GetString(name); value != "" {
+		return value
+	}
+	return os.Getenv(env)
+}
+
+// loadgenAuth sets up basic auth or the client credentials grant for the
+// ingester from the command's flags and environment
+func loadgenAuth(cmd *cobra.Command, ingester *traffic.Ingester) error {
+	if username := flagOrEnv(cmd, "username", "FRKR_USERNAME"); username != "" {
+		password, err := readSecret(cmd, "password")
+		if err != nil {
+			return err
+		}
+		if password == "" {
+			password = os.Getenv("FRKR_PASSWORD")
+		}
+		if password == "" {
+			return fmt.Errorf("a password is required for --username (--password-file, --password-stdin or $FRKR_PASSWORD)")
+		}
+		ingester.WithBasicAuth(username, password)
+		return nil
+	}
+
+	if clientID := flagOrEnv(cmd, "client-id", "FRKR_CLIENT_ID"); clientID != "" {
+		tokenURL, _ := cmd.Flags().GetString("token-url")
+		if tokenURL == "" {
+			return fmt.Errorf("--token-url is required for --client-id")
+		}
+		secret, err := readSecret(cmd, "client-secret")
+		if err != nil {
+			return err
+		}
+		if secret == "" {
+			secret = os.Getenv("FRKR_CLIENT_SECRET")
+		}
+		if secret == "" {
+			return fmt.Errorf("a client secret is required for --client-id (--client-secret-file, --client-secret-stdin or $FRKR_CLIENT_SECRET)")
+		}
+		scope, _ := cmd.Flags().GetString("scope")
+		ingester.WithToken(&traffic.ClientCredentials{
+			TokenURL:     tokenURL,
+			ClientID:     clientID,
+			ClientSecret: secret,
+			Scope:        scope,
+		})
+		return nil
+	}
+
+	return fmt.Errorf("--username or --client-id is required to authenticate with the ingest gateway")
+}
+
+// loadOptions reads the pacing flags
+func loadOptions(cmd *cobra.Command) (traffic.LoadOptions, error) {
+	var opts traffic.LoadOptions
+	rate, _ := cmd.Flags().GetString("rate")
+	var err error
+	if opts.Rate, err = parseRate(rate); err != nil {
+		return opts, err
+	}
+	opts.Duration, _ = cmd.Flags().GetDuration("duration")
+	opts.Requests, _ = cmd.Flags().GetInt("requests")
+	opts.Concurrency, _ = cmd.Flags().GetInt("concurrency")
+
+	switch {
+	case opts.Duration < 0:
+		return opts, fmt.Errorf("--duration cannot be negative")
+	case opts.Requests < 0:
+		return opts, fmt.Errorf("--requests cannot be negative")
+	case opts.Concurrency < 1:
+		return opts, fmt.Errorf("--concurrency must be at least 1")
+	}
+	return opts, nil
+}
+
+// parseRate parses --rate: N, N/s or N/m requests, or max. It returns
+// requests per second, 0 for max.
+func parseRate(value string) (float64, error) {
+	if value == "max" {
+		return 0, nil
+	}
+
+	count, unit, _ := strings.Cut(value, "/")
+	per := time.Second
+	switch unit {
+	case "", "s":
+	case "m":
+		per = time.Minute
+	default:
+		count = ""
+	}
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate '%s' (expected e.g. 500/s, 1000/m or max)", value)
+	}
+	return n / per.Seconds(), nil
+}
+
+// loadgenRequests returns the function that makes the nth request: taken in
+// turn from --file, or synthetic
+func loadgenRequests(cmd *cobra.Command) (func(n int) traffic.Request, error) {
+	file, _ := cmd.Flags().GetString("file")
+	if file == "" {
+		bodySize, _ := cmd.Flags().GetInt("body-size")
+		if bodySize < 0 {
+			return nil, fmt.Errorf("--body-size cannot be negative")
+		}
+		return func(n int) traffic.Request { return syntheticRequest(n, bodySize) }, nil
+	}
+
+	format, err := trafficFormat(cmd, file)
+	if err != nil {
+		return nil, err
+	}
+	records, err := readTrafficFile(cmd, file, format)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s holds no requests", file)
+	}
+	return func(n int) traffic.Request { return records[n%len(records)].Request }, nil
+}
+
+// syntheticRequest makes a JSON POST whose body is about bodySize bytes
+func syntheticRequest(n, bodySize int) traffic.Request {
+	body := fmt.Sprintf(`{"seq":%d,"padding":""}`, n)
+	if pad := bodySize - len(body); pad > 0 {
+		body = fmt.Sprintf(`{"seq":%d,"padding":"%s"}`, n, strings.Repeat("x", pad))
+	}
+	return traffic.Request{
+		Method:   http.MethodPost,
+		Path:     "/loadgen/orders",
+		Headers:  map[string]string{"Content-Type": "application/json", "User-Agent": "frkrcfg-loadgen"},
+		Body:     body,
+		Response: &traffic.Response{Status: http.StatusCreated},
+	}
+}
+
+func printLoadgen(w io.Writer, view loadgenView) {
+	if view.Interrupted {
+		fmt.Fprintf(w, "⚠️  Load run interrupted\n")
+	}
+	rate := "max"
+	if view.Rate > 0 {
+		rate = strconv.FormatFloat(view.Rate, 'f', -1, 64) + "/s"
+	}
+	fmt.Fprintf(w, "Sent %d request(s) to %s for stream '%s' in %s (target rate %s)\n",
+		view.Sent, view.Gateway, view.Stream, (time.Duration(view.DurationMs) * time.Millisecond).String(), rate)
+	fmt.Fprintf(w, "  throughput: %.1f responses/s\n", view.Throughput)
+	fmt.Fprintf(w, "  accepted (2xx): %d\n", view.Accepted)
+	fmt.Fprintf(w, "  latency: p50 %.1fms  p95 %.1fms  p99 %.1fms  max %.1fms\n",
+		view.LatencyMs.P50, view.LatencyMs.P95, view.LatencyMs.P99, view.LatencyMs.Max)
+	for _, status := range traffic.SortedKeys(view.Statuses) {
+		fmt.Fprintf(w, "  %s: %d\n", status, view.Statuses[status])
+	}
+	if view.Failed > 0 {
+		fmt.Fprintf(w, "  failed: %d\n", view.Failed)
+		for _, msg := range traffic.SortedKeys(view.Errors) {
+			fmt.Fprintf(w, "    %dx %s\n", view.Errors[msg], msg)
+		}
+	}
+}
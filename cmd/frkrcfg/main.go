@@ -2,10 +2,14 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/frkr-io/frkr-tools/pkg/db"
 	_ "github.com/lib/pq"
 	"github.com/spf13/cobra"
 )
@@ -14,25 +18,55 @@ var rootCmd = &cobra.Command{
 	Use:   "frkrcfg",
 	Short: "frkrcfg - Direct configuration tool for local development",
 	Long:  `frkrcfg provides direct database access for local development without requiring Kubernetes.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		if err := validateOutputFormat(); err != nil {
+			return err
+		}
+		return resolveSettings(cmd)
+	},
 }
 
 var (
 	dbURL      string
 	tenantName string
+	brokerURL  string
 	outputFormat string
+	contextName    string
+	dbPasswordFile string
+
+	// brokerSecurity holds the broker SASL and TLS flags, completed from the
+	// environment and the context by resolveSettings
+	brokerSecurity     broker.Security
+	brokerPasswordFile string
 )
 
 func init() {
 	// Global flags
-	rootCmd.PersistentFlags().StringVar(&dbURL, "db-url", "", "Database connection URL (required)")
-	rootCmd.PersistentFlags().StringVar(&tenantName, "tenant", "default", "Tenant name (default: 'default')")
-	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", "text", "Output format (text, json)")
+	rootCmd.PersistentFlags().StringVar(&dbURL, "db-url", "", "Database connection URL (default: $FRKR_DB_URL or the context's URL)")
+	rootCmd.PersistentFlags().StringVar(&brokerURL, "broker-url", "", "Broker address (host:port) for topic operations (optional)")
+	rootCmd.PersistentFlags().StringVar(&tenantName, "tenant", "default", "Tenant name (default: $FRKR_TENANT, the context's tenant, or 'default')")
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", outputTable, "Output format (table, json, yaml, name)")
+	rootCmd.PersistentFlags().StringVar(&contextName, "context", "", "Context from the config file to use (default: the current context)")
+	rootCmd.PersistentFlags().StringVar(&dbPasswordFile, "db-password-file", "", "File holding the database password, instead of putting it in the URL")
+	rootCmd.PersistentFlags().StringVar(&brokerSecurity.SASLMechanism, "broker-sasl-mechanism", "", "Broker SASL mechanism: PLAIN, SCRAM-SHA-256 or SCRAM-SHA-512 (default: none)")
+	rootCmd.PersistentFlags().StringVar(&brokerSecurity.Username, "broker-user", "", "Broker SASL username")
+	rootCmd.PersistentFlags().StringVar(&brokerPasswordFile, "broker-password-file", "", "File holding the broker SASL password (default: $FRKR_BROKER_PASSWORD)")
+	rootCmd.PersistentFlags().BoolVar(&brokerSecurity.TLS, "broker-tls", false, "Connect to the broker over TLS")
+	rootCmd.PersistentFlags().StringVar(&brokerSecurity.CAFile, "broker-ca-file", "", "PEM CA bundle to verify the broker with, instead of the system roots (implies --broker-tls)")
+	rootCmd.PersistentFlags().StringVar(&brokerSecurity.CertFile, "broker-cert-file", "", "PEM client certificate for mutual TLS with the broker (implies --broker-tls)")
+	rootCmd.PersistentFlags().StringVar(&brokerSecurity.KeyFile, "broker-key-file", "", "PEM private key for --broker-cert-file")
 
 	rootCmd.AddCommand(streamCmd)
 	rootCmd.AddCommand(userCmd)
 	rootCmd.AddCommand(clientCmd)
 	rootCmd.AddCommand(migrateCmd)
 	rootCmd.AddCommand(tenantCmd)
+	rootCmd.AddCommand(applyCmd)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(contextCmd)
+	rootCmd.AddCommand(doctorCmd)
+	rootCmd.AddCommand(loadgenCmd)
 }
 
 func main() {
@@ -44,7 +78,7 @@ func main() {
 
 func getDB() (*sql.DB, error) {
 	if dbURL == "" {
-		return nil, fmt.Errorf("--db-url is required")
+		return nil, fmt.Errorf("--db-url is required (or set %s, or select a context with 'frkrcfg context use')", envDBURL)
 	}
 
 	// dbURL should be in postgres:// format (CockroachDB is PostgreSQL-compatible)
@@ -71,3 +105,26 @@ func getDB() (*sql.DB, error) {
 
 	return conn, nil
 }
+
+// newBrokerManager returns a Manager for --broker-url that connects with the
+// resolved broker SASL and TLS settings
+func newBrokerManager() (*broker.Manager, error) {
+	m, err := broker.NewSecureManager(brokerURL, brokerSecurity)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker settings: %w", err)
+	}
+	return m, nil
+}
+
+// resolveTenant looks up the --tenant tenant. It never creates one: tenants
+// are only created by 'frkrcfg tenant create' and 'frkrcfg apply'.
+func resolveTenant(conn *sql.DB) (*models.Tenant, error) {
+	tenant, err := db.GetTenantByName(conn, tenantName)
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("tenant '%s' not found (create it with 'frkrcfg tenant create %s')", tenantName, tenantName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+	return tenant, nil
+}
@@ -4,22 +4,36 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/frkr-io/frkr-common/migrate"
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/frkr-io/frkr-tools/pkg/db"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go/modules/cockroachdb"
+	"gopkg.in/yaml.v3"
 )
 
 func resetGlobals() {
 	dbURL = ""
+	outputFormat = outputTable
+	contextName = ""
+	dbPasswordFile = ""
+	brokerURL = ""
+	brokerSecurity = broker.Security{}
+	brokerPasswordFile = ""
 }
 
 func setupTestDBForCLI(t *testing.T) (*sql.DB, string) {
 	resetGlobals()
+	// Keep the developer's own contexts and FRKR_* settings out of the tests
+	useTempConfig(t)
 	ctx := context.Background()
 
 	// Start CockroachDB container
@@ -72,8 +86,29 @@ func setupTestDBForCLI(t *testing.T) (*sql.DB, string) {
 	return db, migrateURL
 }
 
+// runCLI executes rootCmd with args and returns what it wrote to stdout
+// followed by what it wrote to stderr
+func runCLI(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	rootCmd.SetArgs(args)
+	var outBuf, errBuf bytes.Buffer
+	rootCmd.SetOut(&outBuf)
+	rootCmd.SetErr(&errBuf)
+	err := rootCmd.Execute()
+	return outBuf.String() + errBuf.String(), err
+}
+
+// createTestTenants creates tenants up front; frkrcfg commands no longer create them implicitly
+func createTestTenants(t *testing.T, conn *sql.DB, names ...string) {
+	for _, name := range names {
+		_, err := db.CreateOrGetTenant(conn, name)
+		require.NoError(t, err)
+	}
+}
+
 func TestStreamCreateCommand(t *testing.T) {
-	_, dbURL := setupTestDBForCLI(t)
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "test-tenant", "test-tenant-2", "test-tenant-3")
 
 	t.Run("create stream successfully", func(t *testing.T) {
 		rootCmd.SetArgs([]string{
@@ -116,6 +151,56 @@ func TestStreamCreateCommand(t *testing.T) {
 		require.Contains(t, output, "default-retention-stream")
 	})
 
+	t.Run("duplicate stream fails before the topic is created", func(t *testing.T) {
+		t.Cleanup(resetGlobals)
+		// Nothing listens on this port, so reaching the broker would fail
+		// with a connection error rather than the duplicate-name error
+		_, err := runCLI(t, "stream", "create", "test-api",
+			"--db-url", dbURL,
+			"--tenant", "test-tenant",
+			"--broker-url", "127.0.0.1:1",
+		)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already exists")
+	})
+
+	t.Run("broker failure does not leave a stream row", func(t *testing.T) {
+		t.Cleanup(resetGlobals)
+		_, err := runCLI(t, "stream", "create", "unreachable-broker-stream",
+			"--db-url", dbURL,
+			"--tenant", "test-tenant",
+			"--broker-url", "127.0.0.1:1",
+		)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "failed to create topic")
+
+		tenant, err := db.GetTenantByName(conn, "test-tenant")
+		require.NoError(t, err)
+		_, err = db.GetStream(conn, tenant.ID, "unreachable-broker-stream")
+		require.ErrorIs(t, err, db.ErrNotFound)
+	})
+
+	t.Run("topic flags require broker url", func(t *testing.T) {
+		rootCmd.SetArgs([]string{
+			"stream", "create", "partitioned-stream",
+			"--db-url", dbURL,
+			"--tenant", "test-tenant",
+			"--partitions", "3",
+		})
+
+		var outBuf, errBuf bytes.Buffer
+		rootCmd.SetOut(&outBuf)
+		rootCmd.SetErr(&errBuf)
+
+		err := rootCmd.Execute()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "require --broker-url")
+
+		// Reset so later subtests don't inherit the flag
+		streamCreateCmd.Flags().Set("partitions", "1")
+		streamCreateCmd.Flags().Lookup("partitions").Changed = false
+	})
+
 	t.Run("create stream fails with invalid retention", func(t *testing.T) {
 		rootCmd.SetArgs([]string{
 			"stream", "create", "invalid-stream",
@@ -153,7 +238,8 @@ func TestStreamCreateCommand(t *testing.T) {
 }
 
 func TestStreamListCommand(t *testing.T) {
-	_, dbURL := setupTestDBForCLI(t)
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "list-tenant", "empty-tenant")
 
 	// Create a stream first
 	rootCmd.SetArgs([]string{
@@ -206,7 +292,8 @@ func TestStreamListCommand(t *testing.T) {
 }
 
 func TestStreamGetCommand(t *testing.T) {
-	_, dbURL := setupTestDBForCLI(t)
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "get-tenant")
 
 	// Create a stream first
 	rootCmd.SetArgs([]string{
@@ -256,6 +343,114 @@ func TestStreamGetCommand(t *testing.T) {
 	})
 }
 
+func TestStreamUpdateCommand(t *testing.T) {
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "update-tenant")
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, append(args, "--db-url", dbURL, "--tenant", "update-tenant")...)
+	}
+
+	_, err := run("stream", "create", "update-api", "--retention-days", "7")
+	require.NoError(t, err)
+
+	t.Run("update retention and status", func(t *testing.T) {
+		output, err := run("stream", "update", "update-api", "--retention-days", "30", "--status", "paused")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ Stream updated successfully")
+		require.Contains(t, output, "Retention:     30 days")
+		require.Contains(t, output, "Status:        paused")
+		require.Contains(t, output, "--broker-url not set")
+	})
+
+	t.Run("invalid retention fails", func(t *testing.T) {
+		_, err := run("stream", "update", "update-api", "--retention-days", "400")
+		require.Error(t, err)
+	})
+
+	t.Run("zero retention fails instead of resetting to the default", func(t *testing.T) {
+		_, err := run("stream", "update", "update-api", "--retention-days", "0")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least 1")
+	})
+
+	t.Run("invalid status fails", func(t *testing.T) {
+		_, err := run("stream", "update", "update-api", "--retention-days", "30", "--status", "archived")
+		require.Error(t, err)
+	})
+}
+
+func TestStreamRestoreAndPurgeCommands(t *testing.T) {
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "purge-tenant")
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, append(args, "--db-url", dbURL, "--tenant", "purge-tenant")...)
+	}
+
+	_, err := run("stream", "create", "purge-api")
+	require.NoError(t, err)
+	_, err = run("stream", "delete", "purge-api", "--force")
+	require.NoError(t, err)
+
+	t.Run("list deleted", func(t *testing.T) {
+		output, err := run("stream", "list", "--deleted")
+		require.NoError(t, err)
+		require.Contains(t, output, "Deleted streams for tenant 'purge-tenant'")
+		require.Contains(t, output, "purge-api")
+	})
+
+	t.Run("restore", func(t *testing.T) {
+		output, err := run("stream", "restore", "purge-api")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ Stream 'purge-api' restored")
+
+		output, err = run("stream", "list", "--deleted=false")
+		require.NoError(t, err)
+		require.Contains(t, output, "purge-api")
+	})
+
+	t.Run("purge requires force", func(t *testing.T) {
+		_, err := run("stream", "purge", "--older-than", "0")
+		require.Error(t, err)
+	})
+
+	t.Run("purge", func(t *testing.T) {
+		_, err := run("stream", "delete", "purge-api", "--force")
+		require.NoError(t, err)
+
+		output, err := run("stream", "purge", "--older-than", "0", "--force")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ Purged 1 stream(s) and 0 client(s)")
+
+		output, err = run("stream", "list", "--deleted")
+		require.NoError(t, err)
+		require.Contains(t, output, "No deleted streams found")
+	})
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"0", 0},
+		{"30d", 30 * 24 * time.Hour},
+		{"12h", 12 * time.Hour},
+		{"90m", 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := parseAge(tt.value)
+		require.NoError(t, err, tt.value)
+		require.Equal(t, tt.want, got, tt.value)
+	}
+
+	for _, value := range []string{"", "d", "-1d", "thirty days", "-5h"} {
+		_, err := parseAge(value)
+		require.Error(t, err, value)
+	}
+}
+
 func TestMigrateCommand(t *testing.T) {
 	_, dbURL := setupTestDBForCLI(t)
 
@@ -276,6 +471,44 @@ func TestMigrateCommand(t *testing.T) {
 		require.Contains(t, output, "✅ Migrations completed successfully")
 	})
 
+	t.Run("subcommands", func(t *testing.T) {
+		run := func(args ...string) (string, error) {
+			return runCLI(t, append(args, "--db-url", dbURL)...)
+		}
+		status := func() migrationStatusView {
+			out, err := run("migrate", "status", "-o", "json")
+			require.NoError(t, err)
+			var view migrationStatusView
+			require.NoError(t, json.Unmarshal([]byte(out), &view))
+			return view
+		}
+
+		initial := status()
+		require.False(t, initial.Dirty)
+		require.Empty(t, initial.Pending)
+
+		_, err := run("migrate", "down", "1", "-o", "table")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "--force")
+
+		_, err = run("migrate", "down", "0", "--force")
+		require.Error(t, err)
+
+		out, err := run("migrate", "down", "1", "--force")
+		require.NoError(t, err)
+		require.Contains(t, out, "Rolled back")
+		require.Len(t, status().Pending, 1)
+
+		out, err = run("migrate", "up", "-o", "table")
+		require.NoError(t, err)
+		require.Contains(t, out, fmt.Sprintf("Version: %d", initial.Version))
+
+		_, err = run("migrate", "force", fmt.Sprint(initial.Version), "-o", "table")
+		require.NoError(t, err)
+		require.Equal(t, initial.Version, status().Version)
+		resetGlobals()
+	})
+
 	t.Run("migrate fails without db-url", func(t *testing.T) {
 		resetGlobals() // Ensure dbURL is empty
 		rootCmd.PersistentFlags().Set("db-url", "")
@@ -294,7 +527,8 @@ func TestMigrateCommand(t *testing.T) {
 }
 
 func TestUserCreateCommand(t *testing.T) {
-	_, dbURL := setupTestDBForCLI(t)
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "user-tenant")
 
 	t.Run("create user successfully", func(t *testing.T) {
 		rootCmd.SetArgs([]string{
@@ -345,6 +579,353 @@ func TestUserCreateCommand(t *testing.T) {
 		err := rootCmd.Execute()
 		require.Error(t, err)
 	})
+
+	t.Run("password from stdin written to secret-out", func(t *testing.T) {
+		secretOut := filepath.Join(t.TempDir(), "password")
+		rootCmd.SetArgs([]string{
+			"user", "create", "stdinuser",
+			"--db-url", dbURL,
+			"--tenant", "user-tenant",
+			"--password-stdin",
+			"--secret-out", secretOut,
+		})
+		rootCmd.SetIn(bytes.NewBufferString("piped-password\n"))
+		t.Cleanup(func() {
+			rootCmd.SetIn(nil)
+			userCreateCmd.Flags().Set("password-stdin", "false")
+			userCreateCmd.Flags().Set("secret-out", "")
+		})
+
+		var outBuf bytes.Buffer
+		rootCmd.SetOut(&outBuf)
+		rootCmd.SetErr(&outBuf)
+
+		err := rootCmd.Execute()
+		require.NoError(t, err)
+		require.NotContains(t, outBuf.String(), "piped-password")
+		require.Contains(t, outBuf.String(), "written to "+secretOut)
+
+		data, err := os.ReadFile(secretOut)
+		require.NoError(t, err)
+		require.Equal(t, "piped-password\n", string(data))
+
+		tenant, err := db.GetTenantByName(conn, "user-tenant")
+		require.NoError(t, err)
+		user, err := db.GetUser(conn, tenant.ID, "stdinuser")
+		require.NoError(t, err)
+		require.NoError(t, db.VerifyPassword(user, "piped-password"))
+	})
+}
+
+func TestUserLifecycleCommands(t *testing.T) {
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "lifecycle-tenant")
+
+	rootCmd.SetArgs([]string{
+		"user", "create", "lifecycle-user",
+		"--db-url", dbURL,
+		"--tenant", "lifecycle-tenant",
+	})
+	rootCmd.SetOut(os.Stderr) // Suppress output
+	err := rootCmd.Execute()
+	require.NoError(t, err)
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, append(args, "--db-url", dbURL, "--tenant", "lifecycle-tenant")...)
+	}
+
+	t.Run("list users", func(t *testing.T) {
+		output, err := run("user", "list")
+		require.NoError(t, err)
+		require.Contains(t, output, "lifecycle-user")
+		require.Contains(t, output, "active")
+	})
+
+	t.Run("reset password", func(t *testing.T) {
+		output, err := run("user", "reset-password", "lifecycle-user")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ Password reset successfully")
+		require.Contains(t, output, "Password:")
+	})
+
+	t.Run("disable and enable", func(t *testing.T) {
+		output, err := run("user", "disable", "lifecycle-user")
+		require.NoError(t, err)
+		require.Contains(t, output, "disabled")
+
+		_, err = run("user", "get", "lifecycle-user")
+		require.Error(t, err)
+
+		output, err = run("user", "list", "--all")
+		require.NoError(t, err)
+		require.Contains(t, output, "disabled")
+
+		output, err = run("user", "enable", "lifecycle-user")
+		require.NoError(t, err)
+		require.Contains(t, output, "enabled")
+	})
+
+	t.Run("delete requires force", func(t *testing.T) {
+		_, err := run("user", "delete", "lifecycle-user")
+		require.Error(t, err)
+
+		output, err := run("user", "delete", "lifecycle-user", "--force")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ User deleted successfully")
+	})
+}
+
+func TestClientLifecycleCommands(t *testing.T) {
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "client-tenant")
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, append(args, "--db-url", dbURL, "--tenant", "client-tenant")...)
+	}
+
+	_, err := run("client", "create", "lifecycle-client", "--secret", "initial-secret-123")
+	require.NoError(t, err)
+
+	t.Run("revoke client", func(t *testing.T) {
+		output, err := run("client", "revoke", "lifecycle-client")
+		require.NoError(t, err)
+		require.Contains(t, output, "revoked")
+
+		_, err = run("client", "get", "lifecycle-client")
+		require.Error(t, err)
+	})
+
+	t.Run("delete requires force", func(t *testing.T) {
+		_, err := run("client", "delete", "lifecycle-client")
+		require.Error(t, err)
+
+		output, err := run("client", "delete", "lifecycle-client", "--force")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ Client deleted successfully")
+	})
+
+	t.Run("emit dotenv", func(t *testing.T) {
+		t.Cleanup(func() {
+			clientCreateCmd.Flags().Set("emit", "")
+			clientCreateCmd.Flags().Set("ingest-url", "")
+		})
+
+		output, err := run("client", "create", "emitted-client", "--secret", "emitted-secret",
+			"--emit", "dotenv", "--ingest-url", "http://localhost:8082")
+		require.NoError(t, err)
+		require.Equal(t, "FRKR_CLIENT_ID=emitted-client\n"+
+			"FRKR_CLIENT_SECRET=emitted-secret\n"+
+			"FRKR_TENANT=client-tenant\n"+
+			"FRKR_INGEST_URL=http://localhost:8082\n", output)
+	})
+}
+
+func TestOutputFormats(t *testing.T) {
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "output-tenant")
+	t.Cleanup(resetGlobals)
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, append(args, "--db-url", dbURL, "--tenant", "output-tenant")...)
+	}
+
+	_, err := run("stream", "create", "output-stream", "-o", "table")
+	require.NoError(t, err)
+
+	t.Run("json", func(t *testing.T) {
+		output, err := run("stream", "get", "output-stream", "-o", "json")
+		require.NoError(t, err)
+
+		var stream map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(output), &stream))
+		require.Equal(t, "output-stream", stream["name"])
+		require.Equal(t, float64(7), stream["retention_days"])
+	})
+
+	t.Run("yaml list", func(t *testing.T) {
+		output, err := run("stream", "list", "-o", "yaml")
+		require.NoError(t, err)
+
+		var streams []map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(output), &streams))
+		require.Len(t, streams, 1)
+		require.Equal(t, "output-stream", streams[0]["name"])
+	})
+
+	t.Run("name", func(t *testing.T) {
+		output, err := run("stream", "list", "-o", "name")
+		require.NoError(t, err)
+		require.Equal(t, "stream/output-stream\n", output)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		_, err := run("stream", "list", "-o", "xml")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "unknown output format")
+	})
+}
+
+func TestApplyCommand(t *testing.T) {
+	conn, dbURL := setupTestDBForCLI(t)
+
+	path := filepath.Join(t.TempDir(), "frkr.yaml")
+	writeFile := func(content string) {
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, append(args, "-f", path, "--db-url", dbURL)...)
+	}
+
+	writeFile(`
+tenants:
+  - name: apply-tenant
+    streams:
+      - name: orders
+        retention_days: 7
+      - name: payments
+    clients:
+      - client_id: orders-sdk
+        stream: orders
+        secret: orders-secret-123
+`)
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		output, err := run("apply", "--dry-run", "--prune=false")
+		require.NoError(t, err)
+		require.Contains(t, output, "+ tenant apply-tenant/apply-tenant")
+		require.Contains(t, output, "+ stream apply-tenant/orders")
+		require.Contains(t, output, "Dry run: no changes applied.")
+
+		var count int
+		require.NoError(t, conn.QueryRow(`SELECT COUNT(*) FROM tenants WHERE name = 'apply-tenant'`).Scan(&count))
+		require.Equal(t, 0, count)
+	})
+
+	t.Run("apply creates everything", func(t *testing.T) {
+		output, err := run("apply", "--dry-run=false", "--prune=false")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ Applied 4 change(s)")
+
+		output, err = run("plan", "--prune=false")
+		require.NoError(t, err)
+		require.Contains(t, output, "No changes.")
+	})
+
+	t.Run("update and prune", func(t *testing.T) {
+		writeFile(`
+tenants:
+  - name: apply-tenant
+    streams:
+      - name: orders
+        retention_days: 14
+`)
+		output, err := run("plan", "--prune")
+		require.NoError(t, err)
+		require.Contains(t, output, "~ stream apply-tenant/orders")
+		require.Contains(t, output, "retention_days: 7 -> 14")
+		require.Contains(t, output, "- client apply-tenant/orders-sdk")
+		require.Contains(t, output, "- stream apply-tenant/payments")
+
+		_, err = run("apply", "--dry-run=false", "--prune")
+		require.NoError(t, err)
+
+		var days int
+		require.NoError(t, conn.QueryRow(`
+			SELECT s.retention_days FROM streams s JOIN tenants t ON t.id = s.tenant_id
+			WHERE t.name = 'apply-tenant' AND s.name = 'orders'
+		`).Scan(&days))
+		require.Equal(t, 14, days)
+
+		output, err = run("plan", "--prune")
+		require.NoError(t, err)
+		require.Contains(t, output, "No changes.")
+	})
+}
+
+func TestExportCommand(t *testing.T) {
+	conn, dbURL := setupTestDBForCLI(t)
+	createTestTenants(t, conn, "export-tenant")
+	t.Cleanup(resetGlobals)
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, append(args, "--db-url", dbURL)...)
+	}
+
+	_, err := run("stream", "create", "export-api", "--tenant", "export-tenant", "--description", "Export API", "--retention-days", "14", "-o", "table")
+	require.NoError(t, err)
+	_, err = run("client", "create", "export-sdk", "--tenant", "export-tenant", "--stream", "export-api", "--secret", "export-secret-123", "-o", "table")
+	require.NoError(t, err)
+
+	output, err := run("export", "--tenant", "export-tenant", "-o", "table")
+	require.NoError(t, err)
+	require.Contains(t, output, "name: export-api")
+	require.Contains(t, output, "retention_days: 14")
+	require.Contains(t, output, "client_id: export-sdk")
+	require.NotContains(t, output, "export-secret-123")
+
+	path := filepath.Join(t.TempDir(), "frkr.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(output), 0644))
+
+	output, err = run("plan", "-f", path, "--prune", "-o", "table")
+	require.NoError(t, err)
+	require.Contains(t, output, "No changes.")
+}
+
+func TestTenantCommands(t *testing.T) {
+	conn, dbURL := setupTestDBForCLI(t)
+
+	run := func(args ...string) (string, error) {
+		return runCLI(t, append(args, "--db-url", dbURL)...)
+	}
+
+	countTenants := func(name string) int {
+		var count int
+		require.NoError(t, conn.QueryRow(`SELECT COUNT(*) FROM tenants WHERE name = $1`, name).Scan(&count))
+		return count
+	}
+
+	t.Run("unknown tenants are not created", func(t *testing.T) {
+		_, err := run("tenant", "get", "typo-tenant")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+
+		_, err = run("stream", "create", "typo-api", "--tenant", "typo-tenant")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "frkrcfg tenant create typo-tenant")
+
+		require.Equal(t, 0, countTenants("typo-tenant"))
+	})
+
+	t.Run("create, list and rename", func(t *testing.T) {
+		_, err := run("tenant", "create", "acme")
+		require.NoError(t, err)
+
+		output, err := run("tenant", "list")
+		require.NoError(t, err)
+		require.Contains(t, output, "acme")
+
+		output, err = run("tenant", "rename", "acme", "acme-corp")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ Tenant 'acme' renamed to 'acme-corp'")
+	})
+
+	t.Run("delete requires cascade when not empty", func(t *testing.T) {
+		_, err := run("stream", "create", "acme-api", "--tenant", "acme-corp")
+		require.NoError(t, err)
+
+		_, err = run("tenant", "delete", "acme-corp", "--force", "--cascade=false")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "--cascade")
+
+		output, err := run("tenant", "delete", "acme-corp", "--force", "--cascade")
+		require.NoError(t, err)
+		require.Contains(t, output, "✅ Tenant 'acme-corp' deleted")
+		require.Contains(t, output, "Also deleted 1 stream(s)")
+
+		_, err = run("tenant", "get", "acme-corp")
+		require.Error(t, err)
+	})
 }
 
 func TestRootCommand(t *testing.T) {
@@ -1,31 +1,233 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strconv"
+	"time"
 
-	"github.com/frkr-io/frkr-common/migrate"
+	"github.com/frkr-io/frkr-tools/pkg/schema"
 	"github.com/spf13/cobra"
 )
 
 var migrateCmd = &cobra.Command{
 	Use:   "migrate",
 	Short: "Run database migrations",
-	Long:  `Run database migrations from the migrations directory.`,
+	Long: `Run all pending database migrations.
+
+Use the subcommands to inspect the schema version or to move it one step at a
+time, e.g. to recover from a migration that failed part-way.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMigrate(cmd, 0, "✅ Migrations completed successfully")
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the schema version and pending migrations",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mg, err := openMigrator()
+		if err != nil {
+			return err
+		}
+		defer mg.Close()
+
+		status, err := mg.Status()
+		if err != nil {
+			return err
+		}
+
+		return renderOne(cmd, newMigrationStatusView(status), func(w io.Writer) {
+			fmt.Fprintf(w, "Version: %d\n", status.Version)
+			fmt.Fprintf(w, "Dirty:   %t\n", status.Dirty)
+			fmt.Fprintf(w, "Pending: %d\n", len(status.Pending))
+			for _, m := range status.Pending {
+				fmt.Fprintf(w, "  %d  %s\n", m.Version, m.Name)
+			}
+			if status.Dirty {
+				fmt.Fprintf(w, "\n⚠️  Migration %d failed part-way. Repair the database by hand, then run\n", status.Version)
+				fmt.Fprintf(w, "   'frkrcfg migrate force %d' if it is now fully applied, or force the previous version if it was undone.\n", status.Version)
+			}
+		})
+	},
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up [N]",
+	Short: "Apply the next N migrations (all pending when N is omitted)",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n := 0
+		if len(args) == 1 {
+			var err error
+			if n, err = parseMigrationCount(args[0]); err != nil {
+				return err
+			}
+		}
+		return runMigrate(cmd, n, "✅ Migrated up")
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down N",
+	Short: "Roll back the last N migrations",
+	Long: `Roll back the last N migrations.
+
+Rolling back drops tables and columns along with their data, so --force is required.`,
+	Example: `  frkrcfg migrate down 1 --force`,
+	Args:    cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		n, err := parseMigrationCount(args[0])
+		if err != nil {
+			return err
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if !force {
+			return fmt.Errorf("rolling back migrations requires --force flag for safety")
+		}
+
+		mg, err := openMigrator()
+		if err != nil {
+			return err
+		}
+		defer mg.Close()
+
+		if err := mg.Down(n); err != nil {
+			return err
+		}
+		return renderMigrationVersion(cmd, mg, "✅ Rolled back")
+	},
+}
+
+var migrateGotoCmd = &cobra.Command{
+	Use:   "goto V",
+	Short: "Migrate up or down to version V",
+	Long: `Migrate up or down to version V.
+
+Going down drops tables and columns along with their data, so --force is
+required when V is below the current version.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if dbURL == "" {
-			return fmt.Errorf("--db-url is required")
+		target, err := strconv.ParseUint(args[0], 10, 0)
+		if err != nil {
+			return fmt.Errorf("invalid version '%s'", args[0])
+		}
+
+		mg, err := openMigrator()
+		if err != nil {
+			return err
 		}
+		defer mg.Close()
 
-// RunMigrations runs database migrations
-		if err := migrate.RunMigrations(dbURL); err != nil {
-			return fmt.Errorf("failed to run migrations: %w", err)
+		current, _, err := mg.Version()
+		if err != nil {
+			return err
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		if uint(target) < current && !force {
+			return fmt.Errorf("going down from version %d to %d requires --force flag for safety", current, target)
 		}
 
-		fmt.Fprintln(cmd.OutOrStdout(), "✅ Migrations completed successfully")
-		return nil
+		if err := mg.Goto(uint(target)); err != nil {
+			return err
+		}
+		return renderMigrationVersion(cmd, mg, "✅ Migrated")
+	},
+}
+
+var migrateForceCmd = &cobra.Command{
+	Use:   "force V",
+	Short: "Set the schema version without running migrations",
+	Long: `Set the schema version to V and clear the dirty flag without running any migration.
+
+Use this after repairing a migration that failed part-way: force its version if
+the repair completed it, or the version before it if the repair undid it. A
+version of -1 marks the database as having no migrations applied.`,
+	Example: `  frkrcfg migrate force 3
+  frkrcfg migrate force -- -1`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid version '%s'", args[0])
+		}
+
+		mg, err := openMigrator()
+		if err != nil {
+			return err
+		}
+		defer mg.Close()
+
+		if err := mg.Force(version); err != nil {
+			return err
+		}
+		return renderMigrationVersion(cmd, mg, "✅ Forced")
 	},
 }
 
 func init() {
-	// No flags needed anymore
+	migrateCmd.AddCommand(migrateStatusCmd)
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateGotoCmd)
+	migrateCmd.AddCommand(migrateForceCmd)
+
+	migrateDownCmd.Flags().Bool("force", false, "Confirm the rollback (required for safety)")
+	migrateGotoCmd.Flags().Bool("force", false, "Confirm a rollback when V is below the current version")
+}
+
+// openMigrator connects to --db-url and picks the migration driver for the
+// database behind it, so a postgres:// URL pointing at CockroachDB works
+func openMigrator() (*schema.Migrator, error) {
+	conn, err := getDB()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return schema.NewMigrator(schema.MigrateURL(ctx, conn, dbURL))
+}
+
+// runMigrate applies n migrations (all pending when n is 0) and reports the
+// resulting version
+func runMigrate(cmd *cobra.Command, n int, message string) error {
+	mg, err := openMigrator()
+	if err != nil {
+		return err
+	}
+	defer mg.Close()
+
+	if err := mg.Up(n); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return renderMigrationVersion(cmd, mg, message)
+}
+
+// renderMigrationVersion prints message followed by the current version
+func renderMigrationVersion(cmd *cobra.Command, mg *schema.Migrator, message string) error {
+	version, dirty, err := mg.Version()
+	if err != nil {
+		return err
+	}
+
+	return renderOne(cmd, migrationView{Version: version, Dirty: dirty}, func(w io.Writer) {
+		fmt.Fprintln(w, message)
+		fmt.Fprintf(w, "Version: %d\n", version)
+	})
+}
+
+// parseMigrationCount parses the N of 'migrate up N' and 'migrate down N'
+func parseMigrationCount(arg string) (int, error) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid number of migrations '%s' (expected a positive integer)", arg)
+	}
+	return n, nil
 }
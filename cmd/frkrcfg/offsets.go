@@ -0,0 +1,303 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/spf13/cobra"
+)
+
+// partitionOffsetsView is one partition of a stream's topic
+type partitionOffsetsView struct {
+	Partition int   `json:"partition" yaml:"partition"`
+	Earliest  int64 `json:"earliest" yaml:"earliest"`
+	Latest    int64 `json:"latest" yaml:"latest"`
+	Messages  int64 `json:"messages" yaml:"messages"`
+}
+
+// groupPartitionView is a consumer group's position in one partition.
+// Committed and Lag are nil when the group has not committed an offset.
+type groupPartitionView struct {
+	Partition int    `json:"partition" yaml:"partition"`
+	Committed *int64 `json:"committed" yaml:"committed"`
+	Lag       *int64 `json:"lag" yaml:"lag"`
+}
+
+// groupOffsetsView is a consumer group reading a stream's topic
+type groupOffsetsView struct {
+	Group      string               `json:"group" yaml:"group"`
+	State      string               `json:"state,omitempty" yaml:"state,omitempty"`
+	Members    int                  `json:"members" yaml:"members"`
+	Lag        int64                `json:"lag" yaml:"lag"`
+	Partitions []groupPartitionView `json:"partitions" yaml:"partitions"`
+}
+
+// streamOffsetsView is the rendered form of stream offsets
+type streamOffsetsView struct {
+	Stream     string                 `json:"stream" yaml:"stream"`
+	Topic      string                 `json:"topic" yaml:"topic"`
+	Partitions []partitionOffsetsView `json:"partitions" yaml:"partitions"`
+	Groups     []groupOffsetsView     `json:"groups" yaml:"groups"`
+}
+
+func (v streamOffsetsView) resourceName() string { return "offsets/" + v.Stream }
+
+func newStreamOffsetsView(stream, topic string, partitions []broker.PartitionOffsets, groups []broker.GroupOffsets) streamOffsetsView {
+	view := streamOffsetsView{
+		Stream:     stream,
+		Topic:      topic,
+		Partitions: make([]partitionOffsetsView, 0, len(partitions)),
+		Groups:     make([]groupOffsetsView, 0, len(groups)),
+	}
+	for _, p := range partitions {
+		view.Partitions = append(view.Partitions, partitionOffsetsView{
+			Partition: p.Partition,
+			Earliest:  p.Earliest,
+			Latest:    p.Latest,
+			Messages:  p.Latest - p.Earliest,
+		})
+	}
+
+	for _, g := range groups {
+		gv := groupOffsetsView{Group: g.Group, State: g.State, Members: g.Members, Partitions: []groupPartitionView{}}
+		for _, p := range partitions {
+			pv := groupPartitionView{Partition: p.Partition}
+			if committed, ok := g.Committed[p.Partition]; ok {
+				lag := max(p.Latest-committed, 0)
+				pv.Committed, pv.Lag = &committed, &lag
+				gv.Lag += lag
+			}
+			gv.Partitions = append(gv.Partitions, pv)
+		}
+		view.Groups = append(view.Groups, gv)
+	}
+	return view
+}
+
+// offsetResetPartitionView is the change to one partition's committed offset
+type offsetResetPartitionView struct {
+	Partition int    `json:"partition" yaml:"partition"`
+	Current   *int64 `json:"current" yaml:"current"`
+	New       int64  `json:"new" yaml:"new"`
+}
+
+// offsetResetView is the rendered result of stream offsets reset
+type offsetResetView struct {
+	Stream     string                     `json:"stream" yaml:"stream"`
+	Topic      string                     `json:"topic" yaml:"topic"`
+	Group      string                     `json:"group" yaml:"group"`
+	To         string                     `json:"to" yaml:"to"`
+	Partitions []offsetResetPartitionView `json:"partitions" yaml:"partitions"`
+	DryRun     bool                       `json:"dry_run,omitempty" yaml:"dry_run,omitempty"`
+}
+
+func (v offsetResetView) resourceName() string { return "offsets/" + v.Stream + "/" + v.Group }
+
+var streamOffsetsCmd = &cobra.Command{
+	Use:   "offsets [stream-name-or-id]",
+	Short: "Show the partitions and consumer group lag of a stream's topic",
+	Long: `Show each partition of a stream's topic with its earliest offset and high
+watermark, and every consumer group that has committed offsets on the topic
+with its state, committed offsets and lag. --group limits the output to the
+given groups, which are shown even if they have not committed yet.
+
+Use 'stream offsets reset' to move a group back to re-deliver messages, or
+forward to skip them.`,
+	Example: `  frkrcfg stream offsets my-api --broker-url localhost:19092
+  frkrcfg stream offsets my-api --group streaming-gateway -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		groups, _ := cmd.Flags().GetStringArray("group")
+
+		stream, err := resolveStreamTopic(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx := cmd.Context()
+		m, err := newBrokerManager()
+		if err != nil {
+			return err
+		}
+		partitions, err := m.TopicOffsets(ctx, stream.Topic)
+		if err != nil {
+			return err
+		}
+		groupOffsets, err := m.ConsumerGroupOffsets(ctx, stream.Topic, groups)
+		if err != nil {
+			return err
+		}
+
+		view := newStreamOffsetsView(stream.Name, stream.Topic, partitions, groupOffsets)
+		return renderOne(cmd, view, func(w io.Writer) {
+			printStreamOffsets(w, view)
+		})
+	},
+}
+
+var streamOffsetsResetCmd = &cobra.Command{
+	Use:   "reset [stream-name-or-id]",
+	Short: "Move a consumer group's committed offsets on a stream's topic",
+	Long: `Commit new offsets for --group on every partition of a stream's topic, so
+that the group resumes reading there: at the oldest retained message
+(--to-earliest), at the end of the topic (--to-latest), or at the first
+message at or after a time (--to-datetime, RFC 3339 or an age such as 1h).
+
+Moving back re-delivers messages; moving forward skips them. The group must
+have no running consumers, since they would overwrite the new offsets.
+--dry-run shows the new offsets without committing them.`,
+	Example: `  frkrcfg stream offsets reset my-api --group streaming-gateway --to-datetime 2024-01-02T15:00:00Z --dry-run
+  frkrcfg stream offsets reset my-api --group streaming-gateway --to-earliest --broker-url localhost:19092`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		group, _ := cmd.Flags().GetString("group")
+		opts, to, err := offsetResetTarget(cmd, time.Now())
+		if err != nil {
+			return err
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		stream, err := resolveStreamTopic(args[0])
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		ctx := cmd.Context()
+		m, err := newBrokerManager()
+		if err != nil {
+			return err
+		}
+		offsets, err := m.StartOffsets(ctx, stream.Topic, opts)
+		if err != nil {
+			return err
+		}
+		current, err := m.ConsumerGroupOffsets(ctx, stream.Topic, []string{group})
+		if err != nil {
+			return err
+		}
+
+		view := newOffsetResetView(stream.Name, stream.Topic, group, to, offsets, current)
+		view.DryRun = dryRun
+		if !dryRun {
+			if err := m.CommitOffsets(ctx, stream.Topic, group, offsets); err != nil {
+				return err
+			}
+		}
+		return renderOne(cmd, view, func(w io.Writer) {
+			printOffsetReset(w, view)
+		})
+	},
+}
+
+func init() {
+	streamOffsetsCmd.Flags().StringArray("group", nil, "Only show this consumer group (repeatable)")
+
+	streamOffsetsResetCmd.Flags().String("group", "", "Consumer group to reset (required)")
+	streamOffsetsResetCmd.Flags().Bool("to-earliest", false, "Resume at the oldest retained message")
+	streamOffsetsResetCmd.Flags().Bool("to-latest", false, "Resume at the end of the topic, skipping everything before")
+	streamOffsetsResetCmd.Flags().String("to-datetime", "", "Resume at the first message at or after this time (RFC 3339 or an age, e.g. 1h)")
+	streamOffsetsResetCmd.Flags().Bool("dry-run", false, "Show the new offsets without committing them")
+	streamOffsetsResetCmd.MarkFlagRequired("group")
+	streamOffsetsResetCmd.MarkFlagsMutuallyExclusive("to-earliest", "to-latest", "to-datetime")
+	streamOffsetsResetCmd.MarkFlagsOneRequired("to-earliest", "to-latest", "to-datetime")
+
+	streamOffsetsCmd.AddCommand(streamOffsetsResetCmd)
+	streamCmd.AddCommand(streamOffsetsCmd)
+}
+
+// offsetResetTarget reads --to-earliest, --to-latest or --to-datetime into
+// the read position to reset to, and a description of it
+func offsetResetTarget(cmd *cobra.Command, now time.Time) (broker.ReadOptions, string, error) {
+	if earliest, _ := cmd.Flags().GetBool("to-earliest"); earliest {
+		return broker.ReadOptions{FromBeginning: true}, "earliest", nil
+	}
+	if datetime, _ := cmd.Flags().GetString("to-datetime"); datetime != "" {
+		t, err := parseTimeFlag(datetime, now)
+		if err != nil {
+			return broker.ReadOptions{}, "", err
+		}
+		return broker.ReadOptions{Since: t}, t.UTC().Format(time.RFC3339), nil
+	}
+	if latest, _ := cmd.Flags().GetBool("to-latest"); latest {
+		return broker.ReadOptions{}, "latest", nil
+	}
+	return broker.ReadOptions{}, "", fmt.Errorf("one of --to-earliest, --to-latest or --to-datetime is required")
+}
+
+func newOffsetResetView(stream, topic, group, to string, offsets map[int]int64, current []broker.GroupOffsets) offsetResetView {
+	view := offsetResetView{Stream: stream, Topic: topic, Group: group, To: to, Partitions: []offsetResetPartitionView{}}
+
+	var committed map[int]int64
+	for _, g := range current {
+		if g.Group == group {
+			committed = g.Committed
+		}
+	}
+
+	partitions := make([]int, 0, len(offsets))
+	for p := range offsets {
+		partitions = append(partitions, p)
+	}
+	sort.Ints(partitions)
+	for _, p := range partitions {
+		pv := offsetResetPartitionView{Partition: p, New: offsets[p]}
+		if c, ok := committed[p]; ok {
+			pv.Current = &c
+		}
+		view.Partitions = append(view.Partitions, pv)
+	}
+	return view
+}
+
+// offsetString prints an optional offset, or - when there is none
+func offsetString(offset *int64) string {
+	if offset == nil {
+		return "-"
+	}
+	return strconv.FormatInt(*offset, 10)
+}
+
+func printStreamOffsets(w io.Writer, view streamOffsetsView) {
+	fmt.Fprintf(w, "Topic %s of stream '%s':\n\n", view.Topic, view.Stream)
+	fmt.Fprintf(w, "%-10s %-15s %-15s %-15s\n", "Partition", "Earliest", "Latest", "Messages")
+	fmt.Fprintf(w, "%s\n", "----------------------------------------------------------")
+	for _, p := range view.Partitions {
+		fmt.Fprintf(w, "%-10d %-15d %-15d %-15d\n", p.Partition, p.Earliest, p.Latest, p.Messages)
+	}
+
+	if len(view.Groups) == 0 {
+		fmt.Fprintf(w, "\nNo consumer group has committed offsets on this topic\n")
+		return
+	}
+	for _, g := range view.Groups {
+		state := g.State
+		if state == "" {
+			state = "unknown"
+		}
+		fmt.Fprintf(w, "\nConsumer group '%s' (%s, %d member(s)), lag %d:\n", g.Group, state, g.Members, g.Lag)
+		fmt.Fprintf(w, "%-10s %-15s %-15s\n", "Partition", "Committed", "Lag")
+		fmt.Fprintf(w, "%s\n", "-----------------------------------------")
+		for _, p := range g.Partitions {
+			fmt.Fprintf(w, "%-10d %-15s %-15s\n", p.Partition, offsetString(p.Committed), offsetString(p.Lag))
+		}
+	}
+}
+
+func printOffsetReset(w io.Writer, view offsetResetView) {
+	fmt.Fprintf(w, "%-10s %-15s %-15s\n", "Partition", "Current", "New")
+	fmt.Fprintf(w, "%s\n", "-----------------------------------------")
+	for _, p := range view.Partitions {
+		fmt.Fprintf(w, "%-10d %-15s %-15d\n", p.Partition, offsetString(p.Current), p.New)
+	}
+	fmt.Fprintln(w)
+	if view.DryRun {
+		fmt.Fprintf(w, "Would reset consumer group '%s' on stream '%s' to %s (dry run)\n", view.Group, view.Stream, view.To)
+		return
+	}
+	fmt.Fprintf(w, "✅ Reset consumer group '%s' on stream '%s' to %s\n", view.Group, view.Stream, view.To)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamOffsetsView(t *testing.T) {
+	partitions := []broker.PartitionOffsets{
+		{Partition: 0, Earliest: 10, Latest: 110},
+		{Partition: 1, Earliest: 0, Latest: 40},
+	}
+	groups := []broker.GroupOffsets{
+		{Group: "streaming-gateway", State: "Stable", Members: 1, Committed: map[int]int64{0: 100, 1: 40}},
+		{Group: "new-consumer", State: "Empty", Committed: map[int]int64{1: 5}},
+	}
+
+	view := newStreamOffsetsView("my-api", "stream-my-api", partitions, groups)
+	require.Equal(t, []partitionOffsetsView{
+		{Partition: 0, Earliest: 10, Latest: 110, Messages: 100},
+		{Partition: 1, Earliest: 0, Latest: 40, Messages: 40},
+	}, view.Partitions)
+
+	require.Len(t, view.Groups, 2)
+	require.Equal(t, int64(10), view.Groups[0].Lag)
+	require.Equal(t, int64(35), view.Groups[1].Lag)
+	require.Nil(t, view.Groups[1].Partitions[0].Committed)
+	require.Nil(t, view.Groups[1].Partitions[0].Lag)
+	require.Equal(t, int64(35), *view.Groups[1].Partitions[1].Lag)
+
+	var buf bytes.Buffer
+	printStreamOffsets(&buf, view)
+	require.Contains(t, buf.String(), "Consumer group 'new-consumer' (Empty, 0 member(s)), lag 35")
+	require.Regexp(t, `0\s+-\s+-`, buf.String())
+
+	buf.Reset()
+	printStreamOffsets(&buf, newStreamOffsetsView("my-api", "stream-my-api", partitions, nil))
+	require.Contains(t, buf.String(), "No consumer group has committed offsets")
+}
+
+func TestOffsetResetTarget(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		for _, name := range []string{"to-earliest", "to-latest", "to-datetime"} {
+			flag := streamOffsetsResetCmd.Flags().Lookup(name)
+			flag.Value.Set(flag.DefValue)
+			flag.Changed = false
+		}
+	})
+
+	require.NoError(t, streamOffsetsResetCmd.Flags().Set("to-datetime", "1h"))
+	opts, to, err := offsetResetTarget(streamOffsetsResetCmd, now)
+	require.NoError(t, err)
+	require.Equal(t, broker.ReadOptions{Since: now.Add(-time.Hour)}, opts)
+	require.Equal(t, "2024-01-02T14:00:00Z", to)
+
+	require.NoError(t, streamOffsetsResetCmd.Flags().Set("to-datetime", "yesterday"))
+	_, _, err = offsetResetTarget(streamOffsetsResetCmd, now)
+	require.ErrorContains(t, err, "invalid time")
+
+	require.NoError(t, streamOffsetsResetCmd.Flags().Set("to-datetime", ""))
+	require.NoError(t, streamOffsetsResetCmd.Flags().Set("to-earliest", "true"))
+	opts, to, err = offsetResetTarget(streamOffsetsResetCmd, now)
+	require.NoError(t, err)
+	require.Equal(t, broker.ReadOptions{FromBeginning: true}, opts)
+	require.Equal(t, "earliest", to)
+}
+
+func TestOffsetResetView(t *testing.T) {
+	current := []broker.GroupOffsets{{Group: "g", Committed: map[int]int64{1: 7}}}
+	view := newOffsetResetView("my-api", "stream-my-api", "g", "earliest", map[int]int64{1: 0, 0: 3}, current)
+	require.Len(t, view.Partitions, 2)
+	require.Equal(t, 0, view.Partitions[0].Partition)
+	require.Nil(t, view.Partitions[0].Current)
+	require.Equal(t, int64(7), *view.Partitions[1].Current)
+	require.Equal(t, int64(0), view.Partitions[1].New)
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-tools/pkg/schema"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats accepted by --output
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputName  = "name"
+)
+
+// validateOutputFormat normalizes --output and rejects unknown formats.
+// "text" is accepted as an alias for "table" for backward compatibility.
+func validateOutputFormat() error {
+	switch outputFormat {
+	case "", "text":
+		outputFormat = outputTable
+	case outputTable, outputJSON, outputYAML, outputName:
+	default:
+		return fmt.Errorf("unknown output format '%s' (expected table, json, yaml, or name)", outputFormat)
+	}
+	return nil
+}
+
+// resource is implemented by every view that frkrcfg renders.
+// resourceName is what -o name prints, in kind/name form.
+type resource interface {
+	resourceName() string
+}
+
+// renderOne writes a single resource in the format selected by --output.
+// printTable is only called for table output.
+func renderOne[T resource](cmd *cobra.Command, item T, printTable func(w io.Writer)) error {
+	w := cmd.OutOrStdout()
+	if outputFormat == outputName {
+		fmt.Fprintln(w, item.resourceName())
+		return nil
+	}
+	return encode(w, item, printTable)
+}
+
+// renderList writes a list of resources in the format selected by --output.
+// JSON and YAML always produce an array, even when the list is empty.
+func renderList[T resource](cmd *cobra.Command, items []T, printTable func(w io.Writer)) error {
+	w := cmd.OutOrStdout()
+	if items == nil {
+		items = []T{}
+	}
+	if outputFormat == outputName {
+		for _, item := range items {
+			fmt.Fprintln(w, item.resourceName())
+		}
+		return nil
+	}
+	return encode(w, items, printTable)
+}
+
+// encode writes v as JSON or YAML, or defers to printTable for table output
+func encode(w io.Writer, v interface{}, printTable func(w io.Writer)) error {
+	switch outputFormat {
+	case outputJSON:
+		return json.NewEncoder(w).Encode(v)
+	case outputYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Close()
+	default:
+		printTable(w)
+		return nil
+	}
+}
+
+// tenantView is the rendered form of a tenant
+type tenantView struct {
+	ID        string    `json:"id" yaml:"id"`
+	Name      string    `json:"name" yaml:"name"`
+	Plan      string    `json:"plan" yaml:"plan"`
+	CreatedAt time.Time `json:"created_at" yaml:"created_at"`
+}
+
+func (v tenantView) resourceName() string { return "tenant/" + v.Name }
+
+func newTenantView(tenant *models.Tenant) tenantView {
+	return tenantView{
+		ID:        tenant.ID,
+		Name:      tenant.Name,
+		Plan:      tenant.Plan,
+		CreatedAt: tenant.CreatedAt,
+	}
+}
+
+// streamView is the rendered form of a stream
+type streamView struct {
+	ID            string     `json:"id" yaml:"id"`
+	Name          string     `json:"name" yaml:"name"`
+	Description   string     `json:"description" yaml:"description"`
+	Status        string     `json:"status" yaml:"status"`
+	RetentionDays int        `json:"retention_days" yaml:"retention_days"`
+	Topic         string     `json:"topic" yaml:"topic"`
+	TenantID      string     `json:"tenant_id" yaml:"tenant_id"`
+	CreatedAt     time.Time  `json:"created_at" yaml:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" yaml:"updated_at"`
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" yaml:"deleted_at,omitempty"`
+}
+
+func (v streamView) resourceName() string { return "stream/" + v.Name }
+
+func newStreamView(stream *models.Stream) streamView {
+	return streamView{
+		ID:            stream.ID,
+		Name:          stream.Name,
+		Description:   stream.Description,
+		Status:        stream.Status,
+		RetentionDays: stream.RetentionDays,
+		Topic:         stream.Topic,
+		TenantID:      stream.TenantID,
+		CreatedAt:     stream.CreatedAt,
+		UpdatedAt:     stream.UpdatedAt,
+		DeletedAt:     stream.DeletedAt,
+	}
+}
+
+// userView is the rendered form of a user. Password is only set when
+// a command has just generated or received one; hashes are never rendered.
+type userView struct {
+	ID         string     `json:"id" yaml:"id"`
+	Username   string     `json:"username" yaml:"username"`
+	Status     string     `json:"status" yaml:"status"`
+	TenantID   string     `json:"tenant_id" yaml:"tenant_id"`
+	TenantName string     `json:"tenant_name" yaml:"tenant_name"`
+	CreatedAt  *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	Password   string     `json:"password,omitempty" yaml:"password,omitempty"`
+}
+
+func (v userView) resourceName() string { return "user/" + v.Username }
+
+func newUserView(user *models.TenantUser, tenant *models.Tenant) userView {
+	view := userView{
+		ID:         user.ID,
+		Username:   user.Username,
+		Status:     userStatus(user),
+		TenantID:   tenant.ID,
+		TenantName: tenant.Name,
+	}
+	if user.CreatedAt.Valid {
+		view.CreatedAt = &user.CreatedAt.Time
+	}
+	return view
+}
+
+// clientView is the rendered form of a client credential. ClientSecret is
+// only set when a command has just generated or received one.
+type clientView struct {
+	ID           string     `json:"id" yaml:"id"`
+	ClientID     string     `json:"client_id" yaml:"client_id"`
+	StreamID     string     `json:"stream_id,omitempty" yaml:"stream_id,omitempty"`
+	TenantID     string     `json:"tenant_id" yaml:"tenant_id"`
+	TenantName   string     `json:"tenant_name" yaml:"tenant_name"`
+	CreatedAt    *time.Time `json:"created_at,omitempty" yaml:"created_at,omitempty"`
+	ClientSecret string     `json:"client_secret,omitempty" yaml:"client_secret,omitempty"`
+}
+
+func (v clientView) resourceName() string { return "client/" + v.ClientID }
+
+func newClientView(client *models.ClientCredential, tenant *models.Tenant) clientView {
+	view := clientView{
+		ID:         client.ID,
+		ClientID:   client.ClientID,
+		TenantID:   tenant.ID,
+		TenantName: tenant.Name,
+	}
+	if client.StreamID.Valid {
+		view.StreamID = client.StreamID.String
+	}
+	if client.CreatedAt.Valid {
+		view.CreatedAt = &client.CreatedAt.Time
+	}
+	return view
+}
+
+// migrationView is the rendered form of the schema migration state
+type migrationView struct {
+	Version uint `json:"version" yaml:"version"`
+	Dirty   bool `json:"dirty" yaml:"dirty"`
+}
+
+func (v migrationView) resourceName() string { return fmt.Sprintf("migration/%d", v.Version) }
+
+// pendingMigrationView is a migration that has not been applied yet
+type pendingMigrationView struct {
+	Version uint   `json:"version" yaml:"version"`
+	Name    string `json:"name" yaml:"name"`
+}
+
+// migrationStatusView is the rendered form of 'migrate status'
+type migrationStatusView struct {
+	Version uint                   `json:"version" yaml:"version"`
+	Dirty   bool                   `json:"dirty" yaml:"dirty"`
+	Pending []pendingMigrationView `json:"pending" yaml:"pending"`
+}
+
+func (v migrationStatusView) resourceName() string { return fmt.Sprintf("migration/%d", v.Version) }
+
+func newMigrationStatusView(status *schema.Status) migrationStatusView {
+	view := migrationStatusView{
+		Version: status.Version,
+		Dirty:   status.Dirty,
+		Pending: []pendingMigrationView{},
+	}
+	for _, m := range status.Pending {
+		view.Pending = append(view.Pending, pendingMigrationView{Version: m.Version, Name: m.Name})
+	}
+	return view
+}
+
+// contextView is the rendered form of a connection context. Passwords in
+// the database URL are masked.
+type contextView struct {
+	Name         string `json:"name" yaml:"name"`
+	Current      bool   `json:"current" yaml:"current"`
+	DBURL        string `json:"db_url" yaml:"db_url"`
+	BrokerURL    string `json:"broker_url,omitempty" yaml:"broker_url,omitempty"`
+	Tenant       string `json:"tenant,omitempty" yaml:"tenant,omitempty"`
+	PasswordFile string `json:"password_file,omitempty" yaml:"password_file,omitempty"`
+	PasswordEnv  string `json:"password_env,omitempty" yaml:"password_env,omitempty"`
+
+	BrokerSASLMechanism string `json:"broker_sasl_mechanism,omitempty" yaml:"broker_sasl_mechanism,omitempty"`
+	BrokerUser          string `json:"broker_user,omitempty" yaml:"broker_user,omitempty"`
+	BrokerPasswordFile  string `json:"broker_password_file,omitempty" yaml:"broker_password_file,omitempty"`
+	BrokerPasswordEnv   string `json:"broker_password_env,omitempty" yaml:"broker_password_env,omitempty"`
+	BrokerTLS           bool   `json:"broker_tls,omitempty" yaml:"broker_tls,omitempty"`
+	BrokerCAFile        string `json:"broker_ca_file,omitempty" yaml:"broker_ca_file,omitempty"`
+	BrokerCertFile      string `json:"broker_cert_file,omitempty" yaml:"broker_cert_file,omitempty"`
+	BrokerKeyFile       string `json:"broker_key_file,omitempty" yaml:"broker_key_file,omitempty"`
+}
+
+func (v contextView) resourceName() string { return "context/" + v.Name }
+
+func newContextView(ctx *contextConfig, currentContext string) contextView {
+	view := contextView{
+		Name:         ctx.Name,
+		Current:      ctx.Name == currentContext,
+		DBURL:        "(invalid URL)",
+		BrokerURL:    ctx.BrokerURL,
+		Tenant:       ctx.Tenant,
+		PasswordFile: ctx.PasswordFile,
+		PasswordEnv:  ctx.PasswordEnv,
+
+		BrokerSASLMechanism: ctx.BrokerSASLMechanism,
+		BrokerUser:          ctx.BrokerUser,
+		BrokerPasswordFile:  ctx.BrokerPasswordFile,
+		BrokerPasswordEnv:   ctx.BrokerPasswordEnv,
+		BrokerTLS:           ctx.BrokerTLS,
+		BrokerCAFile:        ctx.BrokerCAFile,
+		BrokerCertFile:      ctx.BrokerCertFile,
+		BrokerKeyFile:       ctx.BrokerKeyFile,
+	}
+	if u, err := url.Parse(ctx.DBURL); err == nil {
+		view.DBURL = u.Redacted()
+	}
+	return view
+}
+
+// userStatus reports whether a user is active or disabled (soft-deleted)
+func userStatus(user *models.TenantUser) string {
+	if user.DeletedAt != nil && user.DeletedAt.Valid {
+		return "disabled"
+	}
+	return "active"
+}
+
+// formatNullTime formats a nullable timestamp for display
+func formatNullTime(t time.Time, valid bool) string {
+	if !valid {
+		return "N/A"
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// warnSecretHidden tells the user that -o name swallowed a generated secret
+func warnSecretHidden(cmd *cobra.Command, generated bool) {
+	if generated && outputFormat == outputName {
+		fmt.Fprintln(cmd.ErrOrStderr(), "⚠️  A secret was generated but is not shown with -o name; use -o json or -o yaml to capture it.")
+	}
+}
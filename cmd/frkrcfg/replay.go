@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/frkr-io/frkr-tools/pkg/traffic"
+	"github.com/spf13/cobra"
+)
+
+// replaySummaryView is the rendered form of a finished replay
+type replaySummaryView struct {
+	Stream      string         `json:"stream" yaml:"stream"`
+	Target      string         `json:"target" yaml:"target"`
+	Sent        int            `json:"sent" yaml:"sent"`
+	Failed      int            `json:"failed" yaml:"failed"`
+	Skipped     int            `json:"skipped" yaml:"skipped"`
+	Statuses    map[string]int `json:"statuses" yaml:"statuses"`
+	Errors      map[string]int `json:"errors,omitempty" yaml:"errors,omitempty"`
+	DurationMs  int64          `json:"duration_ms" yaml:"duration_ms"`
+	Interrupted bool           `json:"interrupted,omitempty" yaml:"interrupted,omitempty"`
+}
+
+func (v replaySummaryView) resourceName() string { return "replay/" + v.Stream }
+
+var streamReplayCmd = &cobra.Command{
+	Use:   "replay [stream-name-or-id]",
+	Short: "Re-send a stream's mirrored requests to a target URL",
+	Long: `Read the requests mirrored into a stream between --from and --to and send
+them again to --target. Each request keeps its method, path, query, headers
+and body; only the scheme, host and a base path come from --target.
+
+--speed paces the requests by the time they were captured: realtime keeps
+the original gaps, Nx (e.g. 10x) shortens them N times, and max sends as
+fast as --concurrency allows. With --concurrency above 1, requests may
+complete out of order.
+
+When done, a summary of the response status codes is printed. --filter
+selects requests the same way as 'stream tail'.
+
+--rules rewrites requests before they are sent: headers can be removed or
+set, paths mapped, body fields redacted and captured credentials replaced
+with fresh tokens. See the README for the rules file format.`,
+	Example: `  frkrcfg stream replay my-api --from 2024-01-02T15:00:00Z --to 2024-01-02T15:05:00Z \
+    --target http://localhost:3000 --broker-url localhost:19092
+  frkrcfg stream replay my-api --from 1h --speed max --concurrency 8 --target http://localhost:3000`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		targetURL, _ := cmd.Flags().GetString("target")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		target, err := traffic.NewTarget(targetURL, timeout)
+		if err != nil {
+			return err
+		}
+
+		readOpts, err := replayWindow(cmd, time.Now())
+		if err != nil {
+			return err
+		}
+
+		speedFlag, _ := cmd.Flags().GetString("speed")
+		speed, err := parseSpeed(speedFlag)
+		if err != nil {
+			return err
+		}
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be at least 1")
+		}
+
+		filterExprs, _ := cmd.Flags().GetStringArray("filter")
+		filters, err := traffic.ParseFilters(filterExprs)
+		if err != nil {
+			return err
+		}
+		quiet, _ := cmd.Flags().GetBool("quiet")
+		rules, err := loadRules(cmd)
+		if err != nil {
+			return err
+		}
+
+		stream, err := resolveStreamTopic(args[0])
+		if err != nil {
+			return err
+		}
+		rewriter, err := rules.Rewriter(stream.Name)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		var (
+			summary traffic.Summary
+			mu      sync.Mutex
+			skipped int
+		)
+		w := cmd.OutOrStdout()
+		replayer := traffic.NewReplayer(ctx, traffic.ReplayOptions{Speed: speed, Concurrency: concurrency},
+			func(ctx context.Context, rec traffic.Record) {
+				result := target.Do(ctx, rec.Request)
+				summary.Add(result)
+				if quiet || outputFormat != outputTable {
+					return
+				}
+				mu.Lock()
+				defer mu.Unlock()
+				printReplayResult(w, rec, result)
+			})
+
+		start := time.Now()
+		bm, err := newBrokerManager()
+		if err != nil {
+			return err
+		}
+		err = bm.ReadTopic(ctx, stream.Topic, readOpts, func(msg broker.Message) error {
+			rec, err := traffic.FromMessage(msg)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  Skipping message: %v\n", err)
+				skipped++
+				return nil
+			}
+			if !traffic.MatchAll(filters, rec.Request) {
+				return nil
+			}
+			if err := rewriter.Apply(ctx, &rec.Request); err != nil {
+				return err
+			}
+			return replayer.Submit(ctx, rec)
+		})
+		replayer.Wait()
+
+		interrupted := errors.Is(err, context.Canceled) || ctx.Err() != nil
+		if err != nil && !interrupted {
+			return err
+		}
+
+		view := newReplaySummaryView(stream.Name, targetURL, &summary, skipped, time.Since(start))
+		view.Interrupted = interrupted
+		return renderOne(cmd, view, func(w io.Writer) {
+			printReplaySummary(w, view)
+		})
+	},
+}
+
+func init() {
+	streamReplayCmd.Flags().String("from", "", "Replay requests captured at or after this time (RFC 3339 or an age, e.g. 1h); default: the oldest message")
+	streamReplayCmd.Flags().String("to", "", "Replay requests captured up to this time (RFC 3339 or an age); default: the current end of the topic")
+	streamReplayCmd.Flags().String("target", "", "Base URL to send the requests to, e.g. http://localhost:3000 (required)")
+	streamReplayCmd.Flags().String("speed", "realtime", "Pacing: realtime, Nx (e.g. 10x) or max")
+	streamReplayCmd.Flags().Int("concurrency", 1, "Number of requests in flight at most")
+	streamReplayCmd.Flags().Duration("timeout", 10*time.Second, "Timeout for each request")
+	streamReplayCmd.Flags().StringArray("filter", nil, "Only replay requests matching field=pattern (repeatable)")
+	streamReplayCmd.Flags().Bool("quiet", false, "Only print the summary")
+	streamReplayCmd.Flags().String("rules", "", "Rules file for rewriting requests before they are sent")
+	streamReplayCmd.MarkFlagRequired("target")
+
+	streamCmd.AddCommand(streamReplayCmd)
+}
+
+// replayWindow reads --from and --to into the options for reading a
+// window of a topic once, up to its current end
+func replayWindow(cmd *cobra.Command, now time.Time) (broker.ReadOptions, error) {
+	opts := broker.ReadOptions{FromBeginning: true}
+
+	if from, _ := cmd.Flags().GetString("from"); from != "" {
+		t, err := parseTimeFlag(from, now)
+		if err != nil {
+			return opts, err
+		}
+		opts.FromBeginning, opts.Since = false, t
+	}
+	if to, _ := cmd.Flags().GetString("to"); to != "" {
+		t, err := parseTimeFlag(to, now)
+		if err != nil {
+			return opts, err
+		}
+		opts.Until = t
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Until.After(opts.Since) {
+		return opts, fmt.Errorf("--to must be after --from")
+	}
+	return opts, nil
+}
+
+// parseSpeed parses --speed: realtime, max, or a factor such as 10x
+func parseSpeed(value string) (float64, error) {
+	switch value {
+	case "max":
+		return 0, nil
+	case "realtime":
+		return 1, nil
+	}
+	factor, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64)
+	if err != nil || factor <= 0 {
+		return 0, fmt.Errorf("invalid speed '%s' (expected realtime, max or a factor such as 10x)", value)
+	}
+	return factor, nil
+}
+
+func newReplaySummaryView(stream, target string, summary *traffic.Summary, skipped int, elapsed time.Duration) replaySummaryView {
+	statuses := make(map[string]int)
+	for status, count := range summary.Statuses() {
+		statuses[strconv.Itoa(status)] = count
+	}
+	return replaySummaryView{
+		Stream:     stream,
+		Target:     target,
+		Sent:       summary.Sent(),
+		Failed:     summary.Failed(),
+		Skipped:    skipped,
+		Statuses:   statuses,
+		Errors:     summary.Errors(),
+		DurationMs: elapsed.Milliseconds(),
+	}
+}
+
+func printReplayResult(w io.Writer, rec traffic.Record, result traffic.Result) {
+	if result.Err != nil {
+		fmt.Fprintf(w, "ERR  %-6s %s  %v\n", rec.Request.Method, rec.Request.URL(), result.Err)
+		return
+	}
+	fmt.Fprintf(w, "%d  %-6s %s  %s\n", result.Status, rec.Request.Method, rec.Request.URL(), result.Latency.Round(time.Millisecond))
+}
+
+func printReplaySummary(w io.Writer, view replaySummaryView) {
+	if view.Interrupted {
+		fmt.Fprintf(w, "\n⚠️  Replay interrupted\n")
+	}
+	fmt.Fprintf(w, "\nReplayed %d request(s) from stream '%s' to %s in %s\n",
+		view.Sent, view.Stream, view.Target, (time.Duration(view.DurationMs) * time.Millisecond).String())
+	for _, status := range traffic.SortedKeys(view.Statuses) {
+		fmt.Fprintf(w, "  %s: %d\n", status, view.Statuses[status])
+	}
+	if view.Failed > 0 {
+		fmt.Fprintf(w, "  failed: %d\n", view.Failed)
+		for _, msg := range traffic.SortedKeys(view.Errors) {
+			fmt.Fprintf(w, "    %dx %s\n", view.Errors[msg], msg)
+		}
+	}
+	if view.Skipped > 0 {
+		fmt.Fprintf(w, "  skipped (not a mirrored request): %d\n", view.Skipped)
+	}
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/frkr-io/frkr-common/util"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// addSecretFlags registers the ways a command can receive a secret named
+// name ("password" or "secret"): --<name>, --<name>-stdin and --<name>-file.
+// It also registers --secret-out.
+func addSecretFlags(cmd *cobra.Command, name, usage string) {
+	addSecretInputFlags(cmd, name, usage)
+	cmd.Flags().String("secret-out", "", "Write the "+name+" to this file (mode 0600) instead of printing it")
+}
+
+// addSecretInputFlags registers --<name>, --<name>-stdin and --<name>-file
+// for a command that uses an existing secret rather than issuing one
+func addSecretInputFlags(cmd *cobra.Command, name, usage string) {
+	cmd.Flags().String(name, "", usage+" (visible in shell history and ps; prefer --"+name+"-stdin or --"+name+"-file)")
+	cmd.Flags().Bool(name+"-stdin", false, "Read the "+name+" from stdin (prompts without echo when stdin is a terminal)")
+	cmd.Flags().String(name+"-file", "", "Read the "+name+" from the first line of a file")
+	cmd.MarkFlagsMutuallyExclusive(name, name+"-stdin", name+"-file")
+}
+
+// readSecret returns the secret given through the flags registered by
+// addSecretFlags, or "" if none was given and one should be generated
+func readSecret(cmd *cobra.Command, name string) (string, error) {
+	if value, _ := cmd.Flags().GetString(name); value != "" {
+		return value, nil
+	}
+	if path, _ := cmd.Flags().GetString(name + "-file"); path != "" {
+		return readSecretFile(path)
+	}
+	if fromStdin, _ := cmd.Flags().GetBool(name + "-stdin"); fromStdin {
+		if f, ok := cmd.InOrStdin().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			return promptSecret(cmd, f, name)
+		}
+		return readSecretLine(cmd.InOrStdin(), name)
+	}
+	return "", nil
+}
+
+// resolveSecret returns the secret given through the command's flags, or a
+// generated one. generated reports which of the two it is.
+func resolveSecret(cmd *cobra.Command, name string) (secret string, generated bool, err error) {
+	secret, err = readSecret(cmd, name)
+	if err != nil || secret != "" {
+		return secret, false, err
+	}
+
+	secret, err = util.GeneratePassword()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to generate %s: %w", name, err)
+	}
+	return secret, true, nil
+}
+
+// promptSecret asks for a secret twice on the terminal without echoing it
+func promptSecret(cmd *cobra.Command, tty *os.File, name string) (string, error) {
+	prompt := func(label string) (string, error) {
+		fmt.Fprint(cmd.ErrOrStderr(), label)
+		value, err := term.ReadPassword(int(tty.Fd()))
+		fmt.Fprintln(cmd.ErrOrStderr())
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return string(value), nil
+	}
+
+	secret, err := prompt("Enter " + name + ": ")
+	if err != nil {
+		return "", err
+	}
+	if secret == "" {
+		return "", fmt.Errorf("%s cannot be empty", name)
+	}
+	confirm, err := prompt("Confirm " + name + ": ")
+	if err != nil {
+		return "", err
+	}
+	if secret != confirm {
+		return "", fmt.Errorf("%ss do not match", name)
+	}
+	return secret, nil
+}
+
+// readSecretLine reads a secret from the first line of r
+func readSecretLine(r io.Reader, name string) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read %s from stdin: %w", name, err)
+	}
+	secret := strings.TrimRight(line, "\r\n")
+	if secret == "" {
+		return "", fmt.Errorf("no %s on stdin", name)
+	}
+	return secret, nil
+}
+
+// readSecretFile reads a secret from the first line of a file.
+// A leading ~/ is expanded to the home directory.
+func readSecretFile(path string) (string, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %s: %w", path, err)
+		}
+		path = filepath.Join(home, rest)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	secret, _, _ := strings.Cut(string(data), "\n")
+	secret = strings.TrimSuffix(secret, "\r")
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+	return secret, nil
+}
+
+// stagedSecret is a --secret-out file whose content has been written to a
+// temporary file next to its target but not yet moved into place
+type stagedSecret struct {
+	path string
+	tmp  string
+}
+
+// stageSecretFile writes content to a temporary file, readable only by its
+// owner, in the directory of path. Nothing at path changes until commit.
+func stageSecretFile(path, content string) (*stagedSecret, error) {
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", path)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a file next to %s: %w", path, err)
+	}
+	staged := &stagedSecret{path: path, tmp: f.Name()}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		staged.discard()
+		return nil, fmt.Errorf("failed to restrict permissions on %s: %w", f.Name(), err)
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		staged.discard()
+		return nil, fmt.Errorf("failed to write %s: %w", f.Name(), err)
+	}
+	if err := f.Close(); err != nil {
+		staged.discard()
+		return nil, fmt.Errorf("failed to write %s: %w", f.Name(), err)
+	}
+	return staged, nil
+}
+
+// commit moves the staged file over its target, replacing any existing file.
+// If that fails the temporary file is kept, since it may hold the only copy
+// of a secret that is already in use. A nil stagedSecret commits nothing.
+func (s *stagedSecret) commit() error {
+	if s == nil || s.tmp == "" {
+		return nil
+	}
+	tmp := s.tmp
+	s.tmp = ""
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write %s (the secret was saved to %s instead): %w", s.path, tmp, err)
+	}
+	return nil
+}
+
+// discard removes the temporary file if it has not been committed, leaving
+// the target as it was. A nil stagedSecret discards nothing.
+func (s *stagedSecret) discard() {
+	if s == nil || s.tmp == "" {
+		return
+	}
+	os.Remove(s.tmp)
+	s.tmp = ""
+}
+
+// secretOutput handles --secret-out. When it is set, content (the secret,
+// or the --emit output) is staged next to the file before the command
+// changes anything, so a path that cannot be written fails the command
+// early. The command commits the staged file once its change has succeeded
+// and discards it otherwise; the file at path is never touched on failure.
+func secretOutput(cmd *cobra.Command, content string) (path string, staged *stagedSecret, err error) {
+	path, _ = cmd.Flags().GetString("secret-out")
+	if path == "" {
+		return "", nil, nil
+	}
+	staged, err = stageSecretFile(path, content)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, staged, nil
+}
+
+// shownSecret is what table output prints in place of a secret
+func shownSecret(secret, secretOut string) string {
+	if secretOut != "" {
+		return "(written to " + secretOut + ")"
+	}
+	return secret
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSecret(t *testing.T) {
+	newCmd := func(stdin string, args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		addSecretFlags(cmd, "password", "Password")
+		cmd.SetIn(strings.NewReader(stdin))
+		require.NoError(t, cmd.Flags().Parse(args))
+		return cmd
+	}
+
+	t.Run("flag", func(t *testing.T) {
+		secret, err := readSecret(newCmd("", "--password", "from-flag"), "password")
+		require.NoError(t, err)
+		require.Equal(t, "from-flag", secret)
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		secret, err := readSecret(newCmd("from stdin\r\nsecond line\n", "--password-stdin"), "password")
+		require.NoError(t, err)
+		require.Equal(t, "from stdin", secret)
+
+		secret, err = readSecret(newCmd("no newline", "--password-stdin"), "password")
+		require.NoError(t, err)
+		require.Equal(t, "no newline", secret)
+
+		_, err = readSecret(newCmd("", "--password-stdin"), "password")
+		require.Error(t, err)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "password")
+		require.NoError(t, os.WriteFile(path, []byte("from-file\n"), 0600))
+
+		secret, err := readSecret(newCmd("", "--password-file", path), "password")
+		require.NoError(t, err)
+		require.Equal(t, "from-file", secret)
+	})
+
+	t.Run("none given", func(t *testing.T) {
+		secret, err := readSecret(newCmd("ignored"), "password")
+		require.NoError(t, err)
+		require.Empty(t, secret)
+
+		secret, generated, err := resolveSecret(newCmd(""), "password")
+		require.NoError(t, err)
+		require.True(t, generated)
+		require.NotEmpty(t, secret)
+	})
+}
+
+func TestReadSecretFile(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(path, []byte("secret\r\nignored\n"), 0600))
+	secret, err := readSecretFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+
+	empty := filepath.Join(dir, "empty")
+	require.NoError(t, os.WriteFile(empty, []byte("\n"), 0600))
+	_, err = readSecretFile(empty)
+	require.Error(t, err)
+}
+
+func TestStageSecretFile(t *testing.T) {
+	t.Run("commit replaces the target", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "secret")
+		require.NoError(t, os.WriteFile(path, []byte("old and longer\n"), 0644))
+
+		staged, err := stageSecretFile(path, "new")
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "old and longer\n", string(data), "target must not change before commit")
+
+		require.NoError(t, staged.commit())
+		staged.discard()
+
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "new\n", string(data))
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("discard keeps the target", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "secret")
+		require.NoError(t, os.WriteFile(path, []byte("keep me\n"), 0600))
+
+		staged, err := stageSecretFile(path, "new")
+		require.NoError(t, err)
+		staged.discard()
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "keep me\n", string(data))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("discard creates nothing", func(t *testing.T) {
+		dir := t.TempDir()
+		staged, err := stageSecretFile(filepath.Join(dir, "secret"), "new")
+		require.NoError(t, err)
+		staged.discard()
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, entries)
+	})
+
+	t.Run("unwritable path fails early", func(t *testing.T) {
+		_, err := stageSecretFile(filepath.Join(t.TempDir(), "missing", "secret"), "new")
+		require.Error(t, err)
+
+		_, err = stageSecretFile(t.TempDir(), "new")
+		require.Error(t, err)
+	})
+}
@@ -2,8 +2,14 @@ package main
 
 import (
 	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/frkr-io/frkr-common/models"
 	"github.com/frkr-io/frkr-common/util"
+	"github.com/frkr-io/frkr-tools/pkg/broker"
 	"github.com/frkr-io/frkr-tools/pkg/db"
 	"github.com/spf13/cobra"
 )
@@ -17,8 +23,12 @@ var streamCmd = &cobra.Command{
 var streamCreateCmd = &cobra.Command{
 	Use:   "create [stream-name]",
 	Short: "Create a new stream",
-	Long:  `Create a new stream for message mirroring.`,
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new stream for message mirroring.
+
+When --broker-url is set, the stream's topic is created as well, with the
+given partitions and replication factor and retention.ms derived from
+--retention-days.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		streamName := args[0]
 
@@ -37,43 +47,77 @@ var streamCreateCmd = &cobra.Command{
 		}
 		retentionDays = normalizedDays
 
+		topicConfig := broker.DefaultTopicConfig
+		topicConfig.Partitions, _ = cmd.Flags().GetInt("partitions")
+		topicConfig.ReplicationFactor, _ = cmd.Flags().GetInt("replication-factor")
+		topicConfig.RetentionDays = retentionDays
+		if brokerURL == "" && (cmd.Flags().Changed("partitions") || cmd.Flags().Changed("replication-factor")) {
+			return fmt.Errorf("--partitions and --replication-factor require --broker-url")
+		}
+
 		conn, err := getDB()
 		if err != nil {
 			return err
 		}
 		defer conn.Close()
 
-		// Create or get tenant
-		tenant, err := db.CreateOrGetTenant(conn, tenantName)
+		tenant, err := resolveTenant(conn)
 		if err != nil {
-			return fmt.Errorf("failed to create/get tenant: %w", err)
+			return err
+		}
+
+		var bm *broker.Manager
+		if brokerURL != "" {
+			if bm, err = newBrokerManager(); err != nil {
+				return err
+			}
 		}
 
-		// Create stream
-		stream, err := db.CreateStream(conn, tenant.ID, streamName, description, retentionDays)
+		// Insert the row first and create the topic inside the same
+		// transaction. A duplicate name (deleted streams included), an
+		// invalid stream or a database error then leaves no topic behind,
+		// and a broker error rolls the row back. CreateTopic tolerates an
+		// existing topic, so a failed commit can simply be retried.
+		var stream *models.Stream
+		err = db.NewStore(conn).WithTx(cmd.Context(), func(tx db.Store) error {
+			created, err := tx.CreateStream(cmd.Context(), tenant.ID, streamName, description, retentionDays)
+			if err != nil {
+				return fmt.Errorf("failed to create stream: %w", err)
+			}
+			if bm != nil {
+				if err := bm.CreateTopic(created.Topic, topicConfig); err != nil {
+					return fmt.Errorf("failed to create topic: %w", err)
+				}
+			}
+			stream = created
+			return nil
+		})
 		if err != nil {
-			return fmt.Errorf("failed to create stream: %w", err)
+			return err
 		}
 
 		// Output stream information
-		fmt.Fprintf(cmd.OutOrStdout(), "✅ Stream created successfully!\n\n")
-		fmt.Fprintf(cmd.OutOrStdout(), "Stream ID:     %s\n", stream.ID)
-		fmt.Fprintf(cmd.OutOrStdout(), "Stream Name:   %s\n", stream.Name)
-		fmt.Fprintf(cmd.OutOrStdout(), "Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
-		fmt.Fprintf(cmd.OutOrStdout(), "Topic:         %s\n", stream.Topic)
-		fmt.Fprintf(cmd.OutOrStdout(), "Retention:     %d days\n", stream.RetentionDays)
-		fmt.Fprintf(cmd.OutOrStdout(), "Status:        %s\n\n", stream.Status)
-		fmt.Fprintf(cmd.OutOrStdout(), "Use this stream ID in your SDK:\n")
-		fmt.Fprintf(cmd.OutOrStdout(), "  streamId: '%s'\n", stream.Name)
-
-		return nil
+		return renderOne(cmd, newStreamView(stream), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Stream created successfully!\n\n")
+			fmt.Fprintf(w, "Stream ID:     %s\n", stream.ID)
+			fmt.Fprintf(w, "Stream Name:   %s\n", stream.Name)
+			fmt.Fprintf(w, "Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
+			fmt.Fprintf(w, "Topic:         %s\n", stream.Topic)
+			fmt.Fprintf(w, "Retention:     %d days\n", stream.RetentionDays)
+			fmt.Fprintf(w, "Status:        %s\n\n", stream.Status)
+			if brokerURL == "" {
+				fmt.Fprintf(w, "Note: --broker-url not set, topic %s was not created.\n\n", stream.Topic)
+			}
+			fmt.Fprintf(w, "Use this stream ID in your SDK:\n")
+			fmt.Fprintf(w, "  streamId: '%s'\n", stream.Name)
+		})
 	},
 }
 
 var streamListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all streams",
-	Long:  `List all streams for a tenant.`,
+	Long:  `List all streams for a tenant. With --deleted, list soft-deleted streams instead.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		conn, err := getDB()
 		if err != nil {
@@ -81,35 +125,64 @@ var streamListCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		// Get tenant
-		tenant, err := db.CreateOrGetTenant(conn, tenantName)
+		tenant, err := resolveTenant(conn)
 		if err != nil {
-			return fmt.Errorf("failed to get tenant: %w", err)
+			return err
 		}
 
 		// List streams
-		streams, err := db.ListStreams(conn, tenant.ID)
+		deleted, _ := cmd.Flags().GetBool("deleted")
+		var streams []*models.Stream
+		if deleted {
+			streams, err = db.ListDeletedStreams(conn, tenant.ID)
+		} else {
+			streams, err = db.ListStreams(conn, tenant.ID)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to list streams: %w", err)
 		}
 
-		if len(streams) == 0 {
-			fmt.Fprintf(cmd.OutOrStdout(), "No streams found for tenant '%s'\n", tenantName)
-			return nil
-		}
-
-		fmt.Fprintf(cmd.OutOrStdout(), "Streams for tenant '%s':\n\n", tenantName)
-		fmt.Fprintf(cmd.OutOrStdout(), "%-36s %-20s %-15s %-30s\n", "ID", "Name", "Status", "Topic")
-		fmt.Fprintf(cmd.OutOrStdout(), "%s\n", "------------------------------------------------------------------------------------------------")
+		views := make([]streamView, 0, len(streams))
 		for _, stream := range streams {
-			fmt.Fprintf(cmd.OutOrStdout(), "%-36s %-20s %-15s %-30s\n",
-				stream.ID,
-				stream.Name,
-				stream.Status,
-				stream.Topic)
+			views = append(views, newStreamView(stream))
 		}
 
-		return nil
+		return renderList(cmd, views, func(w io.Writer) {
+			if deleted {
+				if len(streams) == 0 {
+					fmt.Fprintf(w, "No deleted streams found for tenant '%s'\n", tenantName)
+					return
+				}
+
+				fmt.Fprintf(w, "Deleted streams for tenant '%s':\n\n", tenantName)
+				fmt.Fprintf(w, "%-36s %-20s %-20s %-30s\n", "ID", "Name", "Deleted", "Topic")
+				fmt.Fprintf(w, "%s\n", "---------------------------------------------------------------------------------------------------------")
+				for _, stream := range streams {
+					fmt.Fprintf(w, "%-36s %-20s %-20s %-30s\n",
+						stream.ID,
+						stream.Name,
+						stream.DeletedAt.Format("2006-01-02 15:04:05"),
+						stream.Topic)
+				}
+				return
+			}
+
+			if len(streams) == 0 {
+				fmt.Fprintf(w, "No streams found for tenant '%s'\n", tenantName)
+				return
+			}
+
+			fmt.Fprintf(w, "Streams for tenant '%s':\n\n", tenantName)
+			fmt.Fprintf(w, "%-36s %-20s %-15s %-30s\n", "ID", "Name", "Status", "Topic")
+			fmt.Fprintf(w, "%s\n", "------------------------------------------------------------------------------------------------")
+			for _, stream := range streams {
+				fmt.Fprintf(w, "%-36s %-20s %-15s %-30s\n",
+					stream.ID,
+					stream.Name,
+					stream.Status,
+					stream.Topic)
+			}
+		})
 	},
 }
 
@@ -131,10 +204,9 @@ var streamGetCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		// Get tenant
-		tenant, err := db.CreateOrGetTenant(conn, tenantName)
+		tenant, err := resolveTenant(conn)
 		if err != nil {
-			return fmt.Errorf("failed to get tenant: %w", err)
+			return err
 		}
 
 		// Get stream
@@ -143,17 +215,105 @@ var streamGetCmd = &cobra.Command{
 			return fmt.Errorf("failed to get stream: %w", err)
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "Stream Details:\n\n")
-		fmt.Fprintf(cmd.OutOrStdout(), "ID:            %s\n", stream.ID)
-		fmt.Fprintf(cmd.OutOrStdout(), "Name:          %s\n", stream.Name)
-		fmt.Fprintf(cmd.OutOrStdout(), "Description:   %s\n", stream.Description)
-		fmt.Fprintf(cmd.OutOrStdout(), "Status:        %s\n", stream.Status)
-		fmt.Fprintf(cmd.OutOrStdout(), "Retention:     %d days\n", stream.RetentionDays)
-		fmt.Fprintf(cmd.OutOrStdout(), "Topic:         %s\n", stream.Topic)
-		fmt.Fprintf(cmd.OutOrStdout(), "Tenant ID:     %s\n", stream.TenantID)
-		fmt.Fprintf(cmd.OutOrStdout(), "Created:       %s\n", stream.CreatedAt.Format("2006-01-02 15:04:05"))
+		return renderOne(cmd, newStreamView(stream), func(w io.Writer) {
+			fmt.Fprintf(w, "Stream Details:\n\n")
+			fmt.Fprintf(w, "ID:            %s\n", stream.ID)
+			fmt.Fprintf(w, "Name:          %s\n", stream.Name)
+			fmt.Fprintf(w, "Description:   %s\n", stream.Description)
+			fmt.Fprintf(w, "Status:        %s\n", stream.Status)
+			fmt.Fprintf(w, "Retention:     %d days\n", stream.RetentionDays)
+			fmt.Fprintf(w, "Topic:         %s\n", stream.Topic)
+			fmt.Fprintf(w, "Tenant ID:     %s\n", stream.TenantID)
+			fmt.Fprintf(w, "Created:       %s\n", stream.CreatedAt.Format("2006-01-02 15:04:05"))
+		})
+	},
+}
 
-		return nil
+var streamUpdateCmd = &cobra.Command{
+	Use:   "update [stream-name-or-id]",
+	Short: "Update a stream",
+	Long: `Change a stream's description, retention or status. Only the flags that are given are changed.
+
+When --broker-url is set, a retention change is also applied to the stream's
+topic as retention.ms. Without it, only the database is updated and the topic
+keeps its current retention.`,
+	Example: `  frkrcfg stream update my-api --retention-days 30 --broker-url localhost:19092
+  frkrcfg stream update my-api --status paused`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		streamIdentifier := args[0]
+
+		var update db.StreamUpdate
+		if cmd.Flags().Changed("description") {
+			description, _ := cmd.Flags().GetString("description")
+			update.Description = &description
+		}
+		if cmd.Flags().Changed("retention-days") {
+			retentionDays, _ := cmd.Flags().GetInt("retention-days")
+			// NormalizeRetentionDays maps 0 to the create default, which
+			// would silently reset an existing stream to 7 days
+			if retentionDays < 1 {
+				return fmt.Errorf("--retention-days must be at least 1, got %d", retentionDays)
+			}
+			normalizedDays, err := util.NormalizeRetentionDays(retentionDays)
+			if err != nil {
+				return err
+			}
+			update.RetentionDays = &normalizedDays
+		}
+		if cmd.Flags().Changed("status") {
+			status, _ := cmd.Flags().GetString("status")
+			update.Status = &status
+		}
+		if update.Description == nil && update.RetentionDays == nil && update.Status == nil {
+			return fmt.Errorf("nothing to update: set --description, --retention-days or --status")
+		}
+
+		// Catch bad broker settings before the database is changed
+		var bm *broker.Manager
+		if update.RetentionDays != nil && brokerURL != "" {
+			var err error
+			if bm, err = newBrokerManager(); err != nil {
+				return err
+			}
+		}
+
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		stream, err := db.UpdateStream(conn, tenant.ID, streamIdentifier, update)
+		if err != nil {
+			return fmt.Errorf("failed to update stream: %w", err)
+		}
+
+		topicUpdated := false
+		if bm != nil {
+			if err := bm.SetRetention(stream.Topic, stream.RetentionDays); err != nil {
+				return fmt.Errorf("stream updated in the database, but the topic was not: %w (re-run the command to retry)", err)
+			}
+			topicUpdated = true
+		}
+
+		return renderOne(cmd, newStreamView(stream), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Stream updated successfully!\n\n")
+			fmt.Fprintf(w, "Stream Name:   %s\n", stream.Name)
+			fmt.Fprintf(w, "Description:   %s\n", stream.Description)
+			fmt.Fprintf(w, "Retention:     %d days\n", stream.RetentionDays)
+			fmt.Fprintf(w, "Status:        %s\n", stream.Status)
+			if topicUpdated {
+				fmt.Fprintf(w, "\nTopic %s retention.ms set to %d\n", stream.Topic, broker.RetentionMs(stream.RetentionDays))
+			} else if update.RetentionDays != nil {
+				fmt.Fprintf(w, "\nNote: --broker-url not set, topic %s retention was not changed.\n", stream.Topic)
+			}
+		})
 	},
 }
 
@@ -175,16 +335,29 @@ var streamDeleteCmd = &cobra.Command{
 			return fmt.Errorf("deletion requires --force flag for safety")
 		}
 
+		deleteTopic, _ := cmd.Flags().GetBool("delete-topic")
+		if deleteTopic && brokerURL == "" {
+			return fmt.Errorf("--delete-topic requires --broker-url")
+		}
+
+		// Catch bad broker settings before the database is changed
+		var bm *broker.Manager
+		if deleteTopic {
+			var err error
+			if bm, err = newBrokerManager(); err != nil {
+				return err
+			}
+		}
+
 		conn, err := getDB()
 		if err != nil {
 			return err
 		}
 		defer conn.Close()
 
-		// Get tenant
-		tenant, err := db.CreateOrGetTenant(conn, tenantName)
+		tenant, err := resolveTenant(conn)
 		if err != nil {
-			return fmt.Errorf("failed to get tenant: %w", err)
+			return err
 		}
 
 		// Get stream first to show what we're deleting
@@ -199,13 +372,140 @@ var streamDeleteCmd = &cobra.Command{
 			return fmt.Errorf("failed to delete stream: %w", err)
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "✅ Stream deleted successfully!\n\n")
-		fmt.Fprintf(cmd.OutOrStdout(), "Deleted stream:\n")
-		fmt.Fprintf(cmd.OutOrStdout(), "  Name: %s\n", stream.Name)
-		fmt.Fprintf(cmd.OutOrStdout(), "  ID:   %s\n", stream.ID)
-		fmt.Fprintf(cmd.OutOrStdout(), "  Topic: %s\n", stream.Topic)
-		fmt.Fprintf(cmd.OutOrStdout(), "\nNote: This is a soft delete. The stream data remains in the database.\n")
+		if deleteTopic {
+			if err := bm.DeleteTopic(stream.Topic); err != nil {
+				return fmt.Errorf("stream deleted, but its topic was not: %w", err)
+			}
+		}
+
+		return renderOne(cmd, newStreamView(stream), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Stream deleted successfully!\n\n")
+			fmt.Fprintf(w, "Deleted stream:\n")
+			fmt.Fprintf(w, "  Name: %s\n", stream.Name)
+			fmt.Fprintf(w, "  ID:   %s\n", stream.ID)
+			fmt.Fprintf(w, "  Topic: %s\n", stream.Topic)
+			fmt.Fprintf(w, "\nNote: This is a soft delete. The stream data remains in the database.\n")
+			if deleteTopic {
+				fmt.Fprintf(w, "Topic %s and its messages were deleted; restoring the stream will not bring them back.\n", stream.Topic)
+			}
+			fmt.Fprintf(w, "Use 'frkrcfg stream restore %s' to undo it.\n", stream.ID)
+		})
+	},
+}
+
+var streamRestoreCmd = &cobra.Command{
+	Use:   "restore [stream-name-or-id]",
+	Short: "Restore a deleted stream",
+	Long:  `Undo a soft delete. Clients scoped to the stream start working again. Streams that have been purged cannot be restored.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		stream, err := db.RestoreStream(conn, tenant.ID, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to restore stream: %w", err)
+		}
+
+		return renderOne(cmd, newStreamView(stream), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Stream '%s' restored\n", stream.Name)
+		})
+	},
+}
+
+var streamPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Permanently remove old deleted streams",
+	Long: `Hard-delete streams that were soft-deleted longer ago than --older-than,
+together with the clients scoped to them. Purged streams cannot be restored.
+
+With --delete-topics, the backing topics are deleted from the broker given by
+--broker-url as well. Otherwise the topics and their messages are left in place.`,
+	Example: `  frkrcfg stream purge --older-than 30d --force
+  frkrcfg stream purge --older-than 0 --force --delete-topics --broker-url localhost:19092`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		olderThanFlag, _ := cmd.Flags().GetString("older-than")
+		olderThan, err := parseAge(olderThanFlag)
+		if err != nil {
+			return err
+		}
+
+		force, _ := cmd.Flags().GetBool("force")
+		if !force {
+			return fmt.Errorf("purge requires --force flag for safety")
+		}
+
+		deleteTopics, _ := cmd.Flags().GetBool("delete-topics")
+		if deleteTopics && brokerURL == "" {
+			return fmt.Errorf("--delete-topics requires --broker-url")
+		}
+
+		// Catch bad broker settings before the database is changed
+		var bm *broker.Manager
+		if deleteTopics {
+			if bm, err = newBrokerManager(); err != nil {
+				return err
+			}
+		}
 
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		streams, clientsDeleted, err := db.PurgeStreams(conn, tenant.ID, olderThan)
+		if err != nil {
+			return fmt.Errorf("failed to purge streams: %w", err)
+		}
+
+		var topicErrs []string
+		if deleteTopics {
+			for _, stream := range streams {
+				if err := bm.DeleteTopic(stream.Topic); err != nil {
+					topicErrs = append(topicErrs, err.Error())
+				}
+			}
+		}
+
+		views := make([]streamView, 0, len(streams))
+		for _, stream := range streams {
+			views = append(views, newStreamView(stream))
+		}
+
+		if err := renderList(cmd, views, func(w io.Writer) {
+			if len(streams) == 0 {
+				fmt.Fprintf(w, "No deleted streams older than %s for tenant '%s'\n", olderThanFlag, tenantName)
+				return
+			}
+			fmt.Fprintf(w, "✅ Purged %d stream(s) and %d client(s)\n\n", len(streams), clientsDeleted)
+			for _, stream := range streams {
+				fmt.Fprintf(w, "  %s (%s)\n", stream.Name, stream.Topic)
+			}
+			if !deleteTopics {
+				fmt.Fprintf(w, "\nNote: topics were not deleted. Use --delete-topics --broker-url to remove them.\n")
+			}
+		}); err != nil {
+			return err
+		}
+
+		if len(topicErrs) > 0 {
+			return fmt.Errorf("streams were purged, but some topics were not deleted:\n  %s", strings.Join(topicErrs, "\n  "))
+		}
 		return nil
 	},
 }
@@ -213,11 +513,47 @@ var streamDeleteCmd = &cobra.Command{
 func init() {
 	streamCreateCmd.Flags().String("description", "", "Stream description")
 	streamCreateCmd.Flags().Int("retention-days", 7, "Retention period in days (default: 7)")
+	streamCreateCmd.Flags().Int("partitions", broker.DefaultTopicConfig.Partitions, "Topic partitions (requires --broker-url)")
+	streamCreateCmd.Flags().Int("replication-factor", broker.DefaultTopicConfig.ReplicationFactor, "Topic replication factor (requires --broker-url)")
+
+	streamUpdateCmd.Flags().String("description", "", "New stream description")
+	streamUpdateCmd.Flags().Int("retention-days", 0, "New retention period in days (1-365)")
+	streamUpdateCmd.Flags().String("status", "", "New stream status (active or paused)")
+
+	streamListCmd.Flags().Bool("deleted", false, "List soft-deleted streams instead of active ones")
 
 	streamDeleteCmd.Flags().Bool("force", false, "Force deletion (required for safety)")
+	streamDeleteCmd.Flags().Bool("delete-topic", false, "Also delete the backing topic and its messages (requires --broker-url)")
+
+	streamPurgeCmd.Flags().String("older-than", "30d", "Only purge streams deleted longer ago than this (e.g. 30d, 12h, 0)")
+	streamPurgeCmd.Flags().Bool("force", false, "Force purge (required for safety)")
+	streamPurgeCmd.Flags().Bool("delete-topics", false, "Also delete the backing topics (requires --broker-url)")
 
 	streamCmd.AddCommand(streamCreateCmd)
 	streamCmd.AddCommand(streamListCmd)
 	streamCmd.AddCommand(streamGetCmd)
+	streamCmd.AddCommand(streamUpdateCmd)
 	streamCmd.AddCommand(streamDeleteCmd)
+	streamCmd.AddCommand(streamRestoreCmd)
+	streamCmd.AddCommand(streamPurgeCmd)
+}
+
+// parseAge parses a duration that may also be given in days, e.g. "30d".
+// A bare "0" means no minimum age.
+func parseAge(value string) (time.Duration, error) {
+	if value == "0" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid age '%s' (expected e.g. 30d or 12h)", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid age '%s' (expected e.g. 30d or 12h)", value)
+	}
+	return d, nil
 }
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/frkr-io/frkr-tools/pkg/traffic"
+	"github.com/spf13/cobra"
+)
+
+var streamTailCmd = &cobra.Command{
+	Use:   "tail [stream-name-or-id]",
+	Short: "Print the requests mirrored into a stream",
+	Long: `Consume a stream's topic from the broker given by --broker-url and print
+the mirrored requests: time, method, path, response status and headers.
+With -o json, each request is printed as one JSON object per line (NDJSON).
+
+By default only new requests are printed until interrupted. --from-beginning
+or --since start further back, and --follow=false stops at the current end
+of the topic.
+
+--filter selects requests by field=pattern, where * matches anything
+(including /). Fields are method, path, status, request_id and
+header.<name>. Repeated filters must all match.`,
+	Example: `  frkrcfg stream tail my-api --broker-url localhost:19092
+  frkrcfg stream tail my-api --since 10m --filter path=/api/* --filter method=POST
+  frkrcfg stream tail my-api --from-beginning --follow=false -o json | jq .request.path`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if outputFormat != outputTable && outputFormat != outputJSON {
+			return fmt.Errorf("stream tail supports -o table or -o json, not %s", outputFormat)
+		}
+
+		opts := broker.ReadOptions{}
+		opts.FromBeginning, _ = cmd.Flags().GetBool("from-beginning")
+		opts.Follow, _ = cmd.Flags().GetBool("follow")
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			if opts.FromBeginning {
+				return fmt.Errorf("--since and --from-beginning cannot be combined")
+			}
+			t, err := parseTimeFlag(since, time.Now())
+			if err != nil {
+				return err
+			}
+			opts.Since = t
+		}
+
+		filterExprs, _ := cmd.Flags().GetStringArray("filter")
+		filters, err := traffic.ParseFilters(filterExprs)
+		if err != nil {
+			return err
+		}
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		stream, err := resolveStreamTopic(args[0])
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		w := cmd.OutOrStdout()
+		printed := 0
+		bm, err := newBrokerManager()
+		if err != nil {
+			return err
+		}
+		return bm.ReadTopic(ctx, stream.Topic, opts, func(msg broker.Message) error {
+			rec, err := traffic.FromMessage(msg)
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "⚠️  Skipping message: %v\n", err)
+				return nil
+			}
+			if !traffic.MatchAll(filters, rec.Request) {
+				return nil
+			}
+
+			if err := printRecord(w, rec); err != nil {
+				return err
+			}
+			printed++
+			if limit > 0 && printed >= limit {
+				return broker.ErrStop
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	streamTailCmd.Flags().Bool("from-beginning", false, "Start at the oldest message on the topic")
+	streamTailCmd.Flags().String("since", "", "Start at this time (RFC 3339) or this long ago (e.g. 10m, 2d)")
+	streamTailCmd.Flags().Bool("follow", true, "Keep waiting for new requests")
+	streamTailCmd.Flags().StringArray("filter", nil, "Only print requests matching field=pattern (repeatable)")
+	streamTailCmd.Flags().Int("limit", 0, "Stop after printing this many requests (0 means no limit)")
+
+	streamCmd.AddCommand(streamTailCmd)
+}
+
+// printRecord writes a record as a line of NDJSON, or as text for table output
+func printRecord(w io.Writer, rec traffic.Record) error {
+	if outputFormat == outputJSON {
+		return traffic.WriteNDJSON(w, rec)
+	}
+
+	req := rec.Request
+	status := "-"
+	if s := req.Status(); s != 0 {
+		status = strconv.Itoa(s)
+	}
+	fmt.Fprintf(w, "%s  %-6s %s  %s", rec.Time.UTC().Format(time.RFC3339Nano), req.Method, req.URL(), status)
+	if req.RequestID != "" {
+		fmt.Fprintf(w, "  %s", req.RequestID)
+	}
+	fmt.Fprintln(w)
+	for _, name := range traffic.SortedHeaderNames(req.Headers) {
+		fmt.Fprintf(w, "    %s: %s\n", name, req.Headers[name])
+	}
+	return nil
+}
@@ -1,18 +1,18 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"io"
 
-	"github.com/frkr-io/frkr-common/db"
+	"github.com/frkr-io/frkr-tools/pkg/db"
 	"github.com/spf13/cobra"
 )
 
 var tenantCmd = &cobra.Command{
 	Use:   "tenant",
 	Short: "Manage tenants",
-	Long:  `Create and view tenants directly in the database.`,
+	Long:  `Create, list, rename and delete tenants directly in the database.`,
 }
 
 var tenantCreateCmd = &cobra.Command{
@@ -20,82 +20,176 @@ var tenantCreateCmd = &cobra.Command{
 	Short: "Create a new tenant",
 	Long:  `Create a new tenant in the database. If it already exists, returns the existing ID.`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		
+
 		conn, err := getDB()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error connecting to database: %w", err)
 		}
 		defer conn.Close()
 
 		tenant, err := db.CreateOrGetTenant(conn, name)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating tenant: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error creating tenant: %w", err)
 		}
 
-
-		if outputFormat == "json" {
-			out := map[string]string{
-				"id":   tenant.ID,
-				"name": tenant.Name,
-			}
-			if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
-				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-				os.Exit(1)
-			}
-			return
-		}
-
-		fmt.Printf("✅ Tenant '%s' ready\n", tenant.Name)
-		fmt.Printf("ID: %s\n", tenant.ID)
+		return renderOne(cmd, newTenantView(tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Tenant '%s' ready\n", tenant.Name)
+			fmt.Fprintf(w, "ID: %s\n", tenant.ID)
+		})
 	},
 }
 
 var tenantGetCmd = &cobra.Command{
 	Use:   "get [name]",
 	Short: "Get tenant details",
-	Long:  `Get tenant details and ID from the database.`,
+	Long:  `Get tenant details and ID from the database. Unknown tenants are an error, not created.`,
 	Args:  cobra.ExactArgs(1),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		name := args[0]
-		
+
 		conn, err := getDB()
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error connecting to database: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error connecting to database: %w", err)
 		}
 		defer conn.Close()
 
-		// Use CreateOrGetTenant for now as it handles retrieval.
-		// In a strictly read-only 'get', we might want a separate db.GetTenantByName,
-		// but CreateOrGet is safe enough for local config tool usage and simplifies logic.
-		tenant, err := db.CreateOrGetTenant(conn, name)
+		tenant, err := db.GetTenantByName(conn, name)
+		if errors.Is(err, db.ErrNotFound) {
+			return fmt.Errorf("tenant '%s' not found", name)
+		}
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error getting tenant: %v\n", err)
-			os.Exit(1)
+			return fmt.Errorf("error getting tenant: %w", err)
 		}
 
+		return renderOne(cmd, newTenantView(tenant), func(w io.Writer) {
+			fmt.Fprintln(w, tenant.ID)
+		})
+	},
+}
 
-		if outputFormat == "json" {
-			out := map[string]string{
-				"id":   tenant.ID,
-				"name": tenant.Name,
+var tenantListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all tenants",
+	Long:  `List all active tenants.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return fmt.Errorf("error connecting to database: %w", err)
+		}
+		defer conn.Close()
+
+		tenants, err := db.ListTenants(conn)
+		if err != nil {
+			return fmt.Errorf("failed to list tenants: %w", err)
+		}
+
+		views := make([]tenantView, 0, len(tenants))
+		for _, tenant := range tenants {
+			views = append(views, newTenantView(tenant))
+		}
+
+		return renderList(cmd, views, func(w io.Writer) {
+			if len(tenants) == 0 {
+				fmt.Fprintln(w, "No tenants found")
+				return
 			}
-			if err := json.NewEncoder(os.Stdout).Encode(out); err != nil {
-				fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-				os.Exit(1)
+
+			fmt.Fprintf(w, "%-36s %-30s %-10s %-20s\n", "ID", "Name", "Plan", "Created")
+			fmt.Fprintf(w, "%s\n", "------------------------------------------------------------------------------------------------------")
+			for _, tenant := range tenants {
+				fmt.Fprintf(w, "%-36s %-30s %-10s %-20s\n",
+					tenant.ID,
+					tenant.Name,
+					tenant.Plan,
+					tenant.CreatedAt.Format("2006-01-02 15:04:05"))
 			}
-			return
+		})
+	},
+}
+
+var tenantRenameCmd = &cobra.Command{
+	Use:   "rename [old-name] [new-name]",
+	Short: "Rename a tenant",
+	Long:  `Rename a tenant. The tenant ID does not change, so existing topics, streams, users and clients are unaffected.`,
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		oldName, newName := args[0], args[1]
+
+		conn, err := getDB()
+		if err != nil {
+			return fmt.Errorf("error connecting to database: %w", err)
+		}
+		defer conn.Close()
+
+		tenant, err := db.RenameTenant(conn, oldName, newName)
+		if errors.Is(err, db.ErrNotFound) {
+			return fmt.Errorf("tenant '%s' not found", oldName)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to rename tenant: %w", err)
+		}
+
+		return renderOne(cmd, newTenantView(tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Tenant '%s' renamed to '%s'\n", oldName, tenant.Name)
+		})
+	},
+}
+
+var tenantDeleteCmd = &cobra.Command{
+	Use:   "delete [name]",
+	Short: "Delete a tenant",
+	Long: `Soft-delete a tenant.
+
+A tenant that still has streams, users or clients is only deleted with
+--cascade, which also soft-deletes its streams, disables its users and
+revokes its clients.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		force, _ := cmd.Flags().GetBool("force")
+		if !force {
+			return fmt.Errorf("deletion requires --force flag for safety")
 		}
+		cascade, _ := cmd.Flags().GetBool("cascade")
 
-		fmt.Println(tenant.ID)
+		conn, err := getDB()
+		if err != nil {
+			return fmt.Errorf("error connecting to database: %w", err)
+		}
+		defer conn.Close()
+
+		tenant, contents, err := db.DeleteTenant(conn, name, cascade)
+		if errors.Is(err, db.ErrNotFound) {
+			return fmt.Errorf("tenant '%s' not found", name)
+		}
+		if err != nil {
+			if !contents.Empty() && !cascade {
+				return fmt.Errorf("%w; use --cascade to delete them too", err)
+			}
+			return fmt.Errorf("failed to delete tenant: %w", err)
+		}
+
+		return renderOne(cmd, newTenantView(tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Tenant '%s' deleted\n", tenant.Name)
+			if !contents.Empty() {
+				fmt.Fprintf(w, "\nAlso deleted %d stream(s), disabled %d user(s) and revoked %d client(s).\n",
+					contents.Streams, contents.Users, contents.Clients)
+			}
+		})
 	},
 }
 
 func init() {
+	tenantDeleteCmd.Flags().Bool("force", false, "Force deletion (required for safety)")
+	tenantDeleteCmd.Flags().Bool("cascade", false, "Also delete the tenant's streams, users and clients")
+
 	tenantCmd.AddCommand(tenantCreateCmd)
 	tenantCmd.AddCommand(tenantGetCmd)
+	tenantCmd.AddCommand(tenantListCmd)
+	tenantCmd.AddCommand(tenantRenameCmd)
+	tenantCmd.AddCommand(tenantDeleteCmd)
 }
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-tools/pkg/db"
+	"github.com/frkr-io/frkr-tools/pkg/traffic"
+	"github.com/spf13/cobra"
+)
+
+// resolveStreamTopic looks up a stream of the selected tenant for a command
+// that reads or writes the stream's topic, which needs --broker-url
+func resolveStreamTopic(streamIdentifier string) (*models.Stream, error) {
+	if brokerURL == "" {
+		return nil, fmt.Errorf("--broker-url is required to access the stream's topic")
+	}
+
+	conn, err := getDB()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	tenant, err := resolveTenant(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := db.GetStream(conn, tenant.ID, streamIdentifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stream: %w", err)
+	}
+	return stream, nil
+}
+
+// parseTimeFlag parses a point in time given either as an RFC 3339 timestamp
+// or as an age relative to now, e.g. 10m or 2d
+func parseTimeFlag(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	age, err := parseAge(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time '%s' (expected RFC 3339, e.g. 2024-01-02T15:04:05Z, or an age, e.g. 10m or 2d)", value)
+	}
+	return now.Add(-age), nil
+}
+
+// loadRules reads the --rules file, if one was given
+func loadRules(cmd *cobra.Command) (*traffic.RuleSet, error) {
+	path, _ := cmd.Flags().GetString("rules")
+	if path == "" {
+		return nil, nil
+	}
+	return traffic.LoadRules(path)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/traffic"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTimeFlag(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+
+	ts, err := parseTimeFlag("2024-01-02T14:00:00Z", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-time.Hour), ts)
+
+	ts, err = parseTimeFlag("10m", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-10*time.Minute), ts)
+
+	ts, err = parseTimeFlag("2d", now)
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-48*time.Hour), ts)
+
+	_, err = parseTimeFlag("yesterday", now)
+	require.Error(t, err)
+}
+
+func TestPrintRecord(t *testing.T) {
+	rec := traffic.Record{
+		Time: time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC),
+		Envelope: traffic.Envelope{Request: traffic.Request{
+			RequestID: "req-1",
+			Method:    "POST",
+			Path:      "/one",
+			Headers:   map[string]string{"X-B": "2", "X-A": "1"},
+		}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, printRecord(&buf, rec))
+	require.Equal(t, "2024-01-02T15:00:00Z  POST   /one  -  req-1\n    X-A: 1\n    X-B: 2\n", buf.String())
+
+	outputFormat = outputJSON
+	t.Cleanup(func() { outputFormat = outputTable })
+	buf.Reset()
+	require.NoError(t, printRecord(&buf, rec))
+	require.Contains(t, buf.String(), `"request":{"request_id":"req-1","method":"POST","path":"/one"`)
+	require.Equal(t, 1, bytes.Count(buf.Bytes(), []byte("\n")))
+}
+
+func TestParseSpeed(t *testing.T) {
+	for value, want := range map[string]float64{"max": 0, "realtime": 1, "10x": 10, "0.5x": 0.5, "2": 2} {
+		got, err := parseSpeed(value)
+		require.NoError(t, err, value)
+		require.Equal(t, want, got, value)
+	}
+	for _, bad := range []string{"fast", "0x", "-1x", ""} {
+		_, err := parseSpeed(bad)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestReplayWindow(t *testing.T) {
+	now := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		streamReplayCmd.Flags().Set("from", "")
+		streamReplayCmd.Flags().Set("to", "")
+	})
+
+	opts, err := replayWindow(streamReplayCmd, now)
+	require.NoError(t, err)
+	require.True(t, opts.FromBeginning)
+	require.False(t, opts.Follow)
+
+	require.NoError(t, streamReplayCmd.Flags().Set("from", "1h"))
+	require.NoError(t, streamReplayCmd.Flags().Set("to", "2024-01-02T14:30:00Z"))
+	opts, err = replayWindow(streamReplayCmd, now)
+	require.NoError(t, err)
+	require.False(t, opts.FromBeginning)
+	require.Equal(t, now.Add(-time.Hour), opts.Since)
+	require.Equal(t, now.Add(-30*time.Minute), opts.Until)
+
+	require.NoError(t, streamReplayCmd.Flags().Set("to", "2h"))
+	_, err = replayWindow(streamReplayCmd, now)
+	require.ErrorContains(t, err, "--to must be after --from")
+}
+
+func TestTrafficFormat(t *testing.T) {
+	t.Cleanup(func() { streamExportCmd.Flags().Set("format", "") })
+
+	for file, want := range map[string]string{"a.har": formatHAR, "A.HAR": formatHAR, "a.ndjson": formatNDJSON, "-": formatNDJSON} {
+		format, err := trafficFormat(streamExportCmd, file)
+		require.NoError(t, err)
+		require.Equal(t, want, format, file)
+	}
+
+	require.NoError(t, streamExportCmd.Flags().Set("format", "har"))
+	format, err := trafficFormat(streamExportCmd, "-")
+	require.NoError(t, err)
+	require.Equal(t, formatHAR, format)
+
+	require.NoError(t, streamExportCmd.Flags().Set("format", "csv"))
+	_, err = trafficFormat(streamExportCmd, "-")
+	require.Error(t, err)
+}
+
+func TestExportFile(t *testing.T) {
+	t.Run("commit replaces the target", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "traffic.ndjson")
+		require.NoError(t, os.WriteFile(path, []byte("previous export\n"), 0644))
+
+		f, err := createExportFile(path)
+		require.NoError(t, err)
+		_, err = f.WriteString("{}\n")
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "previous export\n", string(data), "target must not change before commit")
+
+		require.NoError(t, f.commit())
+		f.discard()
+
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "{}\n", string(data))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("discard keeps the target", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "traffic.ndjson")
+		require.NoError(t, os.WriteFile(path, []byte("previous export\n"), 0644))
+
+		f, err := createExportFile(path)
+		require.NoError(t, err)
+		_, err = f.WriteString("{\"truncated")
+		require.NoError(t, err)
+		f.discard()
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, "previous export\n", string(data))
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+	})
+
+	t.Run("directory target fails", func(t *testing.T) {
+		_, err := createExportFile(t.TempDir())
+		require.Error(t, err)
+	})
+}
+
+func TestImportMessages(t *testing.T) {
+	captured := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	records := []traffic.Record{
+		{Time: captured, Envelope: traffic.Envelope{Request: traffic.Request{RequestID: "req-1", Method: "GET", Path: "/api/a"}}},
+		{Envelope: traffic.Envelope{Request: traffic.Request{Method: "GET", Path: "/static/app.js"}}},
+	}
+	filters, err := traffic.ParseFilters([]string{"path=/api/*"})
+	require.NoError(t, err)
+
+	msgs, err := importMessages("dev-api", records, filters)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, []byte("req-1"), msgs[0].Key)
+	require.True(t, msgs[0].Time.IsZero(), "the broker stamps imported messages")
+
+	env, err := traffic.Decode(msgs[0].Value)
+	require.NoError(t, err)
+	require.Equal(t, "dev-api", env.StreamID)
+	require.Equal(t, captured.UnixNano(), env.Request.TimestampNs)
+
+	msgs, err = importMessages("dev-api", records, nil)
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Nil(t, msgs[1].Key)
+}
+
+func TestDiffReplayView(t *testing.T) {
+	view := diffReplayView{ByKind: make(map[string]int)}
+	rec := traffic.Record{Partition: 0, Offset: 5, Envelope: traffic.Envelope{Request: traffic.Request{Method: "GET", Path: "/a", Query: "x=1"}}}
+
+	view.add(rec, nil)
+	view.add(rec, []traffic.Difference{
+		{Kind: traffic.DiffBody, Path: "$.a"},
+		{Kind: traffic.DiffBody, Path: "$.b"},
+		{Kind: traffic.DiffStatus},
+	})
+
+	require.Equal(t, 2, view.Compared)
+	require.Equal(t, 1, view.Matched)
+	require.Equal(t, 1, view.Mismatched)
+	require.Equal(t, map[string]int{"body": 1, "status": 1}, view.ByKind)
+	require.Equal(t, "/a?x=1", view.Mismatches[0].URL)
+	require.Len(t, view.Mismatches[0].Differences, 3)
+}
+
+func TestDiffOptions(t *testing.T) {
+	t.Cleanup(func() {
+		streamDiffReplayCmd.Flags().Set("no-headers", "false")
+		resetStringArray(streamDiffReplayCmd, "ignore-field")
+	})
+
+	opts, err := diffOptions(streamDiffReplayCmd)
+	require.NoError(t, err)
+	require.True(t, opts.CompareHeaders)
+
+	require.NoError(t, streamDiffReplayCmd.Flags().Set("no-headers", "true"))
+	require.NoError(t, streamDiffReplayCmd.Flags().Set("ignore-field", "$..id"))
+	opts, err = diffOptions(streamDiffReplayCmd)
+	require.NoError(t, err)
+	require.False(t, opts.CompareHeaders)
+	require.Len(t, opts.IgnoreFields, 1)
+
+	require.NoError(t, streamDiffReplayCmd.Flags().Set("ignore-field", "id"))
+	_, err = diffOptions(streamDiffReplayCmd)
+	require.Error(t, err)
+}
+
+// resetStringArray clears a repeatable flag, which Set only appends to
+func resetStringArray(cmd *cobra.Command, name string) {
+	flag := cmd.Flags().Lookup(name)
+	flag.Value.(interface{ Replace([]string) error }).Replace(nil)
+	flag.Changed = false
+}
+
+func TestParseRate(t *testing.T) {
+	for value, want := range map[string]float64{"max": 0, "500": 500, "500/s": 500, "1200/m": 20, "0.5/s": 0.5} {
+		got, err := parseRate(value)
+		require.NoError(t, err, value)
+		require.Equal(t, want, got, value)
+	}
+	for _, value := range []string{"", "0/s", "-1", "10/h", "fast"} {
+		_, err := parseRate(value)
+		require.Error(t, err, value)
+	}
+}
+
+func TestSyntheticRequest(t *testing.T) {
+	req := syntheticRequest(7, 256)
+	require.Equal(t, "POST", req.Method)
+	require.Len(t, req.Body, 256)
+	require.True(t, json.Valid([]byte(req.Body)))
+
+	require.Equal(t, `{"seq":7,"padding":""}`, syntheticRequest(7, 0).Body)
+}
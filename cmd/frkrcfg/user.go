@@ -1,10 +1,13 @@
 package main
 
 import (
-	"encoding/json"
+	"cmp"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 
+	"github.com/frkr-io/frkr-common/models"
 	"github.com/frkr-io/frkr-common/util"
 	"github.com/frkr-io/frkr-tools/pkg/db"
 	"github.com/spf13/cobra"
@@ -13,7 +16,7 @@ import (
 var userCmd = &cobra.Command{
 	Use:   "user",
 	Short: "Manage users",
-	Long:  `Create, list, and manage users.`,
+	Long:  `Create, list, disable, and manage users.`,
 }
 
 var userCreateCmd = &cobra.Command{
@@ -35,57 +38,307 @@ var userCreateCmd = &cobra.Command{
 		}
 		defer conn.Close()
 
-		// Get tenant
-		tenant, err := db.CreateOrGetTenant(conn, tenantName)
+		tenant, err := resolveTenant(conn)
 		if err != nil {
-			return fmt.Errorf("failed to get tenant: %w", err)
+			return err
 		}
 
-		// Get password from flag or generate one
-		password, _ := cmd.Flags().GetString("password")
-		if password == "" {
-			// Generate password using shared utility
-			var err error
-			password, err = util.GeneratePassword()
-			if err != nil {
-				return fmt.Errorf("failed to generate password: %w", err)
-			}
+		// Get password from the flags or generate one
+		password, generated, err := resolveSecret(cmd, "password")
+		if err != nil {
+			return err
 		}
+		emitted, err := emitCredential(cmd, credential{Kind: "user", ID: username, Secret: password, Tenant: tenant.Name})
+		if err != nil {
+			return err
+		}
+		secretOut, staged, err := secretOutput(cmd, cmp.Or(emitted, password))
+		if err != nil {
+			return err
+		}
+		defer staged.discard()
 
 		// Create user in database
 		user, err := db.CreateUser(conn, tenant.ID, username, password)
 		if err != nil {
 			// If users table doesn't exist, provide helpful error message
-			if strings.Contains(err.Error(), "does not exist") {
+			if errors.Is(err, db.ErrSchemaMissing) {
 				return fmt.Errorf("users table does not exist - please run migrations first: %w", err)
 			}
 			return fmt.Errorf("failed to create user: %w", err)
 		}
+		if err := staged.commit(); err != nil {
+			return err
+		}
+		if emitted != "" && secretOut == "" {
+			fmt.Fprint(cmd.OutOrStdout(), emitted)
+			return nil
+		}
+
+		view := newUserView(user, tenant)
+		if secretOut == "" {
+			view.Password = password
+			warnSecretHidden(cmd, generated)
+		}
+		return renderOne(cmd, view, func(w io.Writer) {
+			fmt.Fprintf(w, "✅ User created successfully!\n\n")
+			fmt.Fprintf(w, "User ID:       %s\n", user.ID)
+			fmt.Fprintf(w, "Username:      %s\n", username)
+			fmt.Fprintf(w, "Password:      %s\n", shownSecret(password, secretOut))
+			fmt.Fprintf(w, "Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
+			if secretOut == "" {
+				fmt.Fprintf(w, "\n⚠️  Save this password - it won't be shown again!\n")
+			}
+		})
+	},
+}
+
+var userListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all users",
+	Long:  `List all users for a tenant. Disabled users are only shown with --all.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		all, _ := cmd.Flags().GetBool("all")
+		var users []*models.TenantUser
+		if all {
+			users, err = db.ListAllUsers(conn, tenant.ID)
+		} else {
+			users, err = db.ListUsers(conn, tenant.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+
+		views := make([]userView, 0, len(users))
+		for _, user := range users {
+			views = append(views, newUserView(user, tenant))
+		}
 
+		return renderList(cmd, views, func(w io.Writer) {
+			if len(users) == 0 {
+				fmt.Fprintf(w, "No users found for tenant '%s'\n", tenantName)
+				return
+			}
 
-		if outputFormat == "json" {
-			out := map[string]string{
-				"id":          user.ID,
-				"username":    username,
-				"password":    password,
-				"tenant_id":   tenant.ID,
-				"tenant_name": tenant.Name,
+			fmt.Fprintf(w, "Users for tenant '%s':\n\n", tenantName)
+			fmt.Fprintf(w, "%-36s %-30s %-10s %-20s\n", "ID", "Username", "Status", "Created")
+			fmt.Fprintf(w, "%s\n", strings.Repeat("-", 96))
+			for _, user := range users {
+				fmt.Fprintf(w, "%-36s %-30s %-10s %-20s\n",
+					user.ID,
+					user.Username,
+					userStatus(user),
+					formatNullTime(user.CreatedAt.Time, user.CreatedAt.Valid))
 			}
-			return json.NewEncoder(cmd.OutOrStdout()).Encode(out)
+		})
+	},
+}
+
+var userGetCmd = &cobra.Command{
+	Use:   "get [username-or-id]",
+	Short: "Get user details",
+	Long:  `Get details for a specific user. Password hashes are never displayed.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		user, err := db.GetUser(conn, tenant.ID, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		return renderOne(cmd, newUserView(user, tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "User Details:\n\n")
+			fmt.Fprintf(w, "ID:            %s\n", user.ID)
+			fmt.Fprintf(w, "Username:      %s\n", user.Username)
+			fmt.Fprintf(w, "Status:        %s\n", userStatus(user))
+			fmt.Fprintf(w, "Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
+			fmt.Fprintf(w, "Created:       %s\n", formatNullTime(user.CreatedAt.Time, user.CreatedAt.Valid))
+			fmt.Fprintf(w, "Updated:       %s\n", formatNullTime(user.UpdatedAt.Time, user.UpdatedAt.Valid))
+		})
+	},
+}
+
+var userDeleteCmd = &cobra.Command{
+	Use:   "delete [username-or-id]",
+	Short: "Delete a user",
+	Long: `Permanently delete a user, whether active or disabled. This frees the username for reuse.
+Use 'user disable' instead if the user may need to be restored later.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, _ := cmd.Flags().GetBool("force")
+		if !force {
+			return fmt.Errorf("deletion requires --force flag for safety")
+		}
+
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "✅ User created successfully!\n\n")
-		fmt.Fprintf(cmd.OutOrStdout(), "User ID:       %s\n", user.ID)
-		fmt.Fprintf(cmd.OutOrStdout(), "Username:      %s\n", username)
-		fmt.Fprintf(cmd.OutOrStdout(), "Password:      %s\n", password)
-		fmt.Fprintf(cmd.OutOrStdout(), "Tenant:        %s (%s)\n\n", tenant.Name, tenant.ID)
-		fmt.Fprintf(cmd.OutOrStdout(), "⚠️  Save this password - it won't be shown again!\n")
+		user, err := db.DeleteUser(conn, tenant.ID, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+
+		return renderOne(cmd, newUserView(user, tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ User deleted successfully!\n\n")
+			fmt.Fprintf(w, "Deleted user:\n")
+			fmt.Fprintf(w, "  Username: %s\n", user.Username)
+			fmt.Fprintf(w, "  ID:       %s\n", user.ID)
+		})
+	},
+}
+
+var userDisableCmd = &cobra.Command{
+	Use:   "disable [username-or-id]",
+	Short: "Disable a user",
+	Long:  `Disable a user so it can no longer authenticate against the gateways. The user can be re-enabled later.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		user, err := db.DisableUser(conn, tenant.ID, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to disable user: %w", err)
+		}
+
+		view := newUserView(user, tenant)
+		view.Status = "disabled"
+		return renderOne(cmd, view, func(w io.Writer) {
+			fmt.Fprintf(w, "✅ User '%s' disabled\n", user.Username)
+		})
+	},
+}
+
+var userEnableCmd = &cobra.Command{
+	Use:   "enable [username-or-id]",
+	Short: "Re-enable a disabled user",
+	Long:  `Re-enable a previously disabled user. The existing password keeps working.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		user, err := db.EnableUser(conn, tenant.ID, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to enable user: %w", err)
+		}
+
+		return renderOne(cmd, newUserView(user, tenant), func(w io.Writer) {
+			fmt.Fprintf(w, "✅ User '%s' enabled\n", user.Username)
+		})
+	},
+}
+
+var userResetPasswordCmd = &cobra.Command{
+	Use:   "reset-password [username-or-id]",
+	Short: "Reset a user's password",
+	Long:  `Replace a user's password with an auto-generated or user-provided one. The old password stops working immediately.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		conn, err := getDB()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		tenant, err := resolveTenant(conn)
+		if err != nil {
+			return err
+		}
+
+		password, generated, err := resolveSecret(cmd, "password")
+		if err != nil {
+			return err
+		}
+		secretOut, staged, err := secretOutput(cmd, password)
+		if err != nil {
+			return err
+		}
+		defer staged.discard()
 
-		return nil
+		user, err := db.ResetPassword(conn, tenant.ID, args[0], password)
+		if err != nil {
+			return fmt.Errorf("failed to reset password: %w", err)
+		}
+		if err := staged.commit(); err != nil {
+			return err
+		}
+
+		view := newUserView(user, tenant)
+		if secretOut == "" {
+			view.Password = password
+			warnSecretHidden(cmd, generated)
+		}
+		return renderOne(cmd, view, func(w io.Writer) {
+			fmt.Fprintf(w, "✅ Password reset successfully!\n\n")
+			fmt.Fprintf(w, "User ID:       %s\n", user.ID)
+			fmt.Fprintf(w, "Username:      %s\n", user.Username)
+			fmt.Fprintf(w, "Password:      %s\n", shownSecret(password, secretOut))
+			fmt.Fprintf(w, "Tenant:        %s (%s)\n", tenant.Name, tenant.ID)
+			if secretOut == "" {
+				fmt.Fprintf(w, "\n⚠️  Save this password - it won't be shown again!\n")
+			}
+		})
 	},
 }
 
 func init() {
-	userCreateCmd.Flags().String("password", "", "User password (if not provided, a random password will be generated)")
+	addSecretFlags(userCreateCmd, "password", "User password (if not provided, a random password will be generated)")
+	addEmitFlags(userCreateCmd)
+	userListCmd.Flags().Bool("all", false, "Include disabled users")
+	userDeleteCmd.Flags().Bool("force", false, "Force deletion (required for safety)")
+	addSecretFlags(userResetPasswordCmd, "password", "New password (if not provided, a random password will be generated)")
+
 	userCmd.AddCommand(userCreateCmd)
+	userCmd.AddCommand(userListCmd)
+	userCmd.AddCommand(userGetCmd)
+	userCmd.AddCommand(userDeleteCmd)
+	userCmd.AddCommand(userDisableCmd)
+	userCmd.AddCommand(userEnableCmd)
+	userCmd.AddCommand(userResetPasswordCmd)
 }
@@ -3,14 +3,17 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/url"
 	"os/exec"
 	"strings"
 	"time"
 
-	dbcommon "github.com/frkr-io/frkr-common/db"
 	"github.com/frkr-io/frkr-common/migrate"
 	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-tools/pkg/db"
+	"github.com/frkr-io/frkr-tools/pkg/schema"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/lib/pq"
 )
@@ -50,13 +53,7 @@ func (dm *DatabaseManager) RunMigrations() error {
 	_, _ = testDB.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS public")
 
 	// Detect database type and run migrations
-	migrateURL := dm.dbURL
-	var hasAdvisoryLock bool
-	err = testDB.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'pg_advisory_lock')").Scan(&hasAdvisoryLock)
-	if err == nil && !hasAdvisoryLock && strings.HasPrefix(migrateURL, "postgres://") {
-		// CockroachDB doesn't support pg_advisory_lock - use cockroachdb:// driver
-		migrateURL = strings.Replace(migrateURL, "postgres://", "cockroachdb://", 1)
-	}
+	migrateURL := schema.MigrateURL(ctx, testDB, dm.dbURL)
 
 	if err := migrate.RunMigrations(migrateURL); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
@@ -110,18 +107,11 @@ func (dm *DatabaseManager) ensureDatabaseExists(testDB *sql.DB, ctx context.Cont
 
 // maskPassword masks the password in a database URL for logging
 func maskPassword(dbURL string) string {
-	if strings.Contains(dbURL, "@") {
-		parts := strings.Split(dbURL, "@")
-		if len(parts) == 2 {
-			if strings.Contains(parts[0], ":") {
-				userPass := strings.Split(parts[0], ":")
-				if len(userPass) >= 3 {
-					return strings.Join(userPass[:2], ":") + ":***@" + parts[1]
-				}
-			}
-		}
+	u, err := url.Parse(dbURL)
+	if err != nil {
+		return "(invalid database URL)"
 	}
-	return dbURL
+	return u.Redacted()
 }
 
 // RunMigrationsK8s runs migrations for Kubernetes setup
@@ -162,19 +152,21 @@ func (dm *DatabaseManager) CreateStream(streamName string) (*models.Stream, erro
 		return nil, fmt.Errorf("cannot connect to database: %w", err)
 	}
 
+	store := db.NewStore(dbConn)
+
 	// Create or get tenant
-	tenant, err := dbcommon.CreateOrGetTenant(dbConn, "default")
+	tenant, err := store.CreateOrGetTenant(ctx, "default")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get tenant: %w", err)
 	}
 
-	// Create stream using the common library
+	// Create stream using the store
 	// We use 7 days retention as default
-	stream, err := dbcommon.CreateStream(dbConn, tenant.ID, streamName, "Created by frkrup", 7)
+	stream, err := store.CreateStream(ctx, tenant.ID, streamName, "Created by frkrup", 7)
 	if err != nil {
 		// If it already exists, that's fine, we want to return the existing one
-		if strings.Contains(err.Error(), "already exists") {
-			return dbcommon.GetStream(dbConn, tenant.ID, streamName)
+		if errors.Is(err, db.ErrAlreadyExists) {
+			return store.GetStream(ctx, tenant.ID, streamName)
 		}
 		return nil, fmt.Errorf("failed to create stream: %w", err)
 	}
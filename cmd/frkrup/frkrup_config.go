@@ -2,10 +2,15 @@ package main
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 
+	"github.com/frkr-io/frkr-tools/pkg/broker"
 	"gopkg.in/yaml.v3"
 )
 
@@ -40,17 +45,31 @@ type FrkrupConfig struct {
 	IngressClassName   string `yaml:"ingress_class_name"`
 
 	// Database configuration
-	DBHost     string `yaml:"db_host"`
-	DBPort     string `yaml:"db_port"`
-	DBUser     string `yaml:"db_user"`
-	DBPassword string `yaml:"db_password"`
-	DBName     string `yaml:"db_name"`
+	DBURL         string `yaml:"db_url"` // Full connection URL; replaces all other db_* settings
+	DBHost        string `yaml:"db_host"`
+	DBPort        string `yaml:"db_port"`
+	DBUser        string `yaml:"db_user"`
+	DBPassword    string `yaml:"db_password"`
+	DBName        string `yaml:"db_name"`
+	DBSSLMode     string `yaml:"db_sslmode"`     // libpq sslmode; default "disable", or "verify-full" with db_sslrootcert
+	DBSSLRootCert string `yaml:"db_sslrootcert"` // PEM CA bundle to verify the server with
+	DBSSLCert     string `yaml:"db_sslcert"`     // PEM client certificate
+	DBSSLKey      string `yaml:"db_sslkey"`      // PEM client key
+	// DBPodSSLRootCert is the CA bundle's path inside the gateway pods
+	// (Kubernetes only), e.g. mounted from a Secret. Required for verify-ca
+	// and verify-full, since db_sslrootcert is a path on this machine.
+	DBPodSSLRootCert string `yaml:"db_pod_sslrootcert"`
 
 	// Broker configuration
-	BrokerHost     string `yaml:"broker_host"`
-	BrokerPort     string `yaml:"broker_port"`
-	BrokerUser     string `yaml:"broker_user"`
-	BrokerPassword string `yaml:"broker_password"`
+	BrokerHost          string `yaml:"broker_host"`
+	BrokerPort          string `yaml:"broker_port"`
+	BrokerUser          string `yaml:"broker_user"`
+	BrokerPassword      string `yaml:"broker_password"`
+	BrokerSASLMechanism string `yaml:"broker_sasl_mechanism"` // "PLAIN", "SCRAM-SHA-256" or "SCRAM-SHA-512"; required with broker_user
+	BrokerTLS           bool   `yaml:"broker_tls"`
+	BrokerCAFile        string `yaml:"broker_ca_file"`   // PEM CA bundle trusted instead of the system roots (implies broker_tls)
+	BrokerCertFile      string `yaml:"broker_cert_file"` // PEM client certificate for mutual TLS (implies broker_tls)
+	BrokerKeyFile       string `yaml:"broker_key_file"`
 
 	// Gateway configuration
 	IngestPort    int    `yaml:"ingest_port"`
@@ -102,8 +121,8 @@ func loadConfigFromFile(path string) (*FrkrupConfig, error) {
 func validateConfig(config *FrkrupConfig) error {
 	if !config.K8s {
 		// Local mode requires explicit host configuration
-		if config.DBHost == "" {
-			return fmt.Errorf("db_host is required in config file")
+		if config.DBHost == "" && config.DBURL == "" {
+			return fmt.Errorf("db_host or db_url is required in config file")
 		}
 		if config.BrokerHost == "" {
 			return fmt.Errorf("broker_host is required in config file")
@@ -124,6 +143,14 @@ func validateConfig(config *FrkrupConfig) error {
 		return fmt.Errorf("both ingest_ingress_host and streaming_ingress_host must be specified together")
 	}
 
+	if err := validateDBOptions(config); err != nil {
+		return err
+	}
+
+	if err := config.BrokerSecurity().Validate(); err != nil {
+		return fmt.Errorf("invalid broker security settings: %w", err)
+	}
+
 	return nil
 }
 
@@ -154,8 +181,9 @@ func applyDefaults(config *FrkrupConfig) {
 	}
 
 	// Note: DBHost and BrokerHost are REQUIRED - no defaults
-	// They must be explicitly set in config file or via prompts
-	if config.DBHost == "" && config.K8s {
+	// They must be explicitly set in config file or via prompts.
+	// A db_url points at an existing database, so none is provisioned for it.
+	if config.DBHost == "" && config.DBURL == "" && config.K8s {
 		config.DBHost = "frkr-db"
 	}
 	if config.BrokerHost == "" && config.K8s {
@@ -219,18 +247,139 @@ func applyDefaults(config *FrkrupConfig) {
 	}
 }
 
-// BuildDBURL constructs a PostgreSQL connection URL from the config
+// BuildDBURL constructs a PostgreSQL connection URL from the config.
+// db_url, when set, is returned as is. Otherwise the user and password are
+// escaped, so they may contain any character.
 func (c *FrkrupConfig) BuildDBURL() string {
+	if c.DBURL != "" {
+		return c.DBURL
+	}
+
+	u := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(c.DBHost, c.DBPort),
+		Path:   "/" + c.DBName,
+	}
 	if c.DBUser != "" {
 		if c.DBPassword != "" {
-			return fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable",
-				c.DBUser, c.DBPassword, c.DBHost, c.DBPort, c.DBName)
+			u.User = url.UserPassword(c.DBUser, c.DBPassword)
+		} else {
+			u.User = url.User(c.DBUser)
+		}
+	}
+
+	query := url.Values{}
+	query.Set("sslmode", c.dbSSLMode())
+	if c.DBSSLRootCert != "" {
+		query.Set("sslrootcert", c.DBSSLRootCert)
+	}
+	if c.DBSSLCert != "" {
+		query.Set("sslcert", c.DBSSLCert)
+		query.Set("sslkey", c.DBSSLKey)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// dbSSLModes are the sslmode values libpq and lib/pq accept
+var dbSSLModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// dbSSLMode returns the sslmode for the database connection. Without an
+// explicit db_sslmode, a CA certificate means the server should be verified.
+func (c *FrkrupConfig) dbSSLMode() string {
+	if c.DBSSLMode != "" {
+		return c.DBSSLMode
+	}
+	if c.DBSSLRootCert != "" {
+		return "verify-full"
+	}
+	return "disable"
+}
+
+// dbSSLSettings returns the sslmode, sslrootcert, sslcert and sslkey of the
+// database connection, from the db_ssl* settings or the query of db_url.
+// The certificate paths are paths on this machine.
+func (c *FrkrupConfig) dbSSLSettings() (mode, rootCert, cert, key string) {
+	if c.DBURL != "" {
+		u, err := url.Parse(c.DBURL)
+		if err != nil {
+			return "", "", "", ""
+		}
+		q := u.Query()
+		return q.Get("sslmode"), q.Get("sslrootcert"), q.Get("sslcert"), q.Get("sslkey")
+	}
+	return c.dbSSLMode(), c.DBSSLRootCert, c.DBSSLCert, c.DBSSLKey
+}
+
+// dbCertFiles returns the certificate and key files the database connection
+// refers to that have no path in the gateway pods. The CA bundle is left out
+// when db_pod_sslrootcert gives its path there.
+func (c *FrkrupConfig) dbCertFiles() []string {
+	_, rootCert, cert, key := c.dbSSLSettings()
+	if c.DBPodSSLRootCert != "" {
+		rootCert = ""
+	}
+	var files []string
+	for _, f := range []string{rootCert, cert, key} {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// podDBURL returns db_url as the gateway pods should use it, with
+// sslrootcert pointing at db_pod_sslrootcert when that is set
+func (c *FrkrupConfig) podDBURL() string {
+	if c.DBPodSSLRootCert == "" {
+		return c.DBURL
+	}
+	u, err := url.Parse(c.DBURL)
+	if err != nil {
+		return c.DBURL
+	}
+	q := u.Query()
+	q.Set("sslrootcert", c.DBPodSSLRootCert)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// checkPodDBTLS rejects verify-ca and verify-full for Kubernetes deployments
+// unless db_pod_sslrootcert is set. Local CA files are not copied to the
+// cluster, so the gateways would only fail to verify the server at runtime.
+func checkPodDBTLS(c *FrkrupConfig) error {
+	mode, _, _, _ := c.dbSSLSettings()
+	if (mode == "verify-ca" || mode == "verify-full") && c.DBPodSSLRootCert == "" {
+		return fmt.Errorf("database sslmode %s needs db_pod_sslrootcert in Kubernetes: the CA file is not copied to the cluster, "+
+			"so mount it into the gateway pods (for example from a Secret) and set db_pod_sslrootcert to its path there", mode)
+	}
+	return nil
+}
+
+// validateDBOptions checks db_url and the db_ssl* settings
+func validateDBOptions(config *FrkrupConfig) error {
+	if config.DBURL != "" {
+		if config.DBSSLMode != "" || config.DBSSLRootCert != "" || config.DBSSLCert != "" || config.DBSSLKey != "" {
+			return fmt.Errorf("db_url cannot be combined with db_sslmode, db_sslrootcert, db_sslcert or db_sslkey (set them in the URL instead)")
+		}
+		u, err := url.Parse(config.DBURL)
+		if err != nil {
+			// url.Parse errors quote the URL, which may hold a password
+			return fmt.Errorf("db_url is not a valid URL")
 		}
-		return fmt.Sprintf("postgres://%s@%s:%s/%s?sslmode=disable",
-			c.DBUser, c.DBHost, c.DBPort, c.DBName)
+		if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+			return fmt.Errorf("db_url must be a postgres:// or postgresql:// URL")
+		}
+		return nil
+	}
+
+	if config.DBSSLMode != "" && !slices.Contains(dbSSLModes, config.DBSSLMode) {
+		return fmt.Errorf("db_sslmode must be one of %s", strings.Join(dbSSLModes, ", "))
+	}
+	if (config.DBSSLCert == "") != (config.DBSSLKey == "") {
+		return fmt.Errorf("db_sslcert and db_sslkey must be specified together")
 	}
-	return fmt.Sprintf("postgres://%s:%s/%s?sslmode=disable",
-		c.DBHost, c.DBPort, c.DBName)
+	return nil
 }
 
 // BuildBrokerURL constructs a broker connection URL from the config
@@ -238,6 +387,42 @@ func (c *FrkrupConfig) BuildBrokerURL() string {
 	return fmt.Sprintf("%s:%s", c.BrokerHost, c.BrokerPort)
 }
 
+// brokerWarnings describes broker settings that frkrup accepts but that do
+// not take effect everywhere. The gateways are configured through BROKER_URL
+// alone (frkr-common's gateway config reads nothing else), so SASL and TLS
+// only secure frkrup's own broker checks and topic setup.
+func brokerWarnings(config *FrkrupConfig) []string {
+	var warnings []string
+	if config.BrokerSASLMechanism == "" && (config.BrokerUser != "" || config.BrokerPassword != "") {
+		warnings = append(warnings, fmt.Sprintf("broker_user and broker_password are ignored without broker_sasl_mechanism (%s, %s or %s)",
+			broker.MechanismPlain, broker.MechanismSCRAMSHA256, broker.MechanismSCRAMSHA512))
+	}
+	sec := config.BrokerSecurity()
+	if sec.SASLMechanism != "" || sec.TLSEnabled() {
+		warnings = append(warnings, "broker SASL/TLS settings are used by frkrup's broker checks only; "+
+			"the gateways are given BROKER_URL alone and must be configured for the broker's authentication and TLS separately")
+	}
+	return warnings
+}
+
+// BrokerSecurity returns the SASL and TLS settings for broker connections.
+// Credentials without a mechanism are left out, as they were before
+// broker_sasl_mechanism existed (brokerWarnings reports them).
+func (c *FrkrupConfig) BrokerSecurity() broker.Security {
+	sec := broker.Security{
+		TLS:      c.BrokerTLS,
+		CAFile:   c.BrokerCAFile,
+		CertFile: c.BrokerCertFile,
+		KeyFile:  c.BrokerKeyFile,
+	}
+	if c.BrokerSASLMechanism != "" {
+		sec.SASLMechanism = c.BrokerSASLMechanism
+		sec.Username = c.BrokerUser
+		sec.Password = c.BrokerPassword
+	}
+	return sec
+}
+
 // ingressScheme returns "https" if TLS is configured, "http" otherwise.
 func (c *FrkrupConfig) ingressScheme() string {
 	if c.IngressTLSSecret != "" {
@@ -49,7 +49,7 @@ func (gm *GatewaysManager) StartGateway(ctx context.Context, gatewayType string,
 	// Debug: print what we're passing to the gateway
 	fmt.Printf("   [DEBUG] Starting %s gateway:\n", gatewayType)
 	fmt.Printf("   [DEBUG]   Dir: %s\n", repoPath)
-	fmt.Printf("   [DEBUG]   DB_URL: %s\n", dbURL)
+	fmt.Printf("   [DEBUG]   DB_URL: %s\n", maskPassword(dbURL))
 	fmt.Printf("   [DEBUG]   BROKER_URL: %s\n", brokerURL)
 
 	// Run from module root with package path (required for internal packages)
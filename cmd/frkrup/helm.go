@@ -180,6 +180,26 @@ func (km *KubernetesManager) generateValuesFile(path string) error {
 		values["infrastructure"].(map[string]interface{})["db"].(map[string]interface{})["host"] = "frkr-db"
 	}
 	
+	// TLS options for the gateways' database connection. A db_url replaces
+	// the individual settings, so it is passed through instead. Certificate
+	// paths name files on this machine, not in the pods, so only the CA's
+	// path in the pods (db_pod_sslrootcert) is passed.
+	if err := checkPodDBTLS(km.config); err != nil {
+		return err
+	}
+	dataPlaneDB := values["dataPlane"].(map[string]interface{})["db"].(map[string]interface{})
+	if km.config.DBURL != "" {
+		dataPlaneDB["url"] = km.config.podDBURL()
+	} else {
+		dataPlaneDB["sslmode"] = km.config.dbSSLMode()
+		if km.config.DBPodSSLRootCert != "" {
+			dataPlaneDB["sslrootcert"] = km.config.DBPodSSLRootCert
+		}
+	}
+	if files := km.config.dbCertFiles(); len(files) > 0 {
+		fmt.Printf("⚠️  Database certificate files are not copied to the cluster: %s\n", strings.Join(files, ", "))
+		fmt.Println("   Mount them into the gateway pods (for example from a Secret) and point the chart's database settings at them.")
+	}
 	
 	if provisionRedpanda {
 		values["infrastructure"].(map[string]interface{})["redpanda"] = map[string]interface{}{
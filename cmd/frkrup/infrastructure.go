@@ -12,8 +12,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/frkr-io/frkr-tools/pkg/broker"
 	_ "github.com/lib/pq"
-	"github.com/segmentio/kafka-go"
 )
 
 // InfrastructureManager handles infrastructure setup and verification
@@ -35,7 +35,7 @@ func (im *InfrastructureManager) EnsureRunning() error {
 	brokerURL := im.config.BuildBrokerURL()
 	
 	dbChecker := NewDatabaseChecker()
-	brokerChecker := NewBrokerChecker()
+	brokerChecker := NewBrokerChecker(im.config.BrokerSecurity())
 	
 	// Quick check if services are already ready
 	dbReady := dbChecker.Check(dbURL) == nil
@@ -338,28 +338,21 @@ func (dc *DatabaseChecker) Check(dbURL string) error {
 }
 
 // BrokerChecker handles broker connection verification
-type BrokerChecker struct{}
+type BrokerChecker struct {
+	security broker.Security
+}
 
-// NewBrokerChecker creates a new BrokerChecker
-func NewBrokerChecker() *BrokerChecker {
-	return &BrokerChecker{}
+// NewBrokerChecker creates a new BrokerChecker that connects with the given
+// SASL and TLS settings
+func NewBrokerChecker(security broker.Security) *BrokerChecker {
+	return &BrokerChecker{security: security}
 }
 
-// Check verifies that the broker is accessible
+// Check verifies that the broker is accessible and accepts the credentials
 func (bc *BrokerChecker) Check(brokerURL string) error {
-	// Try to connect to the broker
-	conn, err := kafka.Dial("tcp", brokerURL)
-	if err != nil {
-		return fmt.Errorf("failed to connect to broker at %s: %w", brokerURL, err)
-	}
-	defer conn.Close()
-
-	// Try to get broker metadata
-	_, err = conn.Brokers()
+	m, err := broker.NewSecureManager(brokerURL, bc.security)
 	if err != nil {
-		// If we can't get brokers, at least we connected, which is good enough
-		return nil
+		return err
 	}
-
-	return nil
+	return m.Ping()
 }
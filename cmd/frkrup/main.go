@@ -70,6 +70,21 @@ func main() {
 		config.SkipPortForward = true
 	}
 
+	// Fail before setting anything up rather than deploy gateways that
+	// cannot verify the database server
+	if config.K8s && !pushMode {
+		if err := checkPodDBTLS(config); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !pushMode {
+		for _, warning := range brokerWarnings(config) {
+			fmt.Printf("⚠️  Warning: %s\n", warning)
+		}
+	}
+
 	// Ensure cleanup on exit (for local mode)
 	if !config.K8s {
 		cleanupMgr := NewCleanupManager(config)
@@ -137,7 +152,7 @@ func setupLocal(config *FrkrupConfig) error {
 	fmt.Printf("   [DEBUG] Config: DBHost=%s, DBPort=%s, BrokerHost=%s, BrokerPort=%s\n", 
 		config.DBHost, config.DBPort, config.BrokerHost, config.BrokerPort)
 	fmt.Printf("   [DEBUG] Migrations: %s\n", config.MigrationsPath)
-	fmt.Printf("   [DEBUG] Built URLs: DB=%s, Broker=%s\n", dbURL, brokerURL)
+	fmt.Printf("   [DEBUG] Built URLs: DB=%s, Broker=%s\n", maskPassword(dbURL), brokerURL)
 
 	// Check database connection
 	fmt.Println("\n🔍 Checking database connection...")
@@ -154,7 +169,7 @@ func setupLocal(config *FrkrupConfig) error {
 
 	// Check broker connection
 	fmt.Println("\n🔍 Checking broker connection...")
-	brokerChecker := NewBrokerChecker()
+	brokerChecker := NewBrokerChecker(config.BrokerSecurity())
 	if err := brokerChecker.Check(brokerURL); err != nil {
 		// Cleanup Docker if we started it
 		if config.StartedDocker {
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/frkr-io/frkr-tools/pkg/broker"
 	"golang.org/x/term"
 )
 
@@ -135,7 +136,8 @@ func promptConfig() (*FrkrupConfig, error) {
 	brokerURL := "localhost:19092"
 	
 	dbChecker := NewDatabaseChecker()
-	brokerChecker := NewBrokerChecker()
+	// The default services are the plaintext local Docker Compose setup
+	brokerChecker := NewBrokerChecker(broker.Security{})
 	
 	// Quick check if services are ready (with short timeout to avoid blocking)
 	dbReady := dbChecker.Check(dbURL) == nil
@@ -253,6 +255,27 @@ func promptCustomInfrastructure(config *FrkrupConfig, scanner *bufio.Scanner) *F
 	// Database name is hard-coded to "frkr" to match gateways and other components
 	config.DBName = "frkr"
 
+	fmt.Print("Database SSL mode (disable, require, verify-ca, verify-full) [disable]: ")
+	scanner.Scan()
+	config.DBSSLMode = strings.ToLower(strings.TrimSpace(scanner.Text()))
+	if config.DBSSLMode == "" {
+		config.DBSSLMode = "disable"
+	}
+	if config.DBSSLMode != "disable" {
+		fmt.Print("Database CA certificate file (optional): ")
+		scanner.Scan()
+		config.DBSSLRootCert = strings.TrimSpace(scanner.Text())
+
+		fmt.Print("Database client certificate file (optional): ")
+		scanner.Scan()
+		config.DBSSLCert = strings.TrimSpace(scanner.Text())
+		if config.DBSSLCert != "" {
+			fmt.Print("Database client key file: ")
+			scanner.Scan()
+			config.DBSSLKey = strings.TrimSpace(scanner.Text())
+		}
+	}
+
 	// Broker configuration
 	fmt.Print("Broker host [localhost]: ")
 	scanner.Scan()
@@ -275,6 +298,35 @@ func promptCustomInfrastructure(config *FrkrupConfig, scanner *bufio.Scanner) *F
 	fmt.Print("Broker password (optional): ")
 	scanner.Scan()
 	config.BrokerPassword = strings.TrimSpace(scanner.Text())
+
+	if config.BrokerUser != "" {
+		fmt.Printf("Broker SASL mechanism (%s, %s, %s) [%s]: ",
+			broker.MechanismPlain, broker.MechanismSCRAMSHA256, broker.MechanismSCRAMSHA512, broker.MechanismSCRAMSHA256)
+		scanner.Scan()
+		config.BrokerSASLMechanism = strings.ToUpper(strings.TrimSpace(scanner.Text()))
+		if config.BrokerSASLMechanism == "" {
+			config.BrokerSASLMechanism = broker.MechanismSCRAMSHA256
+		}
+	}
+
+	fmt.Print("Connect to the broker over TLS? (yes/no) [no]: ")
+	scanner.Scan()
+	answer := strings.ToLower(strings.TrimSpace(scanner.Text()))
+	config.BrokerTLS = answer == "yes" || answer == "y"
+	if config.BrokerTLS {
+		fmt.Print("Broker CA file (optional, default: system roots): ")
+		scanner.Scan()
+		config.BrokerCAFile = strings.TrimSpace(scanner.Text())
+
+		fmt.Print("Broker client certificate file (optional): ")
+		scanner.Scan()
+		config.BrokerCertFile = strings.TrimSpace(scanner.Text())
+		if config.BrokerCertFile != "" {
+			fmt.Print("Broker client key file: ")
+			scanner.Scan()
+			config.BrokerKeyFile = strings.TrimSpace(scanner.Text())
+		}
+	}
 	
 	return config
 }
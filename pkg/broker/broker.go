@@ -0,0 +1,246 @@
+// Package broker manages the Kafka-compatible topics that back frkr streams.
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// requestTimeout bounds every admin request sent to the broker
+const requestTimeout = 10 * time.Second
+
+// Manager handles broker operations
+type Manager struct {
+	brokerURL string
+	// dialer opens the single connections used by Ping, CreateTopic and readers
+	dialer *kafka.Dialer
+	// transport carries the requests of admin clients and writers
+	transport kafka.RoundTripper
+}
+
+// NewManager creates a new Manager for the broker at brokerURL (host:port),
+// connecting in plaintext without authentication
+func NewManager(brokerURL string) *Manager {
+	return &Manager{
+		brokerURL: brokerURL,
+		dialer:    &kafka.Dialer{Timeout: requestTimeout, DualStack: true},
+		transport: kafka.DefaultTransport,
+	}
+}
+
+// NewSecureManager creates a new Manager for the broker at brokerURL that
+// authenticates and encrypts its connections as configured by sec.
+// Certificate files are read once, here.
+func NewSecureManager(brokerURL string, sec Security) (*Manager, error) {
+	if err := sec.Validate(); err != nil {
+		return nil, err
+	}
+	mechanism, err := sec.mechanism()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := sec.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	m := NewManager(brokerURL)
+	if mechanism == nil && tlsConfig == nil {
+		return m, nil
+	}
+	m.dialer.SASLMechanism = mechanism
+	m.dialer.TLS = tlsConfig
+	m.transport = &kafka.Transport{
+		Dial: (&net.Dialer{Timeout: requestTimeout}).DialContext,
+		SASL: mechanism,
+		TLS:  tlsConfig,
+	}
+	return m, nil
+}
+
+// client returns an admin client for the broker
+func (m *Manager) client() *kafka.Client {
+	return &kafka.Client{
+		Addr:      kafka.TCP(m.brokerURL),
+		Timeout:   requestTimeout,
+		Transport: m.transport,
+	}
+}
+
+// TopicConfig describes the topic backing a stream
+type TopicConfig struct {
+	Partitions        int
+	ReplicationFactor int
+	// RetentionDays sets retention.ms; 0 keeps the broker's default
+	RetentionDays int
+}
+
+// DefaultTopicConfig is a single-partition, unreplicated topic, which is what
+// the local Docker Compose and kind setups can host
+var DefaultTopicConfig = TopicConfig{Partitions: 1, ReplicationFactor: 1}
+
+// Ping verifies that the broker is reachable
+func (m *Manager) Ping() error {
+	conn, err := m.dialer.Dial("tcp", m.brokerURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to broker at %s: %w", m.brokerURL, err)
+	}
+	defer conn.Close()
+
+	// Try to get broker metadata
+	_, err = conn.Brokers()
+	if err != nil {
+		// If we can't get brokers, at least we connected, which is good enough
+		return nil
+	}
+
+	return nil
+}
+
+// CreateTopic creates a topic for a stream (Kafka Protocol compliant).
+// A topic that already exists is not an error, so callers can safely retry.
+// Security: the topic name must come from the database (not user input).
+func (m *Manager) CreateTopic(topicName string, cfg TopicConfig) error {
+	if cfg.Partitions <= 0 {
+		return fmt.Errorf("partitions must be at least 1")
+	}
+	if cfg.ReplicationFactor <= 0 {
+		return fmt.Errorf("replication factor must be at least 1")
+	}
+
+	topicConfig := kafka.TopicConfig{
+		Topic:             topicName,
+		NumPartitions:     cfg.Partitions,
+		ReplicationFactor: cfg.ReplicationFactor,
+	}
+	if cfg.RetentionDays > 0 {
+		topicConfig.ConfigEntries = []kafka.ConfigEntry{
+			{ConfigName: "retention.ms", ConfigValue: strconv.FormatInt(RetentionMs(cfg.RetentionDays), 10)},
+		}
+	}
+
+	// 1. Try to use the bootstrap broker connection directly first.
+	// In single-node environments like ours, the bootstrap broker is usually the controller.
+	conn, err := m.dialer.Dial("tcp", m.brokerURL)
+	if err != nil {
+		return fmt.Errorf("failed to connect to broker: %w", err)
+	}
+	defer conn.Close()
+
+	err = conn.CreateTopics(topicConfig)
+	if err == nil || isTopicExists(err) {
+		return nil
+	}
+
+	// 2. If it failed with NotController, we need to find the controller.
+	controller, err := conn.Controller()
+	if err != nil {
+		return fmt.Errorf("failed to get controller: %w", err)
+	}
+
+	controllerAddr := fmt.Sprintf("%s:%d", controller.Host, controller.Port)
+
+	// PORT-FORWARD HANDLING:
+	// If we are connecting via localhost (port-forward), the broker might advertise its
+	// internal Kubernetes hostname (e.g., 'frkr-redpanda') which we can't resolve on the host.
+	isLocal := strings.HasPrefix(m.brokerURL, "localhost:") || strings.HasPrefix(m.brokerURL, "127.0.0.1:")
+	isControllerInternal := controller.Host != "localhost" && controller.Host != "127.0.0.1"
+
+	if isLocal && isControllerInternal {
+		// Fallback: assume the controller is reachable via the same port-forwarded address
+		controllerAddr = m.brokerURL
+	}
+
+	controllerConn, err := m.dialer.Dial("tcp", controllerAddr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to controller: %w", err)
+	}
+	defer controllerConn.Close()
+
+	err = controllerConn.CreateTopics(topicConfig)
+	if err != nil && !isTopicExists(err) {
+		return fmt.Errorf("failed to create topic: %w", err)
+	}
+
+	return nil
+}
+
+func isTopicExists(err error) bool {
+	return errors.Is(err, kafka.TopicAlreadyExists) || strings.Contains(err.Error(), "already exists")
+}
+
+// RetentionMs converts a stream's retention in days to a topic's retention.ms
+func RetentionMs(retentionDays int) int64 {
+	return int64(retentionDays) * int64(24*time.Hour/time.Millisecond)
+}
+
+// SetRetention sets retention.ms on a topic to match retentionDays.
+// Only retention.ms is changed; the topic's other configs are left alone.
+func (m *Manager) SetRetention(topicName string, retentionDays int) error {
+	resp, err := m.client().IncrementalAlterConfigs(context.Background(), &kafka.IncrementalAlterConfigsRequest{
+		Resources: []kafka.IncrementalAlterConfigsRequestResource{
+			{
+				ResourceType: kafka.ResourceTypeTopic,
+				ResourceName: topicName,
+				Configs: []kafka.IncrementalAlterConfigsRequestConfig{
+					{
+						Name:            "retention.ms",
+						Value:           strconv.FormatInt(RetentionMs(retentionDays), 10),
+						ConfigOperation: kafka.ConfigOperationSet,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update retention for topic '%s': %w", topicName, err)
+	}
+	for _, res := range resp.Resources {
+		if res.Error != nil {
+			return fmt.Errorf("failed to update retention for topic '%s': %w", topicName, res.Error)
+		}
+	}
+
+	return nil
+}
+
+// ListTopics lists the names of all non-internal topics on the broker, sorted
+func (m *Manager) ListTopics() ([]string, error) {
+	resp, err := m.client().Metadata(context.Background(), &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list topics: %w", err)
+	}
+
+	var topics []string
+	for _, t := range resp.Topics {
+		if t.Internal || t.Error != nil {
+			continue
+		}
+		topics = append(topics, t.Name)
+	}
+	sort.Strings(topics)
+	return topics, nil
+}
+
+// DeleteTopic deletes a topic and all of its messages.
+// A topic that does not exist is not an error.
+func (m *Manager) DeleteTopic(topicName string) error {
+	resp, err := m.client().DeleteTopics(context.Background(), &kafka.DeleteTopicsRequest{
+		Topics: []string{topicName},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete topic '%s': %w", topicName, err)
+	}
+	if err := resp.Errors[topicName]; err != nil && !errors.Is(err, kafka.UnknownTopicOrPartition) {
+		return fmt.Errorf("failed to delete topic '%s': %w", topicName, err)
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package broker
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/protocol"
+	"github.com/segmentio/kafka-go/protocol/listoffsets"
+	"github.com/segmentio/kafka-go/protocol/metadata"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionMs(t *testing.T) {
+	require.Equal(t, int64(86400000), RetentionMs(1))
+	require.Equal(t, int64(604800000), RetentionMs(7))
+	require.Equal(t, int64(31536000000), RetentionMs(365))
+}
+
+func TestCreateTopicValidation(t *testing.T) {
+	// Validation happens before any connection attempt, so no broker is needed
+	m := NewManager("localhost:0")
+
+	err := m.CreateTopic("stream-test", TopicConfig{Partitions: 0, ReplicationFactor: 1})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "partitions")
+
+	err = m.CreateTopic("stream-test", TopicConfig{Partitions: 1, ReplicationFactor: 0})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "replication factor")
+}
+
+func TestSecurityValidate(t *testing.T) {
+	require.NoError(t, Security{}.Validate())
+	require.NoError(t, Security{SASLMechanism: "scram-sha-512", Username: "frkr", Password: "pw"}.Validate())
+	require.NoError(t, Security{TLS: true, CertFile: "client.pem", KeyFile: "client-key.pem"}.Validate())
+
+	err := Security{Username: "frkr", Password: "pw"}.Validate()
+	require.ErrorContains(t, err, "need a SASL mechanism")
+
+	err = Security{SASLMechanism: MechanismPlain}.Validate()
+	require.ErrorContains(t, err, "needs a username")
+
+	err = Security{SASLMechanism: "GSSAPI", Username: "frkr"}.Validate()
+	require.ErrorContains(t, err, "unsupported SASL mechanism 'GSSAPI'")
+
+	err = Security{CertFile: "client.pem"}.Validate()
+	require.ErrorContains(t, err, "both a certificate file and a key file")
+}
+
+func TestSecurityMechanism(t *testing.T) {
+	for _, name := range []string{MechanismPlain, MechanismSCRAMSHA256, MechanismSCRAMSHA512} {
+		mechanism, err := Security{SASLMechanism: name, Username: "frkr", Password: "pw"}.mechanism()
+		require.NoError(t, err)
+		require.Equal(t, name, mechanism.Name())
+	}
+
+	mechanism, err := Security{}.mechanism()
+	require.NoError(t, err)
+	require.Nil(t, mechanism)
+}
+
+func TestSecurityTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir)
+
+	cfg, err := Security{}.tlsConfig()
+	require.NoError(t, err)
+	require.Nil(t, cfg, "TLS is off by default")
+
+	cfg, err = Security{TLS: true}.tlsConfig()
+	require.NoError(t, err)
+	require.Nil(t, cfg.RootCAs, "system roots are used without a CA file")
+
+	cfg, err = Security{CAFile: certFile, CertFile: certFile, KeyFile: keyFile}.tlsConfig()
+	require.NoError(t, err)
+	require.NotNil(t, cfg.RootCAs)
+	require.Len(t, cfg.Certificates, 1)
+
+	notPEM := filepath.Join(dir, "not-pem")
+	require.NoError(t, os.WriteFile(notPEM, []byte("hello"), 0600))
+	_, err = Security{CAFile: notPEM}.tlsConfig()
+	require.ErrorContains(t, err, "contains no PEM certificates")
+
+	_, err = Security{CAFile: filepath.Join(dir, "missing.pem")}.tlsConfig()
+	require.ErrorContains(t, err, "failed to read broker CA file")
+}
+
+func TestNewSecureManager(t *testing.T) {
+	m, err := NewSecureManager("localhost:0", Security{})
+	require.NoError(t, err)
+	require.Nil(t, m.dialer.TLS)
+	require.Equal(t, kafka.DefaultTransport, m.transport)
+
+	dir := t.TempDir()
+	certFile, _ := writeTestCert(t, dir)
+	m, err = NewSecureManager("localhost:0", Security{SASLMechanism: MechanismSCRAMSHA256, Username: "frkr", Password: "pw", CAFile: certFile})
+	require.NoError(t, err)
+	require.NotNil(t, m.dialer.TLS)
+	require.Equal(t, MechanismSCRAMSHA256, m.dialer.SASLMechanism.Name())
+	transport, ok := m.transport.(*kafka.Transport)
+	require.True(t, ok)
+	require.Same(t, m.dialer.TLS, transport.TLS)
+
+	_, err = NewSecureManager("localhost:0", Security{Username: "frkr"})
+	require.Error(t, err)
+}
+
+// writeTestCert writes a self-signed certificate and its key as PEM files
+func writeTestCert(t *testing.T, dir string) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "frkr-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	return certFile, keyFile
+}
+
+// fakeListOffsets describes a topic with the partitions of latest, and
+// answers ListOffsets requests the way Kafka and Redpanda do:
+// earliest and latest answers carry timestamp -1, time-based answers carry
+// the timestamp of the message found, or -1 and offset -1 without one
+type fakeListOffsets struct {
+	earliest, latest, byTime map[int32]int64
+}
+
+func (f fakeListOffsets) RoundTrip(ctx context.Context, addr net.Addr, msg protocol.Message) (protocol.Message, error) {
+	if req, ok := msg.(*metadata.Request); ok {
+		resp := &metadata.Response{}
+		for _, name := range req.TopicNames {
+			topic := metadata.ResponseTopic{Name: name}
+			for p := range f.latest {
+				topic.Partitions = append(topic.Partitions, metadata.ResponsePartition{PartitionIndex: p})
+			}
+			resp.Topics = append(resp.Topics, topic)
+		}
+		return resp, nil
+	}
+	req, ok := msg.(*listoffsets.Request)
+	if !ok {
+		return nil, fmt.Errorf("unexpected request %T", msg)
+	}
+	resp := &listoffsets.Response{}
+	for _, t := range req.Topics {
+		topic := listoffsets.ResponseTopic{Topic: t.Topic}
+		for _, p := range t.Partitions {
+			answer := listoffsets.ResponsePartition{Partition: p.Partition, Timestamp: -1, Offset: -1}
+			switch p.Timestamp {
+			case kafka.FirstOffset:
+				answer.Offset = f.earliest[p.Partition]
+			case kafka.LastOffset:
+				answer.Offset = f.latest[p.Partition]
+			default:
+				if offset, ok := f.byTime[p.Partition]; ok {
+					answer.Timestamp, answer.Offset = p.Timestamp, offset
+				}
+			}
+			topic.Partitions = append(topic.Partitions, answer)
+		}
+		resp.Topics = append(resp.Topics, topic)
+	}
+	return resp, nil
+}
+
+func TestOffsets(t *testing.T) {
+	m := NewManager("localhost:0")
+	m.transport = fakeListOffsets{
+		// Retention has removed every message of partition 1
+		earliest: map[int32]int64{0: 0, 1: 42},
+		latest:   map[int32]int64{0: 10, 1: 42},
+		byTime:   map[int32]int64{0: 7},
+	}
+	ctx := context.Background()
+	partitions := []int{0, 1}
+
+	earliest, err := m.offsets(ctx, "stream-test", partitions, kafka.FirstOffsetOf)
+	require.NoError(t, err)
+	require.Equal(t, map[int]int64{0: 0, 1: 42}, earliest)
+
+	latest, err := m.offsets(ctx, "stream-test", partitions, kafka.LastOffsetOf)
+	require.NoError(t, err)
+	require.Equal(t, map[int]int64{0: 10, 1: 42}, latest)
+
+	since, err := m.offsets(ctx, "stream-test", partitions, func(p int) kafka.OffsetRequest {
+		return kafka.TimeOffsetOf(p, time.Now().Add(-time.Hour))
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[int]int64{0: 7, 1: -1}, since)
+
+	starts, err := m.startOffsets(ctx, "stream-test", partitions, ReadOptions{FromBeginning: true})
+	require.NoError(t, err)
+	require.Equal(t, latest[1], starts[1], "an expired partition starts at its end, so it is not read")
+}
+
+func TestTopicOffsetsAfterRetention(t *testing.T) {
+	m := NewManager("localhost:0")
+	m.transport = fakeListOffsets{
+		earliest: map[int32]int64{0: 3, 1: 42},
+		latest:   map[int32]int64{0: 10, 1: 42},
+	}
+	ctx := context.Background()
+
+	offsets, err := m.TopicOffsets(ctx, "stream-test")
+	require.NoError(t, err)
+	require.Equal(t, []PartitionOffsets{
+		{Partition: 0, Earliest: 3, Latest: 10},
+		{Partition: 1, Earliest: 42, Latest: 42},
+	}, offsets)
+
+	// 'stream offsets reset --to-earliest' commits these
+	starts, err := m.StartOffsets(ctx, "stream-test", ReadOptions{FromBeginning: true})
+	require.NoError(t, err)
+	require.Equal(t, map[int]int64{0: 3, 1: 42}, starts)
+}
@@ -0,0 +1,204 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// PartitionOffsets are the bounds of one partition of a topic
+type PartitionOffsets struct {
+	Partition int
+	// Earliest is the offset of the oldest retained message
+	Earliest int64
+	// Latest is the high watermark: the offset the next message will get
+	Latest int64
+}
+
+// GroupOffsets are the offsets a consumer group has committed on a topic
+type GroupOffsets struct {
+	Group string
+	// State is the group's state as reported by the broker, e.g. Stable or Empty
+	State   string
+	Members int
+	// Committed maps each partition the group has committed an offset for
+	// to the offset of the next message the group will read
+	Committed map[int]int64
+}
+
+// TopicOffsets returns the earliest and latest offsets of every partition of
+// a topic, sorted by partition
+func (m *Manager) TopicOffsets(ctx context.Context, topic string) ([]PartitionOffsets, error) {
+	partitions, err := m.Partitions(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	earliest, err := m.offsets(ctx, topic, partitions, kafka.FirstOffsetOf)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := m.offsets(ctx, topic, partitions, kafka.LastOffsetOf)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]PartitionOffsets, 0, len(partitions))
+	for _, p := range partitions {
+		result = append(result, PartitionOffsets{Partition: p, Earliest: earliest[p], Latest: latest[p]})
+	}
+	return result, nil
+}
+
+// StartOffsets returns the offset of each partition of a topic that
+// ReadTopic would start reading at with opts
+func (m *Manager) StartOffsets(ctx context.Context, topic string, opts ReadOptions) (map[int]int64, error) {
+	partitions, err := m.Partitions(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+	return m.startOffsets(ctx, topic, partitions, opts)
+}
+
+// ConsumerGroupOffsets returns the committed offsets of consumer groups on a
+// topic, sorted by group. Without groups, every group on the broker that
+// has committed an offset on the topic is returned.
+func (m *Manager) ConsumerGroupOffsets(ctx context.Context, topic string, groups []string) ([]GroupOffsets, error) {
+	partitions, err := m.Partitions(ctx, topic)
+	if err != nil {
+		return nil, err
+	}
+
+	discover := len(groups) == 0
+	if discover {
+		resp, err := m.client().ListGroups(ctx, &kafka.ListGroupsRequest{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list consumer groups: %w", err)
+		}
+		if resp.Error != nil {
+			return nil, fmt.Errorf("failed to list consumer groups: %w", resp.Error)
+		}
+		for _, g := range resp.Groups {
+			groups = append(groups, g.GroupID)
+		}
+	}
+
+	var result []GroupOffsets
+	for _, group := range groups {
+		committed, err := m.committedOffsets(ctx, topic, group, partitions)
+		if err != nil {
+			return nil, err
+		}
+		if discover && len(committed) == 0 {
+			continue
+		}
+		result = append(result, GroupOffsets{Group: group, Committed: committed})
+	}
+	if len(result) == 0 {
+		return result, nil
+	}
+
+	ids := make([]string, 0, len(result))
+	for _, g := range result {
+		ids = append(ids, g.Group)
+	}
+	states, err := m.describeGroups(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range result {
+		if desc, ok := states[result[i].Group]; ok {
+			result[i].State = desc.GroupState
+			result[i].Members = len(desc.Members)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Group < result[j].Group })
+	return result, nil
+}
+
+// CommitOffsets sets a consumer group's committed offsets on a topic, so that
+// it resumes at the given offset of each partition. The group must have no
+// active members; a consumer that is running would overwrite the offsets.
+func (m *Manager) CommitOffsets(ctx context.Context, topic, group string, offsets map[int]int64) error {
+	states, err := m.describeGroups(ctx, []string{group})
+	if err != nil {
+		return err
+	}
+	if desc, ok := states[group]; ok && len(desc.Members) > 0 {
+		return fmt.Errorf("consumer group '%s' has %d active member(s); stop its consumers before resetting offsets", group, len(desc.Members))
+	}
+
+	commits := make([]kafka.OffsetCommit, 0, len(offsets))
+	for p, offset := range offsets {
+		commits = append(commits, kafka.OffsetCommit{Partition: p, Offset: offset})
+	}
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Partition < commits[j].Partition })
+
+	resp, err := m.client().OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      group,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: commits},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets for consumer group '%s': %w", group, err)
+	}
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			return fmt.Errorf("failed to commit offset of partition %d for consumer group '%s': %w", p.Partition, group, p.Error)
+		}
+	}
+	return nil
+}
+
+// committedOffsets returns the offsets a group has committed on the given
+// partitions of a topic. Partitions without a commit are left out.
+func (m *Manager) committedOffsets(ctx context.Context, topic, group string, partitions []int) (map[int]int64, error) {
+	resp, err := m.client().OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: group,
+		Topics:  map[string][]int{topic: partitions},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch offsets of consumer group '%s': %w", group, err)
+	}
+	if resp.Error != nil {
+		return nil, fmt.Errorf("failed to fetch offsets of consumer group '%s': %w", group, resp.Error)
+	}
+
+	committed := make(map[int]int64)
+	for _, p := range resp.Topics[topic] {
+		if p.Error != nil {
+			if errors.Is(p.Error, kafka.UnknownTopicOrPartition) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to fetch offset of partition %d for consumer group '%s': %w", p.Partition, group, p.Error)
+		}
+		if p.CommittedOffset >= 0 {
+			committed[p.Partition] = p.CommittedOffset
+		}
+	}
+	return committed, nil
+}
+
+// describeGroups returns the state and members of consumer groups by ID.
+// Groups the broker does not know are left out.
+func (m *Manager) describeGroups(ctx context.Context, groups []string) (map[string]kafka.DescribeGroupsResponseGroup, error) {
+	resp, err := m.client().DescribeGroups(ctx, &kafka.DescribeGroupsRequest{GroupIDs: groups})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe consumer groups: %w", err)
+	}
+
+	result := make(map[string]kafka.DescribeGroupsResponseGroup, len(resp.Groups))
+	for _, g := range resp.Groups {
+		if g.Error != nil {
+			if errors.Is(g.Error, kafka.GroupIdNotFound) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to describe consumer group '%s': %w", g.GroupID, g.Error)
+		}
+		result[g.GroupID] = g
+	}
+	return result, nil
+}
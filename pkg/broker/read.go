@@ -0,0 +1,235 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// ErrStop can be returned by a ReadTopic handler to stop reading without
+// an error
+var ErrStop = errors.New("stop reading")
+
+// Message is a message read from a topic
+type Message struct {
+	Partition int
+	Offset    int64
+	Time      time.Time
+	Key       []byte
+	Value     []byte
+}
+
+// ReadOptions selects the messages ReadTopic delivers
+type ReadOptions struct {
+	// FromBeginning starts at the oldest retained message
+	FromBeginning bool
+	// Since starts at the first message at or after this time. Without
+	// Since or FromBeginning, reading starts at the end of the topic.
+	Since time.Time
+	// Until stops each partition at its first message after this time
+	Until time.Time
+	// Follow keeps waiting for new messages. Otherwise each partition is
+	// read up to the end it had when reading started.
+	Follow bool
+}
+
+// ReadTopic reads the messages of every partition of a topic and passes them
+// to handle, one at a time. Messages of a partition arrive in offset order;
+// partitions are interleaved. Reading stops when every partition is done, ctx
+// is cancelled or handle returns an error. Cancelling ctx is not an error.
+func (m *Manager) ReadTopic(ctx context.Context, topic string, opts ReadOptions, handle func(Message) error) error {
+	partitions, err := m.Partitions(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	starts, err := m.startOffsets(ctx, topic, partitions, opts)
+	if err != nil {
+		return err
+	}
+	ends, err := m.offsets(ctx, topic, partitions, kafka.LastOffsetOf)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	msgs := make(chan Message)
+	errs := make(chan error, len(partitions))
+	var wg sync.WaitGroup
+	for _, p := range partitions {
+		if !opts.Follow && starts[p] >= ends[p] {
+			continue
+		}
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			if err := m.readPartition(ctx, topic, p, starts[p], ends[p], opts, msgs); err != nil {
+				errs <- err
+				cancel()
+			}
+		}(p)
+	}
+	go func() {
+		wg.Wait()
+		close(msgs)
+	}()
+
+	for msg := range msgs {
+		if err := handle(msg); err != nil {
+			if errors.Is(err, ErrStop) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// readPartition sends the messages of one partition from start on to msgs
+func (m *Manager) readPartition(ctx context.Context, topic string, partition int, start, end int64, opts ReadOptions, msgs chan<- Message) error {
+	r := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   []string{m.brokerURL},
+		Topic:     topic,
+		Partition: partition,
+		MaxWait:   500 * time.Millisecond,
+		Dialer:    m.dialer,
+	})
+	defer r.Close()
+
+	if err := r.SetOffset(start); err != nil {
+		return fmt.Errorf("failed to seek partition %d of topic '%s': %w", partition, topic, err)
+	}
+
+	for {
+		km, err := r.ReadMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read partition %d of topic '%s': %w", partition, topic, err)
+		}
+		if !opts.Until.IsZero() && km.Time.After(opts.Until) {
+			return nil
+		}
+
+		msg := Message{Partition: km.Partition, Offset: km.Offset, Time: km.Time, Key: km.Key, Value: km.Value}
+		select {
+		case msgs <- msg:
+		case <-ctx.Done():
+			return nil
+		}
+
+		if !opts.Follow && km.Offset >= end-1 {
+			return nil
+		}
+	}
+}
+
+// Partitions lists the partition IDs of a topic, sorted
+func (m *Manager) Partitions(ctx context.Context, topic string) ([]int, error) {
+	resp, err := m.client().Metadata(ctx, &kafka.MetadataRequest{Topics: []string{topic}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe topic '%s': %w", topic, err)
+	}
+	if len(resp.Topics) == 0 || errors.Is(resp.Topics[0].Error, kafka.UnknownTopicOrPartition) {
+		return nil, fmt.Errorf("topic '%s' does not exist on the broker", topic)
+	}
+	if err := resp.Topics[0].Error; err != nil {
+		return nil, fmt.Errorf("failed to describe topic '%s': %w", topic, err)
+	}
+
+	partitions := make([]int, 0, len(resp.Topics[0].Partitions))
+	for _, p := range resp.Topics[0].Partitions {
+		partitions = append(partitions, p.ID)
+	}
+	sort.Ints(partitions)
+	return partitions, nil
+}
+
+// startOffsets returns the offset each partition is read from
+func (m *Manager) startOffsets(ctx context.Context, topic string, partitions []int, opts ReadOptions) (map[int]int64, error) {
+	switch {
+	case opts.FromBeginning:
+		return m.offsets(ctx, topic, partitions, kafka.FirstOffsetOf)
+	case !opts.Since.IsZero():
+		starts, err := m.offsets(ctx, topic, partitions, func(p int) kafka.OffsetRequest {
+			return kafka.TimeOffsetOf(p, opts.Since)
+		})
+		if err != nil {
+			return nil, err
+		}
+		// Partitions without a message since then start at their end
+		ends, err := m.offsets(ctx, topic, partitions, kafka.LastOffsetOf)
+		if err != nil {
+			return nil, err
+		}
+		for p, offset := range starts {
+			if offset < 0 {
+				starts[p] = ends[p]
+			}
+		}
+		return starts, nil
+	default:
+		return m.offsets(ctx, topic, partitions, kafka.LastOffsetOf)
+	}
+}
+
+// offsets sends one ListOffsets request for every partition of a topic
+func (m *Manager) offsets(ctx context.Context, topic string, partitions []int, request func(int) kafka.OffsetRequest) (map[int]int64, error) {
+	if len(partitions) == 0 {
+		return map[int]int64{}, nil
+	}
+
+	reqs := make([]kafka.OffsetRequest, 0, len(partitions))
+	for _, p := range partitions {
+		reqs = append(reqs, request(p))
+	}
+
+	resp, err := m.client().ListOffsets(ctx, &kafka.ListOffsetsRequest{
+		Topics: map[string][]kafka.OffsetRequest{topic: reqs},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets of topic '%s': %w", topic, err)
+	}
+
+	offsets := make(map[int]int64, len(partitions))
+	for _, po := range resp.Topics[topic] {
+		if po.Error != nil {
+			return nil, fmt.Errorf("failed to list offsets of partition %d of topic '%s': %w", po.Partition, topic, po.Error)
+		}
+		offsets[po.Partition] = responseOffset(po, reqs[0].Timestamp)
+	}
+	return offsets, nil
+}
+
+// responseOffset returns the offset a broker answered for one partition.
+// kafka-go files each answer by the timestamp in the response rather than
+// the one requested, and brokers answer both earliest and latest requests
+// with timestamp -1 (kafka.LastOffset). So the log-start offset lands in
+// LastOffset, while FirstOffset keeps a placeholder 0. A time-based answer
+// lands in Offsets, or in LastOffset as -1 when no message is that recent.
+func responseOffset(po kafka.PartitionOffsets, requested int64) int64 {
+	if po.LastOffset >= 0 {
+		return po.LastOffset
+	}
+	for offset := range po.Offsets {
+		return offset
+	}
+	if requested == kafka.FirstOffset {
+		return po.FirstOffset
+	}
+	return -1
+}
@@ -0,0 +1,42 @@
+package broker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// publishBatchSize is the number of messages Publish sends per request
+const publishBatchSize = 500
+
+// Publish writes messages to an existing topic, spreading them over its
+// partitions by key. A message without a Time is stamped by the writer.
+func (m *Manager) Publish(ctx context.Context, topic string, msgs []Message) error {
+	if _, err := m.Partitions(ctx, topic); err != nil {
+		return err
+	}
+
+	w := &kafka.Writer{
+		Addr:         kafka.TCP(m.brokerURL),
+		Transport:    m.transport,
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchSize:    publishBatchSize,
+		WriteTimeout: requestTimeout,
+	}
+	defer w.Close()
+
+	for start := 0; start < len(msgs); start += publishBatchSize {
+		end := min(start+publishBatchSize, len(msgs))
+		batch := make([]kafka.Message, 0, end-start)
+		for _, msg := range msgs[start:end] {
+			batch = append(batch, kafka.Message{Key: msg.Key, Value: msg.Value, Time: msg.Time})
+		}
+		if err := w.WriteMessages(ctx, batch...); err != nil {
+			return fmt.Errorf("failed to publish to topic '%s' (%d of %d messages written): %w", topic, start, len(msgs), err)
+		}
+	}
+	return nil
+}
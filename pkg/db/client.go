@@ -0,0 +1,338 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/frkr-io/frkr-common/models"
+)
+
+// ClientCredential aliases the common model
+type ClientCredential = models.ClientCredential
+
+// CreateClient creates a new client credential, optionally scoped to a stream
+func CreateClient(db *sql.DB, tenantID, clientID, clientSecret string, streamID *string) (*models.ClientCredential, error) {
+	return NewStore(db).CreateClient(context.Background(), tenantID, clientID, clientSecret, streamID)
+}
+
+// GetClient retrieves a client by client ID or UUID
+func GetClient(db *sql.DB, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	return NewStore(db).GetClient(context.Background(), tenantID, clientIdentifier)
+}
+
+// ListClients lists all clients for a tenant, optionally filtered by stream
+func ListClients(db *sql.DB, tenantID string, streamID *string) ([]*models.ClientCredential, error) {
+	return NewStore(db).ListClients(context.Background(), tenantID, streamID)
+}
+
+// SetClientStream re-scopes a client to a stream, or to no stream when streamID is nil
+func SetClientStream(db *sql.DB, tenantID, clientIdentifier string, streamID *string) (*models.ClientCredential, error) {
+	return NewStore(db).SetClientStream(context.Background(), tenantID, clientIdentifier, streamID)
+}
+
+// RevokeClient soft-deletes a client by setting deleted_at.
+// The gateways only accept clients whose deleted_at is NULL.
+func RevokeClient(db *sql.DB, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	return NewStore(db).RevokeClient(context.Background(), tenantID, clientIdentifier)
+}
+
+// ListRevokedClients lists the revoked clients of a tenant, most recently revoked first
+func ListRevokedClients(db *sql.DB, tenantID string) ([]*models.ClientCredential, error) {
+	return NewStore(db).ListRevokedClients(context.Background(), tenantID)
+}
+
+// RestoreClient clears deleted_at on a revoked client. The client keeps
+// the secret it had when it was revoked.
+func RestoreClient(db *sql.DB, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	return NewStore(db).RestoreClient(context.Background(), tenantID, clientIdentifier)
+}
+
+// DeleteClient permanently removes a client, whether active or revoked.
+// Client IDs are unique per tenant even across revoked clients, so a hard
+// delete is what frees the client ID for reuse.
+func DeleteClient(db *sql.DB, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	return NewStore(db).DeleteClient(context.Background(), tenantID, clientIdentifier)
+}
+
+// clientColumns is the column list scanned by scanClient
+const clientColumns = `id, tenant_id, stream_id, client_id, client_secret, created_at, updated_at, deleted_at`
+
+func scanClient(row rowScanner) (*models.ClientCredential, error) {
+	var client models.ClientCredential
+	err := row.Scan(
+		&client.ID,
+		&client.TenantID,
+		&client.StreamID,
+		&client.ClientID,
+		&client.ClientSecret,
+		&client.CreatedAt,
+		&client.UpdatedAt,
+		&client.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func validateClientID(clientID string) error {
+	if clientID == "" {
+		return fmt.Errorf("client ID cannot be empty")
+	}
+	if len(clientID) > 255 {
+		return fmt.Errorf("client ID cannot exceed 255 characters")
+	}
+	for _, r := range clientID {
+		if !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_') {
+			return fmt.Errorf("client ID can only contain alphanumeric characters, dashes, and underscores")
+		}
+	}
+	return nil
+}
+
+func validateClientSecret(secret string) error {
+	if secret == "" {
+		return fmt.Errorf("client secret cannot be empty")
+	}
+	if len(secret) < 8 {
+		return fmt.Errorf("client secret must be at least 8 characters")
+	}
+	return nil
+}
+
+// nullStreamID converts an optional stream ID to a nullable column value
+func nullStreamID(streamID *string) sql.NullString {
+	if streamID != nil && *streamID != "" {
+		return sql.NullString{String: *streamID, Valid: true}
+	}
+	return sql.NullString{}
+}
+
+// clientStreamError reports a foreign key violation on the clients table
+func clientStreamError(err error, tenantID string, streamID *string) error {
+	if pqCode(err) != codeForeignKeyViolation {
+		return nil
+	}
+	if strings.Contains(err.Error(), "stream_id") && streamID != nil {
+		return newError(ErrNotFound, err, "stream ID '%s' does not exist", *streamID)
+	}
+	return newError(ErrNotFound, err, "tenant ID '%s' does not exist", tenantID)
+}
+
+func (s *sqlStore) CreateClient(ctx context.Context, tenantID, clientID, clientSecret string, streamID *string) (*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if err := validateClientID(clientID); err != nil {
+		return nil, err
+	}
+	if err := validateClientSecret(clientSecret); err != nil {
+		return nil, err
+	}
+
+	client, err := scanClient(s.q.QueryRowContext(ctx, `
+		INSERT INTO clients (tenant_id, stream_id, client_id, client_secret)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+clientColumns+`
+	`, tenantID, nullStreamID(streamID), clientID, clientSecret))
+	if err != nil {
+		if pqCode(err) == codeUniqueViolation {
+			return nil, newError(ErrAlreadyExists, err, "client ID '%s' already exists for this tenant", clientID)
+		}
+		if fkErr := clientStreamError(err, tenantID, streamID); fkErr != nil {
+			return nil, fkErr
+		}
+		return nil, queryError(err, "clients", "create client")
+	}
+
+	return client, nil
+}
+
+func (s *sqlStore) GetClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if clientIdentifier == "" {
+		return nil, fmt.Errorf("client identifier cannot be empty")
+	}
+
+	column := "client_id"
+	if looksLikeUUID(clientIdentifier) {
+		column = "id"
+	}
+
+	client, err := scanClient(s.q.QueryRowContext(ctx, `
+		SELECT `+clientColumns+`
+		FROM clients
+		WHERE `+column+` = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`, clientIdentifier, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, err, "client '%s' not found", clientIdentifier)
+	}
+	if err != nil {
+		return nil, queryError(err, "clients", "get client")
+	}
+
+	return client, nil
+}
+
+func (s *sqlStore) ListClients(ctx context.Context, tenantID string, streamID *string) ([]*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	query := `
+		SELECT ` + clientColumns + `
+		FROM clients
+		WHERE tenant_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`
+	args := []interface{}{tenantID}
+	if streamID != nil && *streamID != "" {
+		query = `
+			SELECT ` + clientColumns + `
+			FROM clients
+			WHERE tenant_id = $1 AND stream_id = $2 AND deleted_at IS NULL
+			ORDER BY created_at DESC
+		`
+		args = append(args, *streamID)
+	}
+
+	return s.queryClients(ctx, query, args...)
+}
+
+// queryClients runs a query selecting clientColumns and scans every row
+func (s *sqlStore) queryClients(ctx context.Context, query string, args ...interface{}) ([]*models.ClientCredential, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(err, "clients", "query clients")
+	}
+	defer rows.Close()
+
+	var clients []*models.ClientCredential
+	for rows.Next() {
+		client, err := scanClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan client: %w", err)
+		}
+		clients = append(clients, client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (s *sqlStore) SetClientStream(ctx context.Context, tenantID, clientIdentifier string, streamID *string) (*models.ClientCredential, error) {
+	client, err := s.GetClient(ctx, tenantID, clientIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.q.QueryRowContext(ctx, `
+		UPDATE clients
+		SET stream_id = $1, updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL
+		RETURNING stream_id, updated_at
+	`, nullStreamID(streamID), client.ID, tenantID).Scan(&client.StreamID, &client.UpdatedAt)
+	if err != nil {
+		if fkErr := clientStreamError(err, tenantID, streamID); fkErr != nil {
+			return nil, fkErr
+		}
+		return nil, fmt.Errorf("failed to update client stream: %w", err)
+	}
+
+	return client, nil
+}
+
+func (s *sqlStore) RevokeClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	client, err := s.GetClient(ctx, tenantID, clientIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.q.ExecContext(ctx, `
+		UPDATE clients
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`, client.ID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke client: %w", err)
+	}
+
+	return client, nil
+}
+
+func (s *sqlStore) ListRevokedClients(ctx context.Context, tenantID string) ([]*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	return s.queryClients(ctx, `
+		SELECT `+clientColumns+`
+		FROM clients
+		WHERE tenant_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`, tenantID)
+}
+
+func (s *sqlStore) RestoreClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if clientIdentifier == "" {
+		return nil, fmt.Errorf("client identifier cannot be empty")
+	}
+
+	column := "client_id"
+	if looksLikeUUID(clientIdentifier) {
+		column = "id"
+	}
+
+	client, err := scanClient(s.q.QueryRowContext(ctx, `
+		UPDATE clients
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE `+column+` = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL
+		RETURNING `+clientColumns+`
+	`, clientIdentifier, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, err, "revoked client '%s' not found", clientIdentifier)
+	}
+	if err != nil {
+		return nil, queryError(err, "clients", "restore client")
+	}
+
+	return client, nil
+}
+
+func (s *sqlStore) DeleteClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if clientIdentifier == "" {
+		return nil, fmt.Errorf("client identifier cannot be empty")
+	}
+
+	column := "client_id"
+	if looksLikeUUID(clientIdentifier) {
+		column = "id"
+	}
+
+	client, err := scanClient(s.q.QueryRowContext(ctx, `
+		DELETE FROM clients
+		WHERE `+column+` = $1 AND tenant_id = $2
+		RETURNING `+clientColumns+`
+	`, clientIdentifier, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, err, "client '%s' not found", clientIdentifier)
+	}
+	if err != nil {
+		return nil, queryError(err, "clients", "delete client")
+	}
+
+	return client, nil
+}
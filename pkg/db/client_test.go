@@ -0,0 +1,78 @@
+package db
+
+import (
+	"testing"
+
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokeAndDeleteClient(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	tenant, err := CreateOrGetTenant(db, "revoke-client-tenant")
+	require.NoError(t, err)
+
+	client, err := CreateClient(db, tenant.ID, "revoke-client", "secret-12345", nil)
+	require.NoError(t, err)
+
+	t.Run("revoke hides client", func(t *testing.T) {
+		_, err := RevokeClient(db, tenant.ID, "revoke-client")
+		require.NoError(t, err)
+
+		_, err = GetClient(db, tenant.ID, "revoke-client")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+
+		clients, err := ListClients(db, tenant.ID, nil)
+		require.NoError(t, err)
+		require.Len(t, clients, 0)
+	})
+
+	t.Run("revoked client ID cannot be reused", func(t *testing.T) {
+		_, err := CreateClient(db, tenant.ID, "revoke-client", "secret-12345", nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already exists")
+	})
+
+	t.Run("delete removes revoked client", func(t *testing.T) {
+		deleted, err := DeleteClient(db, tenant.ID, client.ID)
+		require.NoError(t, err)
+		require.Equal(t, "revoke-client", deleted.ClientID)
+
+		_, err = CreateClient(db, tenant.ID, "revoke-client", "secret-12345", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("delete non-existent client fails", func(t *testing.T) {
+		_, err := DeleteClient(db, tenant.ID, "non-existent")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+}
+
+func TestSetClientStream(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	tenant, err := CreateOrGetTenant(db, "scope-client-tenant")
+	require.NoError(t, err)
+
+	stream, err := CreateStream(db, tenant.ID, "scope-api", "", 7)
+	require.NoError(t, err)
+
+	_, err = CreateClient(db, tenant.ID, "scope-client", "scope-secret-123", nil)
+	require.NoError(t, err)
+
+	t.Run("scope to stream", func(t *testing.T) {
+		client, err := SetClientStream(db, tenant.ID, "scope-client", &stream.ID)
+		require.NoError(t, err)
+		require.True(t, client.StreamID.Valid)
+		require.Equal(t, stream.ID, client.StreamID.String)
+	})
+
+	t.Run("unscope", func(t *testing.T) {
+		client, err := SetClientStream(db, tenant.ID, "scope-client", nil)
+		require.NoError(t, err)
+		require.False(t, client.StreamID.Valid)
+	})
+}
@@ -0,0 +1,165 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// TopicPrefix is the prefix of every topic name GenerateTopicName produces
+const TopicPrefix = "stream-"
+
+// StreamTopic is a stream row's topic, across all tenants
+type StreamTopic struct {
+	TenantName string
+	StreamName string
+	Topic      string
+	// Deleted is set for soft-deleted streams, whose topics may still exist
+	Deleted bool
+}
+
+// ListStreamTopics lists the topic of every stream row, including
+// soft-deleted streams, ordered by tenant and stream name
+func ListStreamTopics(db *sql.DB) ([]StreamTopic, error) {
+	rows, err := db.Query(`
+		SELECT t.name, s.name, s.topic, s.deleted_at IS NOT NULL
+		FROM streams s
+		JOIN tenants t ON t.id = s.tenant_id
+		ORDER BY t.name, s.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stream topics: %w", err)
+	}
+	defer rows.Close()
+
+	var topics []StreamTopic
+	for rows.Next() {
+		var st StreamTopic
+		if err := rows.Scan(&st.TenantName, &st.StreamName, &st.Topic, &st.Deleted); err != nil {
+			return nil, fmt.Errorf("failed to scan stream topic: %w", err)
+		}
+		topics = append(topics, st)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating stream topics: %w", err)
+	}
+
+	return topics, nil
+}
+
+// ClientOnDeletedStream is an active client scoped to a soft-deleted stream.
+// The gateways reject such a client for every stream.
+type ClientOnDeletedStream struct {
+	TenantName string
+	ClientID   string
+	StreamName string
+}
+
+// ListClientsOnDeletedStreams lists active clients, across all tenants,
+// whose stream has been soft-deleted
+func ListClientsOnDeletedStreams(db *sql.DB) ([]ClientOnDeletedStream, error) {
+	rows, err := db.Query(`
+		SELECT t.name, c.client_id, s.name
+		FROM clients c
+		JOIN streams s ON s.id = c.stream_id
+		JOIN tenants t ON t.id = c.tenant_id
+		WHERE c.deleted_at IS NULL AND s.deleted_at IS NOT NULL
+		ORDER BY t.name, c.client_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []ClientOnDeletedStream
+	for rows.Next() {
+		var c ClientOnDeletedStream
+		if err := rows.Scan(&c.TenantName, &c.ClientID, &c.StreamName); err != nil {
+			return nil, fmt.Errorf("failed to scan client: %w", err)
+		}
+		clients = append(clients, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+// ListTenantNamesWithoutStreams lists active tenants that have no active
+// streams, ordered by name
+func ListTenantNamesWithoutStreams(db *sql.DB) ([]string, error) {
+	return queryStrings(db, "tenants", `
+		SELECT t.name
+		FROM tenants t
+		WHERE t.deleted_at IS NULL
+		  AND NOT EXISTS (
+			SELECT 1 FROM streams s
+			WHERE s.tenant_id = t.id AND s.deleted_at IS NULL
+		  )
+		ORDER BY t.name
+	`)
+}
+
+// UserInMissingTenant is an active user whose tenant has been soft-deleted
+type UserInMissingTenant struct {
+	TenantID   string
+	TenantName string
+	Username   string
+}
+
+// ListUsersInMissingTenants lists active users whose tenant has been
+// soft-deleted, e.g. by a tenant delete that predates --cascade
+func ListUsersInMissingTenants(db *sql.DB) ([]UserInMissingTenant, error) {
+	rows, err := db.Query(`
+		SELECT t.id, t.name, u.username
+		FROM users u
+		JOIN tenants t ON t.id = u.tenant_id
+		WHERE u.deleted_at IS NULL AND t.deleted_at IS NOT NULL
+		ORDER BY t.name, u.username
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query users: %w", err)
+	}
+	defer rows.Close()
+
+	var users []UserInMissingTenant
+	for rows.Next() {
+		var u UserInMissingTenant
+		if err := rows.Scan(&u.TenantID, &u.TenantName, &u.Username); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, nil
+}
+
+// queryStrings runs a query that selects a single string column
+func queryStrings(db *sql.DB, what, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %w", what, err)
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", what, err)
+		}
+		values = append(values, v)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s: %w", what, err)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistencyQueries(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	tenant, err := CreateOrGetTenant(db, "doctor-tenant")
+	require.NoError(t, err)
+	stream, err := CreateStream(db, tenant.ID, "doctor-api", "", 7)
+	require.NoError(t, err)
+	_, err = CreateClient(db, tenant.ID, "doctor-client", "doctor-secret-123", &stream.ID)
+	require.NoError(t, err)
+	require.NoError(t, DeleteStream(db, tenant.ID, "doctor-api"))
+
+	gone, err := CreateOrGetTenant(db, "doctor-gone")
+	require.NoError(t, err)
+	_, err = CreateUser(db, gone.ID, "doctor-user", "doctor-pass-123")
+	require.NoError(t, err)
+	// Soft-delete the tenant directly, as a delete without --cascade once did
+	_, err = db.Exec(`UPDATE tenants SET deleted_at = NOW() WHERE id = $1`, gone.ID)
+	require.NoError(t, err)
+
+	t.Run("stream topics include deleted streams", func(t *testing.T) {
+		topics, err := ListStreamTopics(db)
+		require.NoError(t, err)
+		require.Contains(t, topics, StreamTopic{
+			TenantName: "doctor-tenant",
+			StreamName: "doctor-api",
+			Topic:      stream.Topic,
+			Deleted:    true,
+		})
+	})
+
+	t.Run("clients on deleted streams", func(t *testing.T) {
+		clients, err := ListClientsOnDeletedStreams(db)
+		require.NoError(t, err)
+		require.Contains(t, clients, ClientOnDeletedStream{
+			TenantName: "doctor-tenant",
+			ClientID:   "doctor-client",
+			StreamName: "doctor-api",
+		})
+	})
+
+	t.Run("tenants without streams", func(t *testing.T) {
+		names, err := ListTenantNamesWithoutStreams(db)
+		require.NoError(t, err)
+		require.Contains(t, names, "doctor-tenant")
+		require.NotContains(t, names, "doctor-gone")
+	})
+
+	t.Run("users in missing tenants", func(t *testing.T) {
+		users, err := ListUsersInMissingTenants(db)
+		require.NoError(t, err)
+		require.Contains(t, users, UserInMissingTenant{
+			TenantID:   gone.ID,
+			TenantName: "doctor-gone",
+			Username:   "doctor-user",
+		})
+	})
+}
@@ -0,0 +1,867 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-common/util"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// NewMemoryStore returns an empty Store that keeps its data in memory, for
+// tests that cannot run a database. It applies the same validation,
+// uniqueness and soft-delete rules as the SQL store and returns the same
+// errors. It is safe for concurrent use; while a WithTx transaction runs,
+// calls on the Store that started it wait for the transaction to end.
+//
+// Passwords are hashed with bcrypt's minimum cost to keep tests fast.
+func NewMemoryStore() Store {
+	return &memoryStore{mu: &sync.Mutex{}, data: newMemoryData()}
+}
+
+type memoryStore struct {
+	mu   *sync.Mutex
+	data *memoryData
+	// tx is set on the Store passed to a WithTx function, which holds mu
+	tx bool
+}
+
+// memoryData holds the rows of each table, keyed by ID. Rows are replaced
+// rather than modified, so copies of memoryData can share them.
+type memoryData struct {
+	tenants map[string]*models.Tenant
+	streams map[string]*models.Stream
+	users   map[string]*models.TenantUser
+	clients map[string]*models.ClientCredential
+	// seq records insertion order, which breaks ties between equal timestamps
+	seq     map[string]int64
+	nextSeq int64
+}
+
+func newMemoryData() *memoryData {
+	return &memoryData{
+		tenants: map[string]*models.Tenant{},
+		streams: map[string]*models.Stream{},
+		users:   map[string]*models.TenantUser{},
+		clients: map[string]*models.ClientCredential{},
+		seq:     map[string]int64{},
+	}
+}
+
+func (d *memoryData) clone() *memoryData {
+	c := newMemoryData()
+	for id, row := range d.tenants {
+		c.tenants[id] = row
+	}
+	for id, row := range d.streams {
+		c.streams[id] = row
+	}
+	for id, row := range d.users {
+		c.users[id] = row
+	}
+	for id, row := range d.clients {
+		c.clients[id] = row
+	}
+	for id, n := range d.seq {
+		c.seq[id] = n
+	}
+	c.nextSeq = d.nextSeq
+	return c
+}
+
+// insert assigns a new ID and records its insertion order
+func (d *memoryData) insert() string {
+	id := newUUID()
+	d.nextSeq++
+	d.seq[id] = d.nextSeq
+	return id
+}
+
+// newUUID returns a random (version 4) UUID
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("failed to generate UUID: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// lock takes the store's lock, unless the Store is a transaction that already holds it
+func (s *memoryStore) lock() func() {
+	if s.tx {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *memoryStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	if s.tx {
+		return fn(s)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &memoryStore{mu: s.mu, data: s.data.clone(), tx: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	*s.data = *tx.data
+	return nil
+}
+
+// newestFirst sorts rows by a timestamp, most recent first, then by reverse insertion order
+func newestFirst[T any](d *memoryData, rows []*T, id func(*T) string, at func(*T) time.Time) {
+	sort.Slice(rows, func(i, j int) bool {
+		ti, tj := at(rows[i]), at(rows[j])
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return d.seq[id(rows[i])] > d.seq[id(rows[j])]
+	})
+}
+
+// Tenants
+
+func (s *memoryStore) activeTenant(name string) *models.Tenant {
+	for _, tenant := range s.data.tenants {
+		if tenant.Name == name && tenant.DeletedAt == nil {
+			return tenant
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) CreateOrGetTenant(ctx context.Context, name string) (*models.Tenant, error) {
+	if err := validateTenantName(name); err != nil {
+		return nil, err
+	}
+	defer s.lock()()
+
+	if tenant := s.activeTenant(name); tenant != nil {
+		copied := *tenant
+		return &copied, nil
+	}
+
+	now := time.Now()
+	tenant := &models.Tenant{ID: s.data.insert(), Name: name, Plan: "free", CreatedAt: now, UpdatedAt: now}
+	s.data.tenants[tenant.ID] = tenant
+	copied := *tenant
+	return &copied, nil
+}
+
+func (s *memoryStore) GetTenantByName(ctx context.Context, name string) (*models.Tenant, error) {
+	if name == "" {
+		return nil, fmt.Errorf("tenant name cannot be empty")
+	}
+	defer s.lock()()
+
+	tenant := s.activeTenant(name)
+	if tenant == nil {
+		return nil, newError(ErrNotFound, sql.ErrNoRows, "tenant '%s' not found", name)
+	}
+	copied := *tenant
+	return &copied, nil
+}
+
+func (s *memoryStore) ListTenants(ctx context.Context) ([]*models.Tenant, error) {
+	defer s.lock()()
+
+	var tenants []*models.Tenant
+	for _, tenant := range s.data.tenants {
+		if tenant.DeletedAt == nil {
+			copied := *tenant
+			tenants = append(tenants, &copied)
+		}
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Name < tenants[j].Name })
+	return tenants, nil
+}
+
+func (s *memoryStore) RenameTenant(ctx context.Context, oldName, newName string) (*models.Tenant, error) {
+	if err := validateTenantName(newName); err != nil {
+		return nil, err
+	}
+	if oldName == "" {
+		return nil, fmt.Errorf("tenant name cannot be empty")
+	}
+	defer s.lock()()
+
+	tenant := s.activeTenant(oldName)
+	if tenant == nil {
+		return nil, newError(ErrNotFound, sql.ErrNoRows, "tenant '%s' not found", oldName)
+	}
+	if s.activeTenant(newName) != nil {
+		return nil, newError(ErrAlreadyExists, nil, "tenant '%s' already exists", newName)
+	}
+
+	renamed := *tenant
+	renamed.Name = newName
+	renamed.UpdatedAt = time.Now()
+	s.data.tenants[renamed.ID] = &renamed
+	copied := renamed
+	return &copied, nil
+}
+
+func (s *memoryStore) DeleteTenant(ctx context.Context, name string, cascade bool) (*models.Tenant, TenantContents, error) {
+	var contents TenantContents
+	if name == "" {
+		return nil, contents, fmt.Errorf("tenant name cannot be empty")
+	}
+	defer s.lock()()
+
+	tenant := s.activeTenant(name)
+	if tenant == nil {
+		return nil, contents, newError(ErrNotFound, sql.ErrNoRows, "tenant '%s' not found", name)
+	}
+
+	for _, stream := range s.data.streams {
+		if stream.TenantID == tenant.ID && stream.DeletedAt == nil {
+			contents.Streams++
+		}
+	}
+	for _, user := range s.data.users {
+		if user.TenantID == tenant.ID && user.DeletedAt == nil {
+			contents.Users++
+		}
+	}
+	for _, client := range s.data.clients {
+		if client.TenantID == tenant.ID && client.DeletedAt == nil {
+			contents.Clients++
+		}
+	}
+
+	if !contents.Empty() && !cascade {
+		return nil, contents, fmt.Errorf("tenant '%s' still has %d stream(s), %d user(s) and %d client(s)",
+			name, contents.Streams, contents.Users, contents.Clients)
+	}
+
+	now := time.Now()
+	deletedAt := &sql.NullTime{Time: now, Valid: true}
+	for id, stream := range s.data.streams {
+		if stream.TenantID == tenant.ID && stream.DeletedAt == nil {
+			deleted := *stream
+			deleted.DeletedAt, deleted.UpdatedAt = &now, now
+			s.data.streams[id] = &deleted
+		}
+	}
+	for id, user := range s.data.users {
+		if user.TenantID == tenant.ID && user.DeletedAt == nil {
+			deleted := *user
+			deleted.DeletedAt, deleted.UpdatedAt = deletedAt, sql.NullTime{Time: now, Valid: true}
+			s.data.users[id] = &deleted
+		}
+	}
+	for id, client := range s.data.clients {
+		if client.TenantID == tenant.ID && client.DeletedAt == nil {
+			deleted := *client
+			deleted.DeletedAt, deleted.UpdatedAt = deletedAt, sql.NullTime{Time: now, Valid: true}
+			s.data.clients[id] = &deleted
+		}
+	}
+
+	deleted := *tenant
+	deleted.DeletedAt, deleted.UpdatedAt = &now, now
+	s.data.tenants[deleted.ID] = &deleted
+
+	copied := *tenant
+	return &copied, contents, nil
+}
+
+// Streams
+
+// findStream looks a stream up by name or ID, as GetStream does
+func (s *memoryStore) findStream(tenantID, streamIdentifier string, deleted bool) *models.Stream {
+	byID := looksLikeUUID(streamIdentifier)
+	for _, stream := range s.data.streams {
+		if stream.TenantID != tenantID || (stream.DeletedAt != nil) != deleted {
+			continue
+		}
+		if (byID && stream.ID == streamIdentifier) || (!byID && stream.Name == streamIdentifier) {
+			return stream
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) listStreams(tenantID string, deleted bool) []*models.Stream {
+	var streams []*models.Stream
+	for _, stream := range s.data.streams {
+		if stream.TenantID == tenantID && (stream.DeletedAt != nil) == deleted {
+			copied := *stream
+			streams = append(streams, &copied)
+		}
+	}
+	return streams
+}
+
+func (s *memoryStore) CreateStream(ctx context.Context, tenantID, streamName, description string, retentionDays int) (*models.Stream, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if err := util.ValidateStreamName(streamName); err != nil {
+		return nil, err
+	}
+	normalizedDays, err := util.NormalizeRetentionDays(retentionDays)
+	if err != nil {
+		return nil, err
+	}
+	defer s.lock()()
+
+	// Stream names stay taken while a stream is soft-deleted
+	for _, stream := range s.data.streams {
+		if stream.TenantID == tenantID && stream.Name == streamName {
+			return nil, newError(ErrAlreadyExists, nil, "stream '%s' already exists for this tenant", streamName)
+		}
+	}
+	if _, ok := s.data.tenants[tenantID]; !ok {
+		return nil, newError(ErrNotFound, nil, "tenant ID '%s' does not exist", tenantID)
+	}
+
+	now := time.Now()
+	stream := &models.Stream{
+		ID:            s.data.insert(),
+		TenantID:      tenantID,
+		Name:          streamName,
+		Description:   description,
+		Status:        StreamStatusActive,
+		RetentionDays: normalizedDays,
+		Topic:         GenerateTopicName(tenantID, streamName),
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	s.data.streams[stream.ID] = stream
+	copied := *stream
+	return &copied, nil
+}
+
+func (s *memoryStore) GetStream(ctx context.Context, tenantID, streamIdentifier string) (*models.Stream, error) {
+	defer s.lock()()
+
+	stream := s.findStream(tenantID, streamIdentifier, false)
+	if stream == nil {
+		return nil, newError(ErrNotFound, sql.ErrNoRows, "stream '%s' not found", streamIdentifier)
+	}
+	copied := *stream
+	return &copied, nil
+}
+
+func (s *memoryStore) ListStreams(ctx context.Context, tenantID string) ([]*models.Stream, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	defer s.lock()()
+
+	streams := s.listStreams(tenantID, false)
+	newestFirst(s.data, streams, func(st *models.Stream) string { return st.ID }, func(st *models.Stream) time.Time { return st.CreatedAt })
+	return streams, nil
+}
+
+func (s *memoryStore) UpdateStream(ctx context.Context, tenantID, streamIdentifier string, update StreamUpdate) (*models.Stream, error) {
+	defer s.lock()()
+
+	stream := s.findStream(tenantID, streamIdentifier, false)
+	if stream == nil {
+		return nil, newError(ErrNotFound, sql.ErrNoRows, "stream '%s' not found", streamIdentifier)
+	}
+	updated := *stream
+
+	if update.Description != nil {
+		updated.Description = *update.Description
+	}
+	if update.RetentionDays != nil {
+		if *update.RetentionDays < 1 {
+			return nil, fmt.Errorf("retention days must be at least 1, got %d", *update.RetentionDays)
+		}
+		normalizedDays, err := util.NormalizeRetentionDays(*update.RetentionDays)
+		if err != nil {
+			return nil, err
+		}
+		updated.RetentionDays = normalizedDays
+	}
+	if update.Status != nil {
+		switch *update.Status {
+		case StreamStatusActive, StreamStatusPaused:
+			updated.Status = *update.Status
+		default:
+			return nil, fmt.Errorf("invalid stream status '%s' (expected %s or %s)", *update.Status, StreamStatusActive, StreamStatusPaused)
+		}
+	}
+
+	updated.UpdatedAt = time.Now()
+	s.data.streams[updated.ID] = &updated
+	copied := updated
+	return &copied, nil
+}
+
+func (s *memoryStore) DeleteStream(ctx context.Context, tenantID, streamIdentifier string) error {
+	defer s.lock()()
+
+	stream := s.findStream(tenantID, streamIdentifier, false)
+	if stream == nil {
+		return newError(ErrNotFound, sql.ErrNoRows, "stream '%s' not found", streamIdentifier)
+	}
+
+	now := time.Now()
+	deleted := *stream
+	deleted.DeletedAt, deleted.UpdatedAt = &now, now
+	s.data.streams[deleted.ID] = &deleted
+	return nil
+}
+
+func (s *memoryStore) ListDeletedStreams(ctx context.Context, tenantID string) ([]*models.Stream, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	defer s.lock()()
+
+	streams := s.listStreams(tenantID, true)
+	newestFirst(s.data, streams, func(st *models.Stream) string { return st.ID }, func(st *models.Stream) time.Time { return *st.DeletedAt })
+	return streams, nil
+}
+
+func (s *memoryStore) RestoreStream(ctx context.Context, tenantID, streamIdentifier string) (*models.Stream, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if streamIdentifier == "" {
+		return nil, fmt.Errorf("stream identifier cannot be empty")
+	}
+	defer s.lock()()
+
+	stream := s.findStream(tenantID, streamIdentifier, true)
+	if stream == nil {
+		return nil, newError(ErrNotFound, sql.ErrNoRows, "deleted stream '%s' not found", streamIdentifier)
+	}
+
+	restored := *stream
+	restored.DeletedAt, restored.UpdatedAt = nil, time.Now()
+	s.data.streams[restored.ID] = &restored
+	copied := restored
+	return &copied, nil
+}
+
+func (s *memoryStore) PurgeStreams(ctx context.Context, tenantID string, olderThan time.Duration) ([]*models.Stream, int64, error) {
+	if tenantID == "" {
+		return nil, 0, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if olderThan < 0 {
+		return nil, 0, fmt.Errorf("purge age cannot be negative")
+	}
+	cutoff := time.Now().Add(-olderThan)
+	defer s.lock()()
+
+	var streams []*models.Stream
+	for _, stream := range s.listStreams(tenantID, true) {
+		if stream.DeletedAt.Before(cutoff) {
+			streams = append(streams, stream)
+		}
+	}
+	// Oldest deletion first, as the SQL store orders them
+	newestFirst(s.data, streams, func(st *models.Stream) string { return st.ID }, func(st *models.Stream) time.Time { return *st.DeletedAt })
+	for i, j := 0, len(streams)-1; i < j; i, j = i+1, j-1 {
+		streams[i], streams[j] = streams[j], streams[i]
+	}
+
+	var clientsDeleted int64
+	for _, stream := range streams {
+		for id, client := range s.data.clients {
+			if client.TenantID == tenantID && client.StreamID.Valid && client.StreamID.String == stream.ID {
+				delete(s.data.clients, id)
+				clientsDeleted++
+			}
+		}
+		delete(s.data.streams, stream.ID)
+
+		// The foreign key sets stream_id to NULL on clients of other tenants
+		for id, client := range s.data.clients {
+			if client.StreamID.Valid && client.StreamID.String == stream.ID {
+				unscoped := *client
+				unscoped.StreamID = sql.NullString{}
+				s.data.clients[id] = &unscoped
+			}
+		}
+	}
+
+	return streams, clientsDeleted, nil
+}
+
+// Users
+
+// findUser looks a user up by username or ID, including disabled users if anyState is set
+func (s *memoryStore) findUser(tenantID, userIdentifier string, anyState bool) (*models.TenantUser, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if userIdentifier == "" {
+		return nil, fmt.Errorf("user identifier cannot be empty")
+	}
+
+	byID := looksLikeUUID(userIdentifier)
+	for _, user := range s.data.users {
+		if user.TenantID != tenantID || (!anyState && user.DeletedAt != nil) {
+			continue
+		}
+		if (byID && user.ID == userIdentifier) || (!byID && user.Username == userIdentifier) {
+			return user, nil
+		}
+	}
+	return nil, newError(ErrNotFound, sql.ErrNoRows, "user '%s' not found", userIdentifier)
+}
+
+func (s *memoryStore) listUsers(tenantID string, anyState bool) []*models.TenantUser {
+	var users []*models.TenantUser
+	for _, user := range s.data.users {
+		if user.TenantID == tenantID && (anyState || user.DeletedAt == nil) {
+			copied := *user
+			users = append(users, &copied)
+		}
+	}
+	newestFirst(s.data, users, func(u *models.TenantUser) string { return u.ID }, func(u *models.TenantUser) time.Time { return u.CreatedAt.Time })
+	return users
+}
+
+func (s *memoryStore) CreateUser(ctx context.Context, tenantID, username, password string) (*models.TenantUser, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if err := util.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	defer s.lock()()
+
+	// Usernames stay taken while a user is disabled
+	for _, user := range s.data.users {
+		if user.TenantID == tenantID && user.Username == username {
+			return nil, newError(ErrAlreadyExists, nil, "username '%s' already exists for this tenant", username)
+		}
+	}
+	if _, ok := s.data.tenants[tenantID]; !ok {
+		return nil, newError(ErrNotFound, nil, "tenant ID '%s' does not exist", tenantID)
+	}
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	user := &models.TenantUser{
+		ID:           s.data.insert(),
+		TenantID:     tenantID,
+		Username:     username,
+		PasswordHash: string(passwordHash),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	s.data.users[user.ID] = user
+	copied := *user
+	return &copied, nil
+}
+
+func (s *memoryStore) GetUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	defer s.lock()()
+
+	user, err := s.findUser(tenantID, userIdentifier, false)
+	if err != nil {
+		return nil, err
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (s *memoryStore) ListUsers(ctx context.Context, tenantID string) ([]*models.TenantUser, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	defer s.lock()()
+
+	return s.listUsers(tenantID, false), nil
+}
+
+func (s *memoryStore) ListAllUsers(ctx context.Context, tenantID string) ([]*models.TenantUser, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	defer s.lock()()
+
+	return s.listUsers(tenantID, true), nil
+}
+
+func (s *memoryStore) ResetPassword(ctx context.Context, tenantID, userIdentifier, password string) (*models.TenantUser, error) {
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+	defer s.lock()()
+
+	user, err := s.findUser(tenantID, userIdentifier, false)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *user
+	updated.PasswordHash = string(passwordHash)
+	updated.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.data.users[updated.ID] = &updated
+	copied := updated
+	return &copied, nil
+}
+
+func (s *memoryStore) DisableUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	defer s.lock()()
+
+	user, err := s.findUser(tenantID, userIdentifier, false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	disabled := *user
+	disabled.DeletedAt, disabled.UpdatedAt = &now, now
+	s.data.users[disabled.ID] = &disabled
+	// Like the SQL store, return the user as it was before the update
+	copied := *user
+	return &copied, nil
+}
+
+func (s *memoryStore) EnableUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	defer s.lock()()
+
+	user, err := s.findUser(tenantID, userIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt == nil || !user.DeletedAt.Valid {
+		return nil, fmt.Errorf("user '%s' is not disabled", userIdentifier)
+	}
+
+	enabled := *user
+	enabled.DeletedAt = nil
+	enabled.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.data.users[enabled.ID] = &enabled
+	copied := enabled
+	return &copied, nil
+}
+
+func (s *memoryStore) DeleteUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	defer s.lock()()
+
+	user, err := s.findUser(tenantID, userIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.data.users, user.ID)
+	copied := *user
+	return &copied, nil
+}
+
+// Clients
+
+// findClient looks a client up by client ID or UUID, including revoked clients if anyState is set
+func (s *memoryStore) findClient(tenantID, clientIdentifier string, anyState bool) (*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if clientIdentifier == "" {
+		return nil, fmt.Errorf("client identifier cannot be empty")
+	}
+
+	byID := looksLikeUUID(clientIdentifier)
+	for _, client := range s.data.clients {
+		if client.TenantID != tenantID || (!anyState && client.DeletedAt != nil) {
+			continue
+		}
+		if (byID && client.ID == clientIdentifier) || (!byID && client.ClientID == clientIdentifier) {
+			return client, nil
+		}
+	}
+	return nil, newError(ErrNotFound, sql.ErrNoRows, "client '%s' not found", clientIdentifier)
+}
+
+// checkStreamID mirrors the clients table's foreign key on stream_id, which
+// accepts any existing stream row, including soft-deleted ones
+func (s *memoryStore) checkStreamID(streamID *string) error {
+	id := nullStreamID(streamID)
+	if !id.Valid {
+		return nil
+	}
+	if _, ok := s.data.streams[id.String]; !ok {
+		return newError(ErrNotFound, nil, "stream ID '%s' does not exist", id.String)
+	}
+	return nil
+}
+
+func (s *memoryStore) CreateClient(ctx context.Context, tenantID, clientID, clientSecret string, streamID *string) (*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if err := validateClientID(clientID); err != nil {
+		return nil, err
+	}
+	if err := validateClientSecret(clientSecret); err != nil {
+		return nil, err
+	}
+	defer s.lock()()
+
+	// Client IDs stay taken while a client is revoked
+	for _, client := range s.data.clients {
+		if client.TenantID == tenantID && client.ClientID == clientID {
+			return nil, newError(ErrAlreadyExists, nil, "client ID '%s' already exists for this tenant", clientID)
+		}
+	}
+	if _, ok := s.data.tenants[tenantID]; !ok {
+		return nil, newError(ErrNotFound, nil, "tenant ID '%s' does not exist", tenantID)
+	}
+	if err := s.checkStreamID(streamID); err != nil {
+		return nil, err
+	}
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	client := &models.ClientCredential{
+		ID:           s.data.insert(),
+		TenantID:     tenantID,
+		StreamID:     nullStreamID(streamID),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	s.data.clients[client.ID] = client
+	copied := *client
+	return &copied, nil
+}
+
+func (s *memoryStore) GetClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	defer s.lock()()
+
+	client, err := s.findClient(tenantID, clientIdentifier, false)
+	if err != nil {
+		return nil, err
+	}
+	copied := *client
+	return &copied, nil
+}
+
+func (s *memoryStore) ListClients(ctx context.Context, tenantID string, streamID *string) ([]*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	stream := nullStreamID(streamID)
+	defer s.lock()()
+
+	var clients []*models.ClientCredential
+	for _, client := range s.data.clients {
+		if client.TenantID != tenantID || client.DeletedAt != nil {
+			continue
+		}
+		if stream.Valid && client.StreamID != stream {
+			continue
+		}
+		copied := *client
+		clients = append(clients, &copied)
+	}
+	newestFirst(s.data, clients, func(c *models.ClientCredential) string { return c.ID }, func(c *models.ClientCredential) time.Time { return c.CreatedAt.Time })
+	return clients, nil
+}
+
+func (s *memoryStore) SetClientStream(ctx context.Context, tenantID, clientIdentifier string, streamID *string) (*models.ClientCredential, error) {
+	defer s.lock()()
+
+	client, err := s.findClient(tenantID, clientIdentifier, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkStreamID(streamID); err != nil {
+		return nil, err
+	}
+
+	updated := *client
+	updated.StreamID = nullStreamID(streamID)
+	updated.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.data.clients[updated.ID] = &updated
+	copied := updated
+	return &copied, nil
+}
+
+func (s *memoryStore) RevokeClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	defer s.lock()()
+
+	client, err := s.findClient(tenantID, clientIdentifier, false)
+	if err != nil {
+		return nil, err
+	}
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	revoked := *client
+	revoked.DeletedAt, revoked.UpdatedAt = &now, now
+	s.data.clients[revoked.ID] = &revoked
+	// Like the SQL store, return the client as it was before the update
+	copied := *client
+	return &copied, nil
+}
+
+func (s *memoryStore) ListRevokedClients(ctx context.Context, tenantID string) ([]*models.ClientCredential, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	defer s.lock()()
+
+	var clients []*models.ClientCredential
+	for _, client := range s.data.clients {
+		if client.TenantID == tenantID && client.DeletedAt != nil {
+			copied := *client
+			clients = append(clients, &copied)
+		}
+	}
+	newestFirst(s.data, clients, func(c *models.ClientCredential) string { return c.ID }, func(c *models.ClientCredential) time.Time { return c.DeletedAt.Time })
+	return clients, nil
+}
+
+func (s *memoryStore) RestoreClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	defer s.lock()()
+
+	client, err := s.findClient(tenantID, clientIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+	if client.DeletedAt == nil {
+		return nil, newError(ErrNotFound, sql.ErrNoRows, "revoked client '%s' not found", clientIdentifier)
+	}
+
+	restored := *client
+	restored.DeletedAt = nil
+	restored.UpdatedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	s.data.clients[restored.ID] = &restored
+	copied := restored
+	return &copied, nil
+}
+
+func (s *memoryStore) DeleteClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error) {
+	defer s.lock()()
+
+	client, err := s.findClient(tenantID, clientIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.data.clients, client.ID)
+	copied := *client
+	return &copied, nil
+}
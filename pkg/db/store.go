@@ -0,0 +1,176 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/frkr-io/frkr-common/models"
+	"github.com/lib/pq"
+)
+
+// Sentinel errors returned by Store methods. Test for them with errors.Is;
+// the error messages name the object involved.
+var (
+	// ErrNotFound means the tenant, stream, user or client does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrAlreadyExists means a create or rename conflicts with an existing object
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrSchemaMissing means a table is missing because migrations have not been run
+	ErrSchemaMissing = errors.New("schema missing")
+)
+
+// Store is the context-aware interface to the frkr configuration tables.
+// Its methods behave like the package functions of the same name, which
+// run against a Store of their own. Methods that need a transaction start
+// one; use WithTx to group several calls.
+type Store interface {
+	TenantStore
+	StreamStore
+	UserStore
+	ClientStore
+
+	// WithTx runs fn in a transaction, which is committed if fn returns nil
+	// and rolled back otherwise. Calls on the Store passed to fn share the
+	// transaction; WithTx on a Store that is already in one joins it.
+	WithTx(ctx context.Context, fn func(tx Store) error) error
+}
+
+// TenantStore manages tenants
+type TenantStore interface {
+	CreateOrGetTenant(ctx context.Context, name string) (*models.Tenant, error)
+	GetTenantByName(ctx context.Context, name string) (*models.Tenant, error)
+	ListTenants(ctx context.Context) ([]*models.Tenant, error)
+	RenameTenant(ctx context.Context, oldName, newName string) (*models.Tenant, error)
+	DeleteTenant(ctx context.Context, name string, cascade bool) (*models.Tenant, TenantContents, error)
+}
+
+// StreamStore manages the streams of a tenant. Streams are identified by
+// name or ID.
+type StreamStore interface {
+	CreateStream(ctx context.Context, tenantID, streamName, description string, retentionDays int) (*models.Stream, error)
+	GetStream(ctx context.Context, tenantID, streamIdentifier string) (*models.Stream, error)
+	ListStreams(ctx context.Context, tenantID string) ([]*models.Stream, error)
+	UpdateStream(ctx context.Context, tenantID, streamIdentifier string, update StreamUpdate) (*models.Stream, error)
+	DeleteStream(ctx context.Context, tenantID, streamIdentifier string) error
+	ListDeletedStreams(ctx context.Context, tenantID string) ([]*models.Stream, error)
+	RestoreStream(ctx context.Context, tenantID, streamIdentifier string) (*models.Stream, error)
+	PurgeStreams(ctx context.Context, tenantID string, olderThan time.Duration) ([]*models.Stream, int64, error)
+}
+
+// UserStore manages the users of a tenant. Users are identified by
+// username or ID.
+type UserStore interface {
+	CreateUser(ctx context.Context, tenantID, username, password string) (*models.TenantUser, error)
+	GetUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error)
+	ListUsers(ctx context.Context, tenantID string) ([]*models.TenantUser, error)
+	ListAllUsers(ctx context.Context, tenantID string) ([]*models.TenantUser, error)
+	ResetPassword(ctx context.Context, tenantID, userIdentifier, password string) (*models.TenantUser, error)
+	DisableUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error)
+	EnableUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error)
+	DeleteUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error)
+}
+
+// ClientStore manages the clients of a tenant. Clients are identified by
+// client ID or UUID.
+type ClientStore interface {
+	CreateClient(ctx context.Context, tenantID, clientID, clientSecret string, streamID *string) (*models.ClientCredential, error)
+	GetClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error)
+	ListClients(ctx context.Context, tenantID string, streamID *string) ([]*models.ClientCredential, error)
+	SetClientStream(ctx context.Context, tenantID, clientIdentifier string, streamID *string) (*models.ClientCredential, error)
+	RevokeClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error)
+	ListRevokedClients(ctx context.Context, tenantID string) ([]*models.ClientCredential, error)
+	RestoreClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error)
+	DeleteClient(ctx context.Context, tenantID, clientIdentifier string) (*models.ClientCredential, error)
+}
+
+// NewStore returns a Store backed by a CockroachDB (or PostgreSQL) database
+func NewStore(db *sql.DB) Store {
+	return &sqlStore{db: db, q: db}
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type sqlStore struct {
+	// db is nil inside a transaction
+	db *sql.DB
+	q  querier
+}
+
+func (s *sqlStore) WithTx(ctx context.Context, fn func(tx Store) error) error {
+	return s.withTx(ctx, func(tx *sqlStore) error { return fn(tx) })
+}
+
+func (s *sqlStore) withTx(ctx context.Context, fn func(tx *sqlStore) error) error {
+	if s.db == nil {
+		return fn(s)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&sqlStore{q: tx}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// storeError is an error matching one of the sentinel errors, with a
+// message describing the object involved
+type storeError struct {
+	kind  error
+	msg   string
+	cause error
+}
+
+func newError(kind, cause error, format string, args ...interface{}) error {
+	return &storeError{kind: kind, msg: fmt.Sprintf(format, args...), cause: cause}
+}
+
+func (e *storeError) Error() string {
+	return e.msg
+}
+
+func (e *storeError) Unwrap() []error {
+	if e.cause == nil {
+		return []error{e.kind}
+	}
+	return []error{e.kind, e.cause}
+}
+
+// PostgreSQL error codes mapped onto the sentinel errors
+const (
+	codeUniqueViolation     = "23505"
+	codeForeignKeyViolation = "23503"
+	codeUndefinedTable      = "42P01"
+)
+
+// pqCode returns the PostgreSQL error code of err, or "" for other errors
+func pqCode(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// queryError reports a failed query on table, mapping a missing table onto ErrSchemaMissing
+func queryError(err error, table, action string) error {
+	if pqCode(err) == codeUndefinedTable {
+		return newError(ErrSchemaMissing, err, "%s table does not exist - migrations may not have been run", table)
+	}
+	return fmt.Errorf("failed to %s: %w", action, err)
+}
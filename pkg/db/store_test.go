@@ -0,0 +1,390 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreErrors(t *testing.T) {
+	err := newError(ErrNotFound, sql.ErrNoRows, "stream '%s' not found", "my-api")
+	require.EqualError(t, err, "stream 'my-api' not found")
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+	require.NotErrorIs(t, err, ErrAlreadyExists)
+	require.ErrorIs(t, fmt.Errorf("failed to get stream: %w", err), ErrNotFound)
+
+	err = queryError(&pq.Error{Code: codeUndefinedTable}, "users", "get user")
+	require.EqualError(t, err, "users table does not exist - migrations may not have been run")
+	require.ErrorIs(t, err, ErrSchemaMissing)
+
+	err = queryError(errors.New("connection refused"), "users", "get user")
+	require.EqualError(t, err, "failed to get user: connection refused")
+	require.NotErrorIs(t, err, ErrSchemaMissing)
+}
+
+func TestSQLStore(t *testing.T) {
+	conn, _ := setupTestDB(t)
+	testStoreConformance(t, NewStore(conn))
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStoreConformance(t, NewMemoryStore())
+}
+
+func TestMemoryStoreConcurrency(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	tenant, err := store.CreateOrGetTenant(ctx, "concurrent")
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 40)
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.CreateStream(ctx, tenant.ID, fmt.Sprintf("stream-%d", i), "", 7)
+			errs <- err
+		}(i)
+		go func() {
+			defer wg.Done()
+			errs <- store.WithTx(ctx, func(tx Store) error {
+				_, err := tx.ListStreams(ctx, tenant.ID)
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	streams, err := store.ListStreams(ctx, tenant.ID)
+	require.NoError(t, err)
+	require.Len(t, streams, 20)
+
+	// Returned rows are copies
+	streams[0].Name = "changed"
+	again, err := store.GetStream(ctx, tenant.ID, streams[0].ID)
+	require.NoError(t, err)
+	require.NotEqual(t, "changed", again.Name)
+}
+
+// testStoreConformance checks the behaviour every Store implementation
+// shares. Each subtest works in a tenant of its own.
+func testStoreConformance(t *testing.T, store Store) {
+	ctx := context.Background()
+	newTenant := func(t *testing.T, name string) string {
+		tenant, err := store.CreateOrGetTenant(ctx, name)
+		require.NoError(t, err)
+		return tenant.ID
+	}
+	missingID := "00000000-0000-0000-0000-000000000000"
+
+	t.Run("tenants", func(t *testing.T) {
+		created, err := store.CreateOrGetTenant(ctx, "conformance-a")
+		require.NoError(t, err)
+		require.Equal(t, "free", created.Plan)
+		again, err := store.CreateOrGetTenant(ctx, "conformance-a")
+		require.NoError(t, err)
+		require.Equal(t, created.ID, again.ID)
+
+		_, err = store.CreateOrGetTenant(ctx, "")
+		require.ErrorContains(t, err, "cannot be empty")
+
+		_, err = store.GetTenantByName(ctx, "conformance-missing")
+		require.ErrorIs(t, err, ErrNotFound)
+		require.ErrorIs(t, err, sql.ErrNoRows)
+
+		_, err = store.CreateOrGetTenant(ctx, "conformance-b")
+		require.NoError(t, err)
+		_, err = store.RenameTenant(ctx, "conformance-a", "conformance-b")
+		require.ErrorIs(t, err, ErrAlreadyExists)
+		renamed, err := store.RenameTenant(ctx, "conformance-a", "conformance-c")
+		require.NoError(t, err)
+		require.Equal(t, created.ID, renamed.ID)
+
+		tenants, err := store.ListTenants(ctx)
+		require.NoError(t, err)
+		var names []string
+		for _, tenant := range tenants {
+			names = append(names, tenant.Name)
+		}
+		require.Subset(t, names, []string{"conformance-b", "conformance-c"})
+		require.IsIncreasing(t, names)
+	})
+
+	t.Run("delete tenant", func(t *testing.T) {
+		tenantID := newTenant(t, t.Name())
+		_, err := store.CreateStream(ctx, tenantID, "orders", "", 7)
+		require.NoError(t, err)
+		_, err = store.CreateUser(ctx, tenantID, "alice", "password-123")
+		require.NoError(t, err)
+
+		_, contents, err := store.DeleteTenant(ctx, t.Name(), false)
+		require.ErrorContains(t, err, "still has 1 stream(s), 1 user(s) and 0 client(s)")
+		require.Equal(t, TenantContents{Streams: 1, Users: 1}, contents)
+
+		deleted, contents, err := store.DeleteTenant(ctx, t.Name(), true)
+		require.NoError(t, err)
+		require.Equal(t, tenantID, deleted.ID)
+		require.Equal(t, int64(1), contents.Streams)
+
+		_, err = store.GetTenantByName(ctx, t.Name())
+		require.ErrorIs(t, err, ErrNotFound)
+		_, err = store.GetStream(ctx, tenantID, "orders")
+		require.ErrorIs(t, err, ErrNotFound)
+		users, err := store.ListAllUsers(ctx, tenantID)
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		require.NotNil(t, users[0].DeletedAt)
+	})
+
+	t.Run("streams", func(t *testing.T) {
+		tenantID := newTenant(t, t.Name())
+		otherID := newTenant(t, t.Name()+"-other")
+
+		_, err := store.CreateStream(ctx, tenantID, "", "", 7)
+		require.ErrorContains(t, err, "cannot be empty")
+		_, err = store.CreateStream(ctx, tenantID, "orders", "", 400)
+		require.ErrorContains(t, err, "cannot exceed 365")
+		_, err = store.CreateStream(ctx, missingID, "orders", "", 7)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		stream, err := store.CreateStream(ctx, tenantID, "orders", "Orders API", 0)
+		require.NoError(t, err)
+		require.Equal(t, StreamStatusActive, stream.Status)
+		require.Equal(t, 7, stream.RetentionDays)
+		require.Equal(t, GenerateTopicName(tenantID, "orders"), stream.Topic)
+		_, err = store.CreateStream(ctx, tenantID, "orders", "", 7)
+		require.ErrorIs(t, err, ErrAlreadyExists)
+		_, err = store.CreateStream(ctx, otherID, "orders", "", 7)
+		require.NoError(t, err)
+
+		byName, err := store.GetStream(ctx, tenantID, "orders")
+		require.NoError(t, err)
+		byID, err := store.GetStream(ctx, tenantID, stream.ID)
+		require.NoError(t, err)
+		require.Equal(t, byName.ID, byID.ID)
+		_, err = store.GetStream(ctx, otherID, stream.ID)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.CreateStream(ctx, tenantID, "payments", "", 7)
+		require.NoError(t, err)
+		streams, err := store.ListStreams(ctx, tenantID)
+		require.NoError(t, err)
+		require.Len(t, streams, 2)
+		require.Equal(t, "payments", streams[0].Name)
+
+		paused := StreamStatusPaused
+		days := 30
+		updated, err := store.UpdateStream(ctx, tenantID, "orders", StreamUpdate{Status: &paused, RetentionDays: &days})
+		require.NoError(t, err)
+		require.Equal(t, StreamStatusPaused, updated.Status)
+		require.Equal(t, 30, updated.RetentionDays)
+		bogus := "stopped"
+		_, err = store.UpdateStream(ctx, tenantID, "orders", StreamUpdate{Status: &bogus})
+		require.ErrorContains(t, err, "invalid stream status")
+		zero := 0
+		_, err = store.UpdateStream(ctx, tenantID, "orders", StreamUpdate{RetentionDays: &zero})
+		require.ErrorContains(t, err, "at least 1")
+		_, err = store.UpdateStream(ctx, tenantID, "missing", StreamUpdate{})
+		require.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("stream soft delete", func(t *testing.T) {
+		tenantID := newTenant(t, t.Name())
+		stream, err := store.CreateStream(ctx, tenantID, "orders", "", 7)
+		require.NoError(t, err)
+		client, err := store.CreateClient(ctx, tenantID, "orders-client", "secret-123", &stream.ID)
+		require.NoError(t, err)
+
+		require.NoError(t, store.DeleteStream(ctx, tenantID, "orders"))
+		require.ErrorIs(t, store.DeleteStream(ctx, tenantID, "orders"), ErrNotFound)
+		_, err = store.GetStream(ctx, tenantID, "orders")
+		require.ErrorIs(t, err, ErrNotFound)
+		// The name stays taken until the stream is purged
+		_, err = store.CreateStream(ctx, tenantID, "orders", "", 7)
+		require.ErrorIs(t, err, ErrAlreadyExists)
+
+		deleted, err := store.ListDeletedStreams(ctx, tenantID)
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		require.NotNil(t, deleted[0].DeletedAt)
+
+		restored, err := store.RestoreStream(ctx, tenantID, stream.ID)
+		require.NoError(t, err)
+		require.Nil(t, restored.DeletedAt)
+		_, err = store.RestoreStream(ctx, tenantID, "orders")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		require.NoError(t, store.DeleteStream(ctx, tenantID, "orders"))
+		purged, _, err := store.PurgeStreams(ctx, tenantID, time.Hour)
+		require.NoError(t, err)
+		require.Empty(t, purged)
+		purged, clients, err := store.PurgeStreams(ctx, tenantID, 0)
+		require.NoError(t, err)
+		require.Len(t, purged, 1)
+		require.Equal(t, int64(1), clients)
+		_, err = store.DeleteClient(ctx, tenantID, client.ClientID)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.CreateStream(ctx, tenantID, "orders", "", 7)
+		require.NoError(t, err)
+	})
+
+	t.Run("users", func(t *testing.T) {
+		tenantID := newTenant(t, t.Name())
+
+		_, err := store.CreateUser(ctx, tenantID, "alice", "short")
+		require.ErrorContains(t, err, "at least 8 characters")
+		_, err = store.CreateUser(ctx, missingID, "alice", "password-123")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		user, err := store.CreateUser(ctx, tenantID, "alice", "password-123")
+		require.NoError(t, err)
+		require.NoError(t, VerifyPassword(user, "password-123"))
+		_, err = store.CreateUser(ctx, tenantID, "alice", "password-123")
+		require.ErrorIs(t, err, ErrAlreadyExists)
+
+		byID, err := store.GetUser(ctx, tenantID, user.ID)
+		require.NoError(t, err)
+		require.Equal(t, "alice", byID.Username)
+
+		reset, err := store.ResetPassword(ctx, tenantID, "alice", "password-456")
+		require.NoError(t, err)
+		require.NoError(t, VerifyPassword(reset, "password-456"))
+
+		_, err = store.EnableUser(ctx, tenantID, "alice")
+		require.ErrorContains(t, err, "is not disabled")
+		_, err = store.DisableUser(ctx, tenantID, "alice")
+		require.NoError(t, err)
+		_, err = store.GetUser(ctx, tenantID, "alice")
+		require.ErrorIs(t, err, ErrNotFound)
+		active, err := store.ListUsers(ctx, tenantID)
+		require.NoError(t, err)
+		require.Empty(t, active)
+		all, err := store.ListAllUsers(ctx, tenantID)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		// Disabled users keep their username
+		_, err = store.CreateUser(ctx, tenantID, "alice", "password-123")
+		require.ErrorIs(t, err, ErrAlreadyExists)
+
+		enabled, err := store.EnableUser(ctx, tenantID, "alice")
+		require.NoError(t, err)
+		require.Nil(t, enabled.DeletedAt)
+
+		_, err = store.DeleteUser(ctx, tenantID, "alice")
+		require.NoError(t, err)
+		_, err = store.DeleteUser(ctx, tenantID, "alice")
+		require.ErrorIs(t, err, ErrNotFound)
+		_, err = store.CreateUser(ctx, tenantID, "alice", "password-123")
+		require.NoError(t, err)
+	})
+
+	t.Run("clients", func(t *testing.T) {
+		tenantID := newTenant(t, t.Name())
+		stream, err := store.CreateStream(ctx, tenantID, "orders", "", 7)
+		require.NoError(t, err)
+
+		_, err = store.CreateClient(ctx, tenantID, "bad id!", "secret-123", nil)
+		require.ErrorContains(t, err, "alphanumeric")
+		_, err = store.CreateClient(ctx, tenantID, "scoped", "secret-123", &missingID)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		scoped, err := store.CreateClient(ctx, tenantID, "scoped", "secret-123", &stream.ID)
+		require.NoError(t, err)
+		require.Equal(t, stream.ID, scoped.StreamID.String)
+		_, err = store.CreateClient(ctx, tenantID, "scoped", "secret-123", nil)
+		require.ErrorIs(t, err, ErrAlreadyExists)
+		_, err = store.CreateClient(ctx, tenantID, "tenant-wide", "secret-123", nil)
+		require.NoError(t, err)
+
+		all, err := store.ListClients(ctx, tenantID, nil)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		require.Equal(t, "tenant-wide", all[0].ClientID)
+		filtered, err := store.ListClients(ctx, tenantID, &stream.ID)
+		require.NoError(t, err)
+		require.Len(t, filtered, 1)
+
+		unscoped, err := store.SetClientStream(ctx, tenantID, "scoped", nil)
+		require.NoError(t, err)
+		require.False(t, unscoped.StreamID.Valid)
+		_, err = store.SetClientStream(ctx, tenantID, "scoped", &missingID)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.RevokeClient(ctx, tenantID, "scoped")
+		require.NoError(t, err)
+		_, err = store.GetClient(ctx, tenantID, "scoped")
+		require.ErrorIs(t, err, ErrNotFound)
+		// Revoked clients keep their client ID until deleted
+		_, err = store.CreateClient(ctx, tenantID, "scoped", "secret-123", nil)
+		require.ErrorIs(t, err, ErrAlreadyExists)
+		revoked, err := store.ListRevokedClients(ctx, tenantID)
+		require.NoError(t, err)
+		require.Len(t, revoked, 1)
+		require.Equal(t, "scoped", revoked[0].ClientID)
+
+		restored, err := store.RestoreClient(ctx, tenantID, "scoped")
+		require.NoError(t, err)
+		require.Nil(t, restored.DeletedAt)
+		require.Equal(t, "secret-123", restored.ClientSecret)
+		_, err = store.GetClient(ctx, tenantID, "scoped")
+		require.NoError(t, err)
+		_, err = store.RestoreClient(ctx, tenantID, "scoped")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		_, err = store.RevokeClient(ctx, tenantID, "scoped")
+		require.NoError(t, err)
+		_, err = store.DeleteClient(ctx, tenantID, "scoped")
+		require.NoError(t, err)
+		_, err = store.CreateClient(ctx, tenantID, "scoped", "secret-123", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("transactions", func(t *testing.T) {
+		tenantID := newTenant(t, t.Name())
+
+		errAbort := errors.New("abort")
+		err := store.WithTx(ctx, func(tx Store) error {
+			if _, err := tx.CreateStream(ctx, tenantID, "rolled-back", "", 7); err != nil {
+				return err
+			}
+			// Visible inside the transaction
+			if _, err := tx.GetStream(ctx, tenantID, "rolled-back"); err != nil {
+				return err
+			}
+			return errAbort
+		})
+		require.ErrorIs(t, err, errAbort)
+		_, err = store.GetStream(ctx, tenantID, "rolled-back")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		err = store.WithTx(ctx, func(tx Store) error {
+			stream, err := tx.CreateStream(ctx, tenantID, "committed", "", 7)
+			if err != nil {
+				return err
+			}
+			// A nested WithTx joins the outer transaction
+			return tx.WithTx(ctx, func(tx Store) error {
+				_, err := tx.CreateClient(ctx, tenantID, "committed-client", "secret-123", &stream.ID)
+				return err
+			})
+		})
+		require.NoError(t, err)
+		client, err := store.GetClient(ctx, tenantID, "committed-client")
+		require.NoError(t, err)
+		require.True(t, client.StreamID.Valid)
+	})
+}
@@ -1,46 +1,337 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"time"
 
 	commondb "github.com/frkr-io/frkr-common/db"
 	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-common/util"
 )
 
-// CreateOrGetTenant creates a tenant or returns existing one
-func CreateOrGetTenant(db *sql.DB, name string) (*models.Tenant, error) {
-	return commondb.CreateOrGetTenant(db, name)
-}
-
 // CreateStream creates a new stream for a tenant
 func CreateStream(db *sql.DB, tenantID, streamName, description string, retentionDays int) (*models.Stream, error) {
-	return commondb.CreateStream(db, tenantID, streamName, description, retentionDays)
+	return NewStore(db).CreateStream(context.Background(), tenantID, streamName, description, retentionDays)
+}
+
+// GenerateTopicName returns the topic name CreateStream assigns to a stream
+func GenerateTopicName(tenantID, streamName string) string {
+	return commondb.GenerateTopicName(tenantID, streamName)
 }
 
 // GetStream retrieves a stream by ID or name
 func GetStream(db *sql.DB, tenantID, streamIdentifier string) (*models.Stream, error) {
-	return commondb.GetStream(db, tenantID, streamIdentifier)
+	return NewStore(db).GetStream(context.Background(), tenantID, streamIdentifier)
 }
 
 // ListStreams lists all streams for a tenant
 func ListStreams(db *sql.DB, tenantID string) ([]*models.Stream, error) {
-	return commondb.ListStreams(db, tenantID)
+	return NewStore(db).ListStreams(context.Background(), tenantID)
+}
+
+// Stream statuses accepted by UpdateStream
+const (
+	StreamStatusActive = "active"
+	StreamStatusPaused = "paused"
+)
+
+// StreamUpdate lists the stream fields to change. Nil fields are left untouched.
+type StreamUpdate struct {
+	Description   *string
+	RetentionDays *int
+	Status        *string
+}
+
+// UpdateStream changes a stream's description, retention and/or status
+func UpdateStream(db *sql.DB, tenantID, streamIdentifier string, update StreamUpdate) (*models.Stream, error) {
+	return NewStore(db).UpdateStream(context.Background(), tenantID, streamIdentifier, update)
 }
 
 // DeleteStream soft-deletes a stream by setting deleted_at
 func DeleteStream(db *sql.DB, tenantID, streamIdentifier string) error {
+	return NewStore(db).DeleteStream(context.Background(), tenantID, streamIdentifier)
+}
+
+// ListDeletedStreams lists the soft-deleted streams of a tenant, most recently deleted first
+func ListDeletedStreams(db *sql.DB, tenantID string) ([]*models.Stream, error) {
+	return NewStore(db).ListDeletedStreams(context.Background(), tenantID)
+}
+
+// RestoreStream clears deleted_at on a soft-deleted stream.
+// Clients scoped to the stream were left alone by DeleteStream, so they work again once it is restored.
+func RestoreStream(db *sql.DB, tenantID, streamIdentifier string) (*models.Stream, error) {
+	return NewStore(db).RestoreStream(context.Background(), tenantID, streamIdentifier)
+}
+
+// PurgeStreams permanently removes streams that were soft-deleted more than
+// olderThan ago, together with the clients scoped to them. The clients have
+// to go first: the foreign key would otherwise set their stream_id to NULL
+// and turn them into tenant-wide clients.
+func PurgeStreams(db *sql.DB, tenantID string, olderThan time.Duration) ([]*models.Stream, int64, error) {
+	return NewStore(db).PurgeStreams(context.Background(), tenantID, olderThan)
+}
+
+// streamColumns is the column list scanned by scanStream
+const streamColumns = `id, tenant_id, name, description, status, retention_days, topic, created_at, updated_at, deleted_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanStream(row rowScanner) (*models.Stream, error) {
+	var stream models.Stream
+	err := row.Scan(
+		&stream.ID,
+		&stream.TenantID,
+		&stream.Name,
+		&stream.Description,
+		&stream.Status,
+		&stream.RetentionDays,
+		&stream.Topic,
+		&stream.CreatedAt,
+		&stream.UpdatedAt,
+		&stream.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &stream, nil
+}
+
+// queryStreams runs a query selecting streamColumns
+func (s *sqlStore) queryStreams(ctx context.Context, query string, args ...interface{}) ([]*models.Stream, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(err, "streams", "query streams")
+	}
+	defer rows.Close()
+
+	var streams []*models.Stream
+	for rows.Next() {
+		stream, err := scanStream(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stream: %w", err)
+		}
+		streams = append(streams, stream)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating streams: %w", err)
+	}
+
+	return streams, nil
+}
+
+func (s *sqlStore) CreateStream(ctx context.Context, tenantID, streamName, description string, retentionDays int) (*models.Stream, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if err := util.ValidateStreamName(streamName); err != nil {
+		return nil, err
+	}
+	normalizedDays, err := util.NormalizeRetentionDays(retentionDays)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := scanStream(s.q.QueryRowContext(ctx, `
+		INSERT INTO streams (tenant_id, name, description, retention_days, topic, status)
+		VALUES ($1, $2, $3, $4, $5, 'active')
+		RETURNING `+streamColumns+`
+	`, tenantID, streamName, description, normalizedDays, GenerateTopicName(tenantID, streamName)))
+	if err != nil {
+		switch pqCode(err) {
+		case codeUniqueViolation:
+			return nil, newError(ErrAlreadyExists, err, "stream '%s' already exists for this tenant", streamName)
+		case codeForeignKeyViolation:
+			return nil, newError(ErrNotFound, err, "tenant ID '%s' does not exist", tenantID)
+		}
+		return nil, queryError(err, "streams", "create stream")
+	}
+
+	return stream, nil
+}
+
+func (s *sqlStore) GetStream(ctx context.Context, tenantID, streamIdentifier string) (*models.Stream, error) {
+	column := "name"
+	if looksLikeUUID(streamIdentifier) {
+		column = "id"
+	}
+
+	stream, err := scanStream(s.q.QueryRowContext(ctx, `
+		SELECT `+streamColumns+`
+		FROM streams
+		WHERE `+column+` = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`, streamIdentifier, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, err, "stream '%s' not found", streamIdentifier)
+	}
+	if err != nil {
+		return nil, queryError(err, "streams", "get stream")
+	}
+
+	return stream, nil
+}
+
+func (s *sqlStore) ListStreams(ctx context.Context, tenantID string) ([]*models.Stream, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	return s.queryStreams(ctx, `
+		SELECT `+streamColumns+`
+		FROM streams
+		WHERE tenant_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, tenantID)
+}
+
+func (s *sqlStore) UpdateStream(ctx context.Context, tenantID, streamIdentifier string, update StreamUpdate) (*models.Stream, error) {
+	stream, err := s.GetStream(ctx, tenantID, streamIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Description != nil {
+		stream.Description = *update.Description
+	}
+	if update.RetentionDays != nil {
+		if *update.RetentionDays < 1 {
+			return nil, fmt.Errorf("retention days must be at least 1, got %d", *update.RetentionDays)
+		}
+		normalizedDays, err := util.NormalizeRetentionDays(*update.RetentionDays)
+		if err != nil {
+			return nil, err
+		}
+		stream.RetentionDays = normalizedDays
+	}
+	if update.Status != nil {
+		switch *update.Status {
+		case StreamStatusActive, StreamStatusPaused:
+			stream.Status = *update.Status
+		default:
+			return nil, fmt.Errorf("invalid stream status '%s' (expected %s or %s)", *update.Status, StreamStatusActive, StreamStatusPaused)
+		}
+	}
+
+	err = s.q.QueryRowContext(ctx, `
+		UPDATE streams
+		SET description = $1, retention_days = $2, status = $3, updated_at = NOW()
+		WHERE id = $4 AND tenant_id = $5 AND deleted_at IS NULL
+		RETURNING updated_at
+	`, stream.Description, stream.RetentionDays, stream.Status, stream.ID, tenantID).Scan(&stream.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update stream: %w", err)
+	}
+
+	return stream, nil
+}
+
+func (s *sqlStore) DeleteStream(ctx context.Context, tenantID, streamIdentifier string) error {
 	// First, verify the stream exists
-	stream, err := GetStream(db, tenantID, streamIdentifier)
+	stream, err := s.GetStream(ctx, tenantID, streamIdentifier)
 	if err != nil {
 		return err
 	}
 
 	// Soft delete by setting deleted_at
-	_, err = db.Exec(`
+	_, err = s.q.ExecContext(ctx, `
 		UPDATE streams
 		SET deleted_at = NOW(), updated_at = NOW()
 		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
 	`, stream.ID, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete stream: %w", err)
+	}
+
+	return nil
+}
+
+func (s *sqlStore) ListDeletedStreams(ctx context.Context, tenantID string) ([]*models.Stream, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	return s.queryStreams(ctx, `
+		SELECT `+streamColumns+`
+		FROM streams
+		WHERE tenant_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`, tenantID)
+}
+
+func (s *sqlStore) RestoreStream(ctx context.Context, tenantID, streamIdentifier string) (*models.Stream, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if streamIdentifier == "" {
+		return nil, fmt.Errorf("stream identifier cannot be empty")
+	}
+
+	column := "name"
+	if looksLikeUUID(streamIdentifier) {
+		column = "id"
+	}
+
+	stream, err := scanStream(s.q.QueryRowContext(ctx, `
+		UPDATE streams
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE `+column+` = $1 AND tenant_id = $2 AND deleted_at IS NOT NULL
+		RETURNING `+streamColumns+`
+	`, streamIdentifier, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, err, "deleted stream '%s' not found", streamIdentifier)
+	}
+	if err != nil {
+		return nil, queryError(err, "streams", "restore stream")
+	}
+
+	return stream, nil
+}
+
+func (s *sqlStore) PurgeStreams(ctx context.Context, tenantID string, olderThan time.Duration) ([]*models.Stream, int64, error) {
+	if tenantID == "" {
+		return nil, 0, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if olderThan < 0 {
+		return nil, 0, fmt.Errorf("purge age cannot be negative")
+	}
+	cutoff := time.Now().Add(-olderThan)
+
+	var streams []*models.Stream
+	var clientsDeleted int64
+	err := s.withTx(ctx, func(tx *sqlStore) error {
+		var err error
+		streams, err = tx.queryStreams(ctx, `
+			SELECT `+streamColumns+`
+			FROM streams
+			WHERE tenant_id = $1 AND deleted_at IS NOT NULL AND deleted_at < $2
+			ORDER BY deleted_at
+		`, tenantID, cutoff)
+		if err != nil {
+			return err
+		}
+
+		for _, stream := range streams {
+			res, err := tx.q.ExecContext(ctx, `DELETE FROM clients WHERE stream_id = $1 AND tenant_id = $2`, stream.ID, tenantID)
+			if err != nil {
+				return fmt.Errorf("failed to delete clients of stream '%s': %w", stream.Name, err)
+			}
+			n, _ := res.RowsAffected()
+			clientsDeleted += n
+
+			if _, err := tx.q.ExecContext(ctx, `DELETE FROM streams WHERE id = $1 AND tenant_id = $2`, stream.ID, tenantID); err != nil {
+				return fmt.Errorf("failed to purge stream '%s': %w", stream.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
 
-	return err
+	return streams, clientsDeleted, nil
 }
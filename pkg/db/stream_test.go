@@ -247,3 +247,118 @@ func TestListStreams_EdgeCases(t *testing.T) {
 		require.Len(t, streams, 0)
 	})
 }
+
+func TestUpdateStream(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	tenant, err := CreateOrGetTenant(db, "update-stream-tenant")
+	require.NoError(t, err)
+
+	_, err = CreateStream(db, tenant.ID, "update-api", "Before", 7)
+	require.NoError(t, err)
+
+	t.Run("update retention only", func(t *testing.T) {
+		days := 30
+		stream, err := UpdateStream(db, tenant.ID, "update-api", StreamUpdate{RetentionDays: &days})
+		require.NoError(t, err)
+		require.Equal(t, 30, stream.RetentionDays)
+		require.Equal(t, "Before", stream.Description)
+	})
+
+	t.Run("update description only", func(t *testing.T) {
+		description := "After"
+		stream, err := UpdateStream(db, tenant.ID, "update-api", StreamUpdate{Description: &description})
+		require.NoError(t, err)
+		require.Equal(t, "After", stream.Description)
+		require.Equal(t, 30, stream.RetentionDays)
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		status := StreamStatusPaused
+		stream, err := UpdateStream(db, tenant.ID, "update-api", StreamUpdate{Status: &status})
+		require.NoError(t, err)
+		require.Equal(t, "paused", stream.Status)
+
+		status = StreamStatusActive
+		stream, err = UpdateStream(db, tenant.ID, "update-api", StreamUpdate{Status: &status})
+		require.NoError(t, err)
+		require.Equal(t, "active", stream.Status)
+	})
+
+	t.Run("invalid status fails", func(t *testing.T) {
+		status := "archived"
+		_, err := UpdateStream(db, tenant.ID, "update-api", StreamUpdate{Status: &status})
+		require.Error(t, err)
+	})
+
+	t.Run("invalid retention fails", func(t *testing.T) {
+		days := 400
+		_, err := UpdateStream(db, tenant.ID, "update-api", StreamUpdate{RetentionDays: &days})
+		require.Error(t, err)
+	})
+
+	t.Run("zero retention fails instead of resetting to the default", func(t *testing.T) {
+		days := 0
+		_, err := UpdateStream(db, tenant.ID, "update-api", StreamUpdate{RetentionDays: &days})
+		require.ErrorContains(t, err, "at least 1")
+
+		stream, err := GetStream(db, tenant.ID, "update-api")
+		require.NoError(t, err)
+		require.Equal(t, 30, stream.RetentionDays)
+	})
+}
+
+func TestRestoreAndPurgeStreams(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	tenant, err := CreateOrGetTenant(db, "purge-tenant")
+	require.NoError(t, err)
+
+	t.Run("restore brings back a deleted stream", func(t *testing.T) {
+		_, err := CreateStream(db, tenant.ID, "restore-api", "", 7)
+		require.NoError(t, err)
+		require.NoError(t, DeleteStream(db, tenant.ID, "restore-api"))
+
+		deleted, err := ListDeletedStreams(db, tenant.ID)
+		require.NoError(t, err)
+		require.Len(t, deleted, 1)
+		require.NotNil(t, deleted[0].DeletedAt)
+
+		stream, err := RestoreStream(db, tenant.ID, deleted[0].ID)
+		require.NoError(t, err)
+		require.Nil(t, stream.DeletedAt)
+
+		_, err = GetStream(db, tenant.ID, "restore-api")
+		require.NoError(t, err)
+
+		_, err = RestoreStream(db, tenant.ID, "restore-api")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+
+	t.Run("purge removes old streams and their clients", func(t *testing.T) {
+		stream, err := CreateStream(db, tenant.ID, "purge-api", "", 7)
+		require.NoError(t, err)
+		_, err = CreateClient(db, tenant.ID, "purge-client", "purge-secret-123", &stream.ID)
+		require.NoError(t, err)
+		require.NoError(t, DeleteStream(db, tenant.ID, "purge-api"))
+
+		// Nothing is older than an hour yet
+		purged, _, err := PurgeStreams(db, tenant.ID, time.Hour)
+		require.NoError(t, err)
+		require.Empty(t, purged)
+
+		purged, clients, err := PurgeStreams(db, tenant.ID, 0)
+		require.NoError(t, err)
+		require.Len(t, purged, 1)
+		require.Equal(t, "purge-api", purged[0].Name)
+		require.Equal(t, int64(1), clients)
+
+		_, err = GetClient(db, tenant.ID, "purge-client")
+		require.Error(t, err)
+
+		// The name is free again
+		_, err = CreateStream(db, tenant.ID, "purge-api", "", 7)
+		require.NoError(t, err)
+	})
+}
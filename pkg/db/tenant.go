@@ -0,0 +1,241 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/frkr-io/frkr-common/models"
+)
+
+// CreateOrGetTenant creates a tenant or returns existing one
+func CreateOrGetTenant(db *sql.DB, name string) (*models.Tenant, error) {
+	return NewStore(db).CreateOrGetTenant(context.Background(), name)
+}
+
+// GetTenantByName retrieves an active tenant by name without creating it.
+// A missing tenant is reported with an error wrapping ErrNotFound and
+// sql.ErrNoRows.
+func GetTenantByName(db *sql.DB, name string) (*models.Tenant, error) {
+	return NewStore(db).GetTenantByName(context.Background(), name)
+}
+
+// ListTenants lists all active tenants, ordered by name
+func ListTenants(db *sql.DB) ([]*models.Tenant, error) {
+	return NewStore(db).ListTenants(context.Background())
+}
+
+// RenameTenant changes a tenant's name. Tenant IDs, and therefore topic
+// names, are unaffected.
+func RenameTenant(db *sql.DB, oldName, newName string) (*models.Tenant, error) {
+	return NewStore(db).RenameTenant(context.Background(), oldName, newName)
+}
+
+// TenantContents counts the active objects that belong to a tenant
+type TenantContents struct {
+	Streams int64
+	Users   int64
+	Clients int64
+}
+
+// Empty reports whether the tenant has no active objects
+func (c TenantContents) Empty() bool {
+	return c.Streams == 0 && c.Users == 0 && c.Clients == 0
+}
+
+// DeleteTenant soft-deletes a tenant. A tenant that still has active
+// streams, users or clients is only deleted with cascade, which soft-deletes
+// its streams, disables its users and revokes its clients in the same
+// transaction. The returned counts are what was active before the delete.
+func DeleteTenant(db *sql.DB, name string, cascade bool) (*models.Tenant, TenantContents, error) {
+	return NewStore(db).DeleteTenant(context.Background(), name, cascade)
+}
+
+// tenantColumns is the column list scanned by scanTenant
+const tenantColumns = `id, name, plan, created_at, updated_at, deleted_at`
+
+func scanTenant(row rowScanner) (*models.Tenant, error) {
+	var tenant models.Tenant
+	err := row.Scan(
+		&tenant.ID,
+		&tenant.Name,
+		&tenant.Plan,
+		&tenant.CreatedAt,
+		&tenant.UpdatedAt,
+		&tenant.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func validateTenantName(name string) error {
+	if name == "" {
+		return fmt.Errorf("tenant name cannot be empty")
+	}
+	if len(name) > 100 {
+		return fmt.Errorf("tenant name cannot exceed 100 characters")
+	}
+	return nil
+}
+
+func (s *sqlStore) CreateOrGetTenant(ctx context.Context, name string) (*models.Tenant, error) {
+	if err := validateTenantName(name); err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.GetTenantByName(ctx, name)
+	if !errors.Is(err, ErrNotFound) {
+		return tenant, err
+	}
+
+	tenant, err = scanTenant(s.q.QueryRowContext(ctx, `
+		INSERT INTO tenants (name, plan)
+		VALUES ($1, 'free')
+		RETURNING `+tenantColumns+`
+	`, name))
+	if err != nil {
+		return nil, queryError(err, "tenants", "create tenant")
+	}
+
+	return tenant, nil
+}
+
+func (s *sqlStore) GetTenantByName(ctx context.Context, name string) (*models.Tenant, error) {
+	if name == "" {
+		return nil, fmt.Errorf("tenant name cannot be empty")
+	}
+
+	tenant, err := scanTenant(s.q.QueryRowContext(ctx, `
+		SELECT `+tenantColumns+`
+		FROM tenants
+		WHERE name = $1 AND deleted_at IS NULL
+	`, name))
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, err, "tenant '%s' not found", name)
+	}
+	if err != nil {
+		return nil, queryError(err, "tenants", "query tenant")
+	}
+
+	return tenant, nil
+}
+
+func (s *sqlStore) ListTenants(ctx context.Context) ([]*models.Tenant, error) {
+	rows, err := s.q.QueryContext(ctx, `
+		SELECT `+tenantColumns+`
+		FROM tenants
+		WHERE deleted_at IS NULL
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, queryError(err, "tenants", "query tenants")
+	}
+	defer rows.Close()
+
+	var tenants []*models.Tenant
+	for rows.Next() {
+		tenant, err := scanTenant(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, tenant)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+func (s *sqlStore) RenameTenant(ctx context.Context, oldName, newName string) (*models.Tenant, error) {
+	if err := validateTenantName(newName); err != nil {
+		return nil, err
+	}
+
+	tenant, err := s.GetTenantByName(ctx, oldName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tenants table has no unique constraint on name, so check here
+	if _, err := s.GetTenantByName(ctx, newName); err == nil {
+		return nil, newError(ErrAlreadyExists, nil, "tenant '%s' already exists", newName)
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	err = s.q.QueryRowContext(ctx, `
+		UPDATE tenants
+		SET name = $1, updated_at = NOW()
+		WHERE id = $2 AND deleted_at IS NULL
+		RETURNING name, updated_at
+	`, newName, tenant.ID).Scan(&tenant.Name, &tenant.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename tenant: %w", err)
+	}
+
+	return tenant, nil
+}
+
+func (s *sqlStore) DeleteTenant(ctx context.Context, name string, cascade bool) (*models.Tenant, TenantContents, error) {
+	var tenant *models.Tenant
+	var contents TenantContents
+
+	err := s.withTx(ctx, func(tx *sqlStore) error {
+		var err error
+		tenant, err = tx.GetTenantByName(ctx, name)
+		if err != nil {
+			return err
+		}
+
+		counts := []struct {
+			table string
+			dest  *int64
+		}{
+			{"streams", &contents.Streams},
+			{"users", &contents.Users},
+			{"clients", &contents.Clients},
+		}
+		for _, c := range counts {
+			if err := tx.q.QueryRowContext(ctx, `
+				SELECT COUNT(*) FROM `+c.table+`
+				WHERE tenant_id = $1 AND deleted_at IS NULL
+			`, tenant.ID).Scan(c.dest); err != nil {
+				return queryError(err, c.table, "count "+c.table)
+			}
+		}
+
+		if !contents.Empty() && !cascade {
+			return fmt.Errorf("tenant '%s' still has %d stream(s), %d user(s) and %d client(s)",
+				name, contents.Streams, contents.Users, contents.Clients)
+		}
+
+		for _, c := range counts {
+			if _, err := tx.q.ExecContext(ctx, `
+				UPDATE `+c.table+`
+				SET deleted_at = NOW(), updated_at = NOW()
+				WHERE tenant_id = $1 AND deleted_at IS NULL
+			`, tenant.ID); err != nil {
+				return fmt.Errorf("failed to delete %s: %w", c.table, err)
+			}
+		}
+
+		if _, err := tx.q.ExecContext(ctx, `
+			UPDATE tenants
+			SET deleted_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND deleted_at IS NULL
+		`, tenant.ID); err != nil {
+			return fmt.Errorf("failed to delete tenant: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, contents, err
+	}
+
+	return tenant, contents, nil
+}
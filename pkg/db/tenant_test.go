@@ -0,0 +1,95 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetTenantByName(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	t.Run("missing tenant is not created", func(t *testing.T) {
+		_, err := GetTenantByName(db, "missing-tenant")
+		require.Error(t, err)
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+
+		_, err = GetTenantByName(db, "missing-tenant")
+		require.Error(t, err)
+	})
+
+	t.Run("existing tenant", func(t *testing.T) {
+		created, err := CreateOrGetTenant(db, "lookup-tenant")
+		require.NoError(t, err)
+
+		tenant, err := GetTenantByName(db, "lookup-tenant")
+		require.NoError(t, err)
+		require.Equal(t, created.ID, tenant.ID)
+	})
+}
+
+func TestRenameTenant(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	tenant, err := CreateOrGetTenant(db, "rename-old")
+	require.NoError(t, err)
+	_, err = CreateOrGetTenant(db, "rename-taken")
+	require.NoError(t, err)
+
+	t.Run("name already in use", func(t *testing.T) {
+		_, err := RenameTenant(db, "rename-old", "rename-taken")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "already exists")
+	})
+
+	t.Run("rename keeps the ID", func(t *testing.T) {
+		renamed, err := RenameTenant(db, "rename-old", "rename-new")
+		require.NoError(t, err)
+		require.Equal(t, tenant.ID, renamed.ID)
+		require.Equal(t, "rename-new", renamed.Name)
+
+		_, err = GetTenantByName(db, "rename-old")
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+	})
+}
+
+func TestDeleteTenant(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	tenant, err := CreateOrGetTenant(db, "delete-tenant")
+	require.NoError(t, err)
+	stream, err := CreateStream(db, tenant.ID, "delete-api", "", 7)
+	require.NoError(t, err)
+	_, err = CreateUser(db, tenant.ID, "delete-user", "delete-pass-123")
+	require.NoError(t, err)
+	_, err = CreateClient(db, tenant.ID, "delete-client", "delete-secret-123", &stream.ID)
+	require.NoError(t, err)
+
+	t.Run("non-empty tenant requires cascade", func(t *testing.T) {
+		_, contents, err := DeleteTenant(db, "delete-tenant", false)
+		require.Error(t, err)
+		require.Equal(t, TenantContents{Streams: 1, Users: 1, Clients: 1}, contents)
+
+		_, err = GetTenantByName(db, "delete-tenant")
+		require.NoError(t, err)
+	})
+
+	t.Run("cascade", func(t *testing.T) {
+		deleted, contents, err := DeleteTenant(db, "delete-tenant", true)
+		require.NoError(t, err)
+		require.Equal(t, tenant.ID, deleted.ID)
+		require.Equal(t, TenantContents{Streams: 1, Users: 1, Clients: 1}, contents)
+
+		_, err = GetTenantByName(db, "delete-tenant")
+		require.True(t, errors.Is(err, sql.ErrNoRows))
+
+		_, err = GetStream(db, tenant.ID, "delete-api")
+		require.Error(t, err)
+		_, err = GetUser(db, tenant.ID, "delete-user")
+		require.Error(t, err)
+		_, err = GetClient(db, tenant.ID, "delete-client")
+		require.Error(t, err)
+	})
+}
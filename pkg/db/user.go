@@ -1,10 +1,15 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"strings"
 
 	commondb "github.com/frkr-io/frkr-common/db"
 	"github.com/frkr-io/frkr-common/models"
+	"github.com/frkr-io/frkr-common/util"
+	"golang.org/x/crypto/bcrypt"
 )
 
 // TenantUser aliases the common model
@@ -12,17 +17,17 @@ type TenantUser = models.TenantUser
 
 // CreateUser creates a new user for a tenant
 func CreateUser(db *sql.DB, tenantID, username, password string) (*models.TenantUser, error) {
-	return commondb.CreateUser(db, tenantID, username, password)
+	return NewStore(db).CreateUser(context.Background(), tenantID, username, password)
 }
 
 // GetUser retrieves a user by username or ID
 func GetUser(db *sql.DB, tenantID, userIdentifier string) (*models.TenantUser, error) {
-	return commondb.GetUser(db, tenantID, userIdentifier)
+	return NewStore(db).GetUser(context.Background(), tenantID, userIdentifier)
 }
 
 // ListUsers lists all users for a tenant
 func ListUsers(db *sql.DB, tenantID string) ([]*models.TenantUser, error) {
-	return commondb.ListUsers(db, tenantID)
+	return NewStore(db).ListUsers(context.Background(), tenantID)
 }
 
 // VerifyPassword verifies a password against a user's password hash
@@ -30,3 +35,272 @@ func VerifyPassword(user *models.TenantUser, password string) error {
 	return commondb.VerifyPassword(user, password)
 }
 
+// ListAllUsers lists all users for a tenant, including disabled ones
+func ListAllUsers(db *sql.DB, tenantID string) ([]*models.TenantUser, error) {
+	return NewStore(db).ListAllUsers(context.Background(), tenantID)
+}
+
+// ResetPassword replaces a user's password hash with a hash of the given password
+func ResetPassword(db *sql.DB, tenantID, userIdentifier, password string) (*models.TenantUser, error) {
+	return NewStore(db).ResetPassword(context.Background(), tenantID, userIdentifier, password)
+}
+
+// DisableUser soft-deletes a user by setting deleted_at.
+// The gateways only authenticate users whose deleted_at is NULL, so a disabled
+// user can no longer ingest or stream until re-enabled.
+func DisableUser(db *sql.DB, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	return NewStore(db).DisableUser(context.Background(), tenantID, userIdentifier)
+}
+
+// EnableUser clears deleted_at on a previously disabled user
+func EnableUser(db *sql.DB, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	return NewStore(db).EnableUser(context.Background(), tenantID, userIdentifier)
+}
+
+// DeleteUser permanently removes a user, whether active or disabled.
+// Usernames are unique per tenant even across disabled users, so a hard delete
+// is what frees the username for reuse.
+func DeleteUser(db *sql.DB, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	return NewStore(db).DeleteUser(context.Background(), tenantID, userIdentifier)
+}
+
+// userColumns is the column list scanned by scanUser
+const userColumns = `id, tenant_id, username, password_hash, created_at, updated_at, deleted_at`
+
+func scanUser(row rowScanner) (*models.TenantUser, error) {
+	var user models.TenantUser
+	err := row.Scan(
+		&user.ID,
+		&user.TenantID,
+		&user.Username,
+		&user.PasswordHash,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func validatePassword(password string) error {
+	if password == "" {
+		return fmt.Errorf("password cannot be empty")
+	}
+	if len(password) < 8 {
+		return fmt.Errorf("password must be at least 8 characters")
+	}
+	return nil
+}
+
+// queryUsers runs a query selecting userColumns
+func (s *sqlStore) queryUsers(ctx context.Context, query string, args ...interface{}) ([]*models.TenantUser, error) {
+	rows, err := s.q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(err, "users", "query users")
+	}
+	defer rows.Close()
+
+	var users []*models.TenantUser
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating users: %w", err)
+	}
+
+	return users, nil
+}
+
+// getUser retrieves a user by username or ID, including disabled users if anyState is set
+func (s *sqlStore) getUser(ctx context.Context, tenantID, userIdentifier string, anyState bool) (*models.TenantUser, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if userIdentifier == "" {
+		return nil, fmt.Errorf("user identifier cannot be empty")
+	}
+
+	column := "username"
+	if looksLikeUUID(userIdentifier) {
+		column = "id"
+	}
+	filter := " AND deleted_at IS NULL"
+	if anyState {
+		filter = ""
+	}
+
+	user, err := scanUser(s.q.QueryRowContext(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE `+column+` = $1 AND tenant_id = $2`+filter+`
+	`, userIdentifier, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, newError(ErrNotFound, err, "user '%s' not found", userIdentifier)
+	}
+	if err != nil {
+		return nil, queryError(err, "users", "get user")
+	}
+
+	return user, nil
+}
+
+func (s *sqlStore) CreateUser(ctx context.Context, tenantID, username, password string) (*models.TenantUser, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+	if err := util.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	user, err := scanUser(s.q.QueryRowContext(ctx, `
+		INSERT INTO users (tenant_id, username, password_hash)
+		VALUES ($1, $2, $3)
+		RETURNING `+userColumns+`
+	`, tenantID, username, string(passwordHash)))
+	if err != nil {
+		switch pqCode(err) {
+		case codeUniqueViolation:
+			return nil, newError(ErrAlreadyExists, err, "username '%s' already exists for this tenant", username)
+		case codeForeignKeyViolation:
+			return nil, newError(ErrNotFound, err, "tenant ID '%s' does not exist", tenantID)
+		}
+		return nil, queryError(err, "users", "create user")
+	}
+
+	return user, nil
+}
+
+func (s *sqlStore) GetUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	return s.getUser(ctx, tenantID, userIdentifier, false)
+}
+
+func (s *sqlStore) ListUsers(ctx context.Context, tenantID string) ([]*models.TenantUser, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	return s.queryUsers(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE tenant_id = $1 AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`, tenantID)
+}
+
+func (s *sqlStore) ListAllUsers(ctx context.Context, tenantID string) ([]*models.TenantUser, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty")
+	}
+
+	return s.queryUsers(ctx, `
+		SELECT `+userColumns+`
+		FROM users
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC
+	`, tenantID)
+}
+
+func (s *sqlStore) ResetPassword(ctx context.Context, tenantID, userIdentifier, password string) (*models.TenantUser, error) {
+	if err := validatePassword(password); err != nil {
+		return nil, err
+	}
+
+	user, err := s.GetUser(ctx, tenantID, userIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	_, err = s.q.ExecContext(ctx, `
+		UPDATE users
+		SET password_hash = $1, updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $3 AND deleted_at IS NULL
+	`, string(passwordHash), user.ID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	user.PasswordHash = string(passwordHash)
+	return user, nil
+}
+
+func (s *sqlStore) DisableUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	user, err := s.GetUser(ctx, tenantID, userIdentifier)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.q.ExecContext(ctx, `
+		UPDATE users
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL
+	`, user.ID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to disable user: %w", err)
+	}
+
+	return user, nil
+}
+
+func (s *sqlStore) EnableUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	user, err := s.getUser(ctx, tenantID, userIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+	if user.DeletedAt == nil || !user.DeletedAt.Valid {
+		return nil, fmt.Errorf("user '%s' is not disabled", userIdentifier)
+	}
+
+	_, err = s.q.ExecContext(ctx, `
+		UPDATE users
+		SET deleted_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND tenant_id = $2
+	`, user.ID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable user: %w", err)
+	}
+
+	user.DeletedAt = nil
+	return user, nil
+}
+
+func (s *sqlStore) DeleteUser(ctx context.Context, tenantID, userIdentifier string) (*models.TenantUser, error) {
+	user, err := s.getUser(ctx, tenantID, userIdentifier, true)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.q.ExecContext(ctx, `
+		DELETE FROM users
+		WHERE id = $1 AND tenant_id = $2
+	`, user.ID, tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	return user, nil
+}
+
+// looksLikeUUID mirrors the ID-vs-name heuristic used by frkr-common lookups
+func looksLikeUUID(identifier string) bool {
+	return len(identifier) == 36 && strings.Contains(identifier, "-")
+}
@@ -327,3 +327,80 @@ func TestVerifyPassword(t *testing.T) {
 		require.Contains(t, err.Error(), "cannot be nil")
 	})
 }
+
+func TestUserLifecycle(t *testing.T) {
+	db, _ := setupTestDB(t)
+
+	tenant, err := CreateOrGetTenant(db, "lifecycle-user-tenant")
+	require.NoError(t, err)
+
+	user, err := CreateUser(db, tenant.ID, "lifecycle-user", "password123")
+	require.NoError(t, err)
+
+	t.Run("reset password", func(t *testing.T) {
+		updated, err := ResetPassword(db, tenant.ID, "lifecycle-user", "new-password-456")
+		require.NoError(t, err)
+		require.Equal(t, user.ID, updated.ID)
+
+		found, err := GetUser(db, tenant.ID, user.ID)
+		require.NoError(t, err)
+		require.NoError(t, VerifyPassword(found, "new-password-456"))
+		require.Error(t, VerifyPassword(found, "password123"))
+	})
+
+	t.Run("reset password rejects short password", func(t *testing.T) {
+		_, err := ResetPassword(db, tenant.ID, "lifecycle-user", "short")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at least 8 characters")
+	})
+
+	t.Run("disable hides user from active lookups", func(t *testing.T) {
+		_, err := DisableUser(db, tenant.ID, "lifecycle-user")
+		require.NoError(t, err)
+
+		_, err = GetUser(db, tenant.ID, "lifecycle-user")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+
+		active, err := ListUsers(db, tenant.ID)
+		require.NoError(t, err)
+		require.Len(t, active, 0)
+
+		all, err := ListAllUsers(db, tenant.ID)
+		require.NoError(t, err)
+		require.Len(t, all, 1)
+		require.NotNil(t, all[0].DeletedAt)
+	})
+
+	t.Run("enable restores user", func(t *testing.T) {
+		_, err := EnableUser(db, tenant.ID, "lifecycle-user")
+		require.NoError(t, err)
+
+		found, err := GetUser(db, tenant.ID, "lifecycle-user")
+		require.NoError(t, err)
+		require.Equal(t, user.ID, found.ID)
+	})
+
+	t.Run("enable active user fails", func(t *testing.T) {
+		_, err := EnableUser(db, tenant.ID, "lifecycle-user")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not disabled")
+	})
+
+	t.Run("delete frees username", func(t *testing.T) {
+		_, err := DeleteUser(db, tenant.ID, user.ID)
+		require.NoError(t, err)
+
+		_, err = GetUser(db, tenant.ID, "lifecycle-user")
+		require.Error(t, err)
+
+		_, err = CreateUser(db, tenant.ID, "lifecycle-user", "password123")
+		require.NoError(t, err)
+	})
+
+	t.Run("delete non-existent user fails", func(t *testing.T) {
+		_, err := DeleteUser(db, tenant.ID, "non-existent")
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "not found")
+	})
+}
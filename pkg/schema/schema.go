@@ -0,0 +1,208 @@
+// Package schema inspects and moves the version of the frkr database schema.
+// It wraps golang-migrate with the migrations embedded in frkr-common.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/frkr-io/frkr-common/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/cockroachdb" // CockroachDB driver registration
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"    // PostgreSQL driver registration
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// MigrateURL returns the URL golang-migrate should use for dbURL.
+// CockroachDB speaks the postgres protocol but has no pg_advisory_lock, which
+// the postgres driver needs, so a postgres:// or postgresql:// URL that
+// points at CockroachDB is switched to the cockroachdb:// driver. conn must be
+// open on dbURL.
+func MigrateURL(ctx context.Context, conn *sql.DB, dbURL string) string {
+	scheme, rest, ok := strings.Cut(dbURL, "://")
+	if !ok || (scheme != "postgres" && scheme != "postgresql") {
+		return dbURL
+	}
+
+	var hasAdvisoryLock bool
+	err := conn.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM pg_proc WHERE proname = 'pg_advisory_lock')").Scan(&hasAdvisoryLock)
+	if err == nil && !hasAdvisoryLock {
+		return "cockroachdb://" + rest
+	}
+	return dbURL
+}
+
+// Migration is one migration shipped with frkr-common
+type Migration struct {
+	Version uint
+	Name    string
+}
+
+// Available lists the embedded migrations in version order
+func Available() ([]Migration, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+	defer src.Close()
+
+	var list []Migration
+	version, err := src.First()
+	for err == nil {
+		m, readErr := readMigration(src, version)
+		if readErr != nil {
+			return nil, readErr
+		}
+		list = append(list, m)
+		version, err = src.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	return list, nil
+}
+
+func readMigration(src source.Driver, version uint) (Migration, error) {
+	r, name, err := src.ReadUp(version)
+	if err != nil {
+		return Migration{}, fmt.Errorf("failed to read migration %d: %w", version, err)
+	}
+	r.Close()
+	return Migration{Version: version, Name: name}, nil
+}
+
+// Status is the migration state of a database
+type Status struct {
+	// Version is the last applied migration; 0 when none has been applied
+	Version uint
+	// Dirty means migration Version failed part-way and must be fixed by
+	// hand, then marked with Force
+	Dirty bool
+	// Pending are the available migrations above Version
+	Pending []Migration
+}
+
+// Pending returns the migrations in available that come after version
+func Pending(available []Migration, version uint) []Migration {
+	var pending []Migration
+	for _, m := range available {
+		if m.Version > version {
+			pending = append(pending, m)
+		}
+	}
+	return pending
+}
+
+// Migrator runs migrations against one database
+type Migrator struct {
+	m *migrate.Migrate
+}
+
+// NewMigrator opens a Migrator for migrateURL, which must name the driver to
+// use (see MigrateURL)
+func NewMigrator(migrateURL string) (*Migrator, error) {
+	src, err := iofs.New(migrations.FS, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, migrateURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+
+	return &Migrator{m: m}, nil
+}
+
+// Close releases the Migrator's database connection
+func (mg *Migrator) Close() error {
+	srcErr, dbErr := mg.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// Version returns the current version and dirty flag. A database without any
+// applied migration is at version 0.
+func (mg *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := mg.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read migration version: %w", err)
+	}
+	return version, dirty, nil
+}
+
+// Status reports the current version, dirty flag and pending migrations
+func (mg *Migrator) Status() (*Status, error) {
+	version, dirty, err := mg.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	available, err := Available()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Status{Version: version, Dirty: dirty, Pending: Pending(available, version)}, nil
+}
+
+// Up applies the next n migrations, or all pending migrations when n is 0.
+// Being already up to date is not an error.
+func (mg *Migrator) Up(n int) error {
+	if n < 0 {
+		return fmt.Errorf("number of migrations must not be negative")
+	}
+
+	var err error
+	if n == 0 {
+		err = mg.m.Up()
+	} else {
+		err = mg.m.Steps(n)
+	}
+	return ignoreNoChange(err)
+}
+
+// Down rolls back the last n migrations. n must be at least 1; there is no
+// "roll back everything" shortcut since that drops every frkr table.
+func (mg *Migrator) Down(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("number of migrations must be at least 1")
+	}
+	return ignoreNoChange(mg.m.Steps(-n))
+}
+
+// Goto migrates up or down to version
+func (mg *Migrator) Goto(version uint) error {
+	return ignoreNoChange(mg.m.Migrate(version))
+}
+
+// Force sets the version without running any migration and clears the dirty
+// flag. Use it after fixing a half-applied migration by hand. A version of -1
+// marks the database as having no migrations applied.
+func (mg *Migrator) Force(version int) error {
+	if version < -1 {
+		return fmt.Errorf("version must be -1 or greater")
+	}
+	if err := mg.m.Force(version); err != nil {
+		return fmt.Errorf("failed to force version %d: %w", version, err)
+	}
+	return nil
+}
+
+func ignoreNoChange(err error) error {
+	if err == nil || errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return fmt.Errorf("migration failed: %w", err)
+}
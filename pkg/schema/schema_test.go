@@ -0,0 +1,102 @@
+package schema
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	commonDB "github.com/frkr-io/frkr-common/db"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailable(t *testing.T) {
+	available, err := Available()
+	require.NoError(t, err)
+	require.NotEmpty(t, available)
+
+	for i, m := range available {
+		require.NotEmpty(t, m.Name)
+		if i > 0 {
+			require.Greater(t, m.Version, available[i-1].Version)
+		}
+	}
+}
+
+func TestPending(t *testing.T) {
+	available := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}}
+
+	require.Equal(t, available, Pending(available, 0))
+	require.Equal(t, []Migration{{Version: 3, Name: "c"}}, Pending(available, 2))
+	require.Empty(t, Pending(available, 3))
+}
+
+func TestMigrateURLOtherSchemes(t *testing.T) {
+	// URLs that already name a driver are returned without querying the database
+	for _, dbURL := range []string{"cockroachdb://root@localhost:26257/frkrdb", "pgx://root@localhost/frkrdb", "not a url"} {
+		require.Equal(t, dbURL, MigrateURL(context.Background(), nil, dbURL))
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	conn, dbURL := commonDB.SetupTestDB(t)
+
+	migrateURL := MigrateURL(context.Background(), conn, dbURL)
+	require.True(t, strings.HasPrefix(migrateURL, "cockroachdb://"), "CockroachDB should use the cockroachdb driver")
+
+	postgresqlURL := "postgresql://" + strings.TrimPrefix(dbURL, "postgres://")
+	require.Equal(t, migrateURL, MigrateURL(context.Background(), conn, postgresqlURL),
+		"postgresql:// URLs should be switched to the cockroachdb driver too")
+
+	mg, err := NewMigrator(migrateURL)
+	require.NoError(t, err)
+	defer mg.Close()
+
+	available, err := Available()
+	require.NoError(t, err)
+	latest := available[len(available)-1].Version
+
+	status, err := mg.Status()
+	require.NoError(t, err)
+	require.Equal(t, latest, status.Version)
+	require.False(t, status.Dirty)
+	require.Empty(t, status.Pending)
+
+	t.Run("down and up", func(t *testing.T) {
+		require.NoError(t, mg.Down(1))
+		status, err := mg.Status()
+		require.NoError(t, err)
+		require.Equal(t, available[len(available)-2].Version, status.Version)
+		require.Equal(t, available[len(available)-1:], status.Pending)
+
+		require.NoError(t, mg.Up(0))
+		require.NoError(t, mg.Up(0), "being up to date is not an error")
+		version, _, err := mg.Version()
+		require.NoError(t, err)
+		require.Equal(t, latest, version)
+	})
+
+	t.Run("goto", func(t *testing.T) {
+		require.NoError(t, mg.Goto(available[0].Version))
+		status, err := mg.Status()
+		require.NoError(t, err)
+		require.Len(t, status.Pending, len(available)-1)
+
+		require.NoError(t, mg.Goto(latest))
+	})
+
+	t.Run("force", func(t *testing.T) {
+		require.NoError(t, mg.Force(int(available[0].Version)))
+		version, dirty, err := mg.Version()
+		require.NoError(t, err)
+		require.Equal(t, available[0].Version, version)
+		require.False(t, dirty)
+
+		require.NoError(t, mg.Force(int(latest)))
+	})
+
+	t.Run("invalid counts", func(t *testing.T) {
+		require.Error(t, mg.Down(0))
+		require.Error(t, mg.Up(-1))
+		require.Error(t, mg.Force(-2))
+	})
+}
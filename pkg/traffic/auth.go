@@ -0,0 +1,124 @@
+package traffic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin is how long before it expires a token is replaced
+const tokenExpiryMargin = 30 * time.Second
+
+// TokenSource provides bearer tokens
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource that always returns the same token
+type StaticToken string
+
+// Token returns the token
+func (t StaticToken) Token(context.Context) (string, error) { return string(t), nil }
+
+// CommandToken is a TokenSource that runs a shell command once and uses its
+// output, e.g. "gcloud auth print-identity-token"
+type CommandToken struct {
+	Command string
+
+	once  sync.Once
+	token string
+	err   error
+}
+
+// Token runs the command the first time it is called
+func (c *CommandToken) Token(ctx context.Context) (string, error) {
+	c.once.Do(func() {
+		out, err := exec.CommandContext(ctx, "sh", "-c", c.Command).Output()
+		if err != nil {
+			c.err = fmt.Errorf("token command failed: %w", err)
+			return
+		}
+		c.token = strings.TrimSpace(string(out))
+		if c.token == "" {
+			c.err = fmt.Errorf("token command printed nothing")
+		}
+	})
+	return c.token, c.err
+}
+
+// ClientCredentials is a TokenSource that fetches tokens from an OAuth 2.0
+// token endpoint with the client credentials grant, and fetches a new one
+// shortly before the last one expires
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	Client       *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// Token returns a cached token, or fetches a new one
+func (c *ClientCredentials) Token(ctx context.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.token != "" && (c.expiry.IsZero() || time.Now().Add(tokenExpiryMargin).Before(c.expiry)) {
+		return c.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if c.Scope != "" {
+		form.Set("scope", c.Scope)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("invalid token URL: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var tok struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &tok); err != nil || tok.AccessToken == "" {
+		return "", fmt.Errorf("token endpoint returned no access_token")
+	}
+
+	c.token = tok.AccessToken
+	c.expiry = time.Time{}
+	if tok.ExpiresIn > 0 {
+		c.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	}
+	return c.token, nil
+}
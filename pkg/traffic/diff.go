@@ -0,0 +1,243 @@
+package traffic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+)
+
+// Difference kinds
+const (
+	DiffError  = "error"
+	DiffStatus = "status"
+	DiffHeader = "header"
+	DiffBody   = "body"
+)
+
+// maxBodyDifferences bounds the body differences reported for one request
+const maxBodyDifferences = 20
+
+// DefaultIgnoredHeaders are response headers that differ between any two
+// responses and are never compared
+var DefaultIgnoredHeaders = []string{
+	"Age",
+	"Connection",
+	"Content-Length",
+	"Date",
+	"Etag",
+	"Expires",
+	"Keep-Alive",
+	"Last-Modified",
+	"Set-Cookie",
+	"Transfer-Encoding",
+	"X-Request-Id",
+}
+
+// DiffOptions controls what Compare compares
+type DiffOptions struct {
+	// CompareHeaders enables comparing response headers
+	CompareHeaders bool
+	// IgnoreHeaders are ignored in addition to DefaultIgnoredHeaders
+	IgnoreHeaders []string
+	// IgnoreFields are removed from JSON bodies before they are compared
+	IgnoreFields []JSONPath
+}
+
+// Difference is one way in which a candidate response differs from the
+// baseline response to the same request
+type Difference struct {
+	Kind string `json:"kind" yaml:"kind"`
+	// Path is the header name or the JSONPath of a body field
+	Path      string `json:"path,omitempty" yaml:"path,omitempty"`
+	Baseline  string `json:"baseline" yaml:"baseline"`
+	Candidate string `json:"candidate" yaml:"candidate"`
+}
+
+func (d Difference) String() string {
+	label := d.Kind
+	if d.Path != "" {
+		label += " " + d.Path
+	}
+	return fmt.Sprintf("%s: %s != %s", label, d.Baseline, d.Candidate)
+}
+
+// Compare returns the differences between the baseline and candidate
+// responses to one request. A request that failed on either side is a
+// single error difference.
+func Compare(baseline, candidate Result, opts DiffOptions) []Difference {
+	if baseline.Err != nil || candidate.Err != nil {
+		return []Difference{{Kind: DiffError, Baseline: errString(baseline.Err), Candidate: errString(candidate.Err)}}
+	}
+
+	var diffs []Difference
+	if baseline.Status != candidate.Status {
+		diffs = append(diffs, Difference{Kind: DiffStatus, Baseline: strconv.Itoa(baseline.Status), Candidate: strconv.Itoa(candidate.Status)})
+	}
+	if opts.CompareHeaders {
+		diffs = append(diffs, compareHeaders(baseline.Header, candidate.Header, opts.IgnoreHeaders)...)
+	}
+	return append(diffs, compareBodies(baseline.Body, candidate.Body, opts.IgnoreFields)...)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return "(no error)"
+	}
+	return err.Error()
+}
+
+func compareHeaders(baseline, candidate http.Header, ignore []string) []Difference {
+	ignored := make(map[string]bool)
+	for _, name := range DefaultIgnoredHeaders {
+		ignored[name] = true
+	}
+	for _, name := range ignore {
+		ignored[http.CanonicalHeaderKey(name)] = true
+	}
+
+	names := make(map[string]int)
+	for name := range baseline {
+		names[name]++
+	}
+	for name := range candidate {
+		names[name]++
+	}
+
+	var diffs []Difference
+	for _, name := range SortedKeys(names) {
+		if ignored[name] {
+			continue
+		}
+		b, c := headerValue(baseline, name), headerValue(candidate, name)
+		if b != c {
+			diffs = append(diffs, Difference{Kind: DiffHeader, Path: name, Baseline: b, Candidate: c})
+		}
+	}
+	return diffs
+}
+
+func headerValue(h http.Header, name string) string {
+	values, ok := h[name]
+	if !ok {
+		return "(missing)"
+	}
+	return fmt.Sprint(values)
+}
+
+// compareBodies compares two bodies as JSON if both parse, or byte for byte
+func compareBodies(baseline, candidate []byte, ignore []JSONPath) []Difference {
+	b, bErr := decodeJSON(baseline)
+	c, cErr := decodeJSON(candidate)
+	if bErr != nil || cErr != nil {
+		if bytes.Equal(baseline, candidate) {
+			return nil
+		}
+		return []Difference{{
+			Kind:      DiffBody,
+			Baseline:  fmt.Sprintf("%d bytes", len(baseline)),
+			Candidate: fmt.Sprintf("%d bytes", len(candidate)),
+		}}
+	}
+
+	for _, path := range ignore {
+		path.Delete(b)
+		path.Delete(c)
+	}
+
+	var diffs []Difference
+	diffJSON("$", b, c, &diffs)
+	return diffs
+}
+
+func decodeJSON(body []byte) (interface{}, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, fmt.Errorf("empty body")
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// plainName matches object keys that can be written as .name in a JSONPath
+var plainName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+// diffJSON appends the differences between two decoded JSON values
+func diffJSON(path string, b, c interface{}, diffs *[]Difference) {
+	if len(*diffs) >= maxBodyDifferences {
+		return
+	}
+
+	switch bv := b.(type) {
+	case map[string]interface{}:
+		cv, ok := c.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make(map[string]int)
+		for k := range bv {
+			keys[k]++
+		}
+		for k := range cv {
+			keys[k]++
+		}
+		for _, k := range SortedKeys(keys) {
+			child := path + "['" + k + "']"
+			if plainName.MatchString(k) {
+				child = path + "." + k
+			}
+			bc, bok := bv[k]
+			cc, cok := cv[k]
+			if !bok || !cok {
+				*diffs = append(*diffs, Difference{Kind: DiffBody, Path: child, Baseline: jsonString(bc, bok), Candidate: jsonString(cc, cok)})
+				continue
+			}
+			diffJSON(child, bc, cc, diffs)
+		}
+		return
+	case []interface{}:
+		cv, ok := c.([]interface{})
+		if !ok {
+			break
+		}
+		if len(bv) != len(cv) {
+			*diffs = append(*diffs, Difference{
+				Kind:      DiffBody,
+				Path:      path,
+				Baseline:  fmt.Sprintf("%d element(s)", len(bv)),
+				Candidate: fmt.Sprintf("%d element(s)", len(cv)),
+			})
+		}
+		for i := 0; i < len(bv) && i < len(cv); i++ {
+			diffJSON(fmt.Sprintf("%s[%d]", path, i), bv[i], cv[i], diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(b, c) {
+		*diffs = append(*diffs, Difference{Kind: DiffBody, Path: path, Baseline: jsonString(b, true), Candidate: jsonString(c, true)})
+	}
+}
+
+// jsonString renders a JSON value for a difference
+func jsonString(v interface{}, present bool) string {
+	if !present {
+		return "(missing)"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	const max = 80
+	if len(data) > max {
+		return string(data[:max]) + "..."
+	}
+	return string(data)
+}
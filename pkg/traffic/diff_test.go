@@ -0,0 +1,76 @@
+package traffic
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompare(t *testing.T) {
+	base := Result{
+		Status: 200,
+		Header: http.Header{"Content-Type": {"application/json"}, "Date": {"Mon"}, "X-Version": {"1"}},
+		Body:   []byte(`{"id": "a1", "total": 10, "items": [{"sku": "x", "at": "t1"}], "updated_at": "t1"}`),
+	}
+
+	t.Run("identical", func(t *testing.T) {
+		require.Empty(t, Compare(base, base, DiffOptions{CompareHeaders: true}))
+	})
+
+	t.Run("status headers and body", func(t *testing.T) {
+		cand := Result{
+			Status: 201,
+			Header: http.Header{"Content-Type": {"application/json"}, "Date": {"Tue"}, "X-Version": {"2"}},
+			Body:   []byte(`{"id": "b2", "total": 10.0, "items": [{"sku": "y", "at": "t2"}, {"sku": "z"}], "updated_at": "t2", "extra": true}`),
+		}
+
+		diffs := Compare(base, cand, DiffOptions{CompareHeaders: true})
+		var got []string
+		for _, d := range diffs {
+			got = append(got, d.String())
+		}
+		require.Equal(t, []string{
+			"status: 200 != 201",
+			"header X-Version: [1] != [2]",
+			"body $.extra: (missing) != true",
+			`body $.id: "a1" != "b2"`,
+			"body $.items: 1 element(s) != 2 element(s)",
+			`body $.items[0].at: "t1" != "t2"`,
+			`body $.items[0].sku: "x" != "y"`,
+			"body $.total: 10 != 10.0",
+			`body $.updated_at: "t1" != "t2"`,
+		}, got)
+
+		ignore := func(exprs ...string) []JSONPath {
+			var paths []JSONPath
+			for _, expr := range exprs {
+				p, err := ParseJSONPath(expr)
+				require.NoError(t, err)
+				paths = append(paths, p)
+			}
+			return paths
+		}
+		cand.Status = 200
+		cand.Body = []byte(`{"id": "b2", "total": 10, "items": [{"sku": "x", "at": "t2"}], "updated_at": "t2"}`)
+		diffs = Compare(base, cand, DiffOptions{
+			CompareHeaders: true,
+			IgnoreHeaders:  []string{"x-version"},
+			IgnoreFields:   ignore("$.id", "$..at", "$.updated_at"),
+		})
+		require.Empty(t, diffs)
+	})
+
+	t.Run("non-JSON bodies", func(t *testing.T) {
+		a := Result{Status: 200, Body: []byte("<html>a</html>")}
+		b := Result{Status: 200, Body: []byte("<html>b</html>!")}
+		require.Empty(t, Compare(a, a, DiffOptions{}))
+		require.Equal(t, []Difference{{Kind: DiffBody, Baseline: "14 bytes", Candidate: "15 bytes"}}, Compare(a, b, DiffOptions{}))
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		diffs := Compare(base, Result{Err: errors.New("connection refused")}, DiffOptions{})
+		require.Equal(t, []Difference{{Kind: DiffError, Baseline: "(no error)", Candidate: "connection refused"}}, diffs)
+	})
+}
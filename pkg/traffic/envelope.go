@@ -0,0 +1,128 @@
+// Package traffic decodes, filters and replays the HTTP requests frkr
+// mirrors into stream topics.
+package traffic
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+)
+
+// Envelope is a message on a stream topic: one mirrored HTTP request, in the
+// shape the ingest gateway's /ingest endpoint accepts
+type Envelope struct {
+	StreamID string  `json:"stream_id,omitempty"`
+	Request  Request `json:"request"`
+}
+
+// Request is a mirrored HTTP request and, if the SDK captured it, its response
+type Request struct {
+	RequestID string            `json:"request_id,omitempty"`
+	Method    string            `json:"method"`
+	Path      string            `json:"path"`
+	Query     string            `json:"query,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+	// TimestampNs is when the SDK captured the request, in Unix nanoseconds
+	TimestampNs int64     `json:"timestamp_ns,omitempty"`
+	Response    *Response `json:"response,omitempty"`
+}
+
+// Response is the response the mirrored service sent
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Record is a decoded message and its position on the topic
+type Record struct {
+	Partition int       `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Time      time.Time `json:"time"`
+	Envelope
+}
+
+// Decode parses a message value. A bare request without the envelope around
+// it is accepted as well.
+func Decode(value []byte) (Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(value, &env); err != nil {
+		return Envelope{}, fmt.Errorf("invalid mirrored request: %w", err)
+	}
+	if env.Request.Method == "" && env.Request.Path == "" {
+		if err := json.Unmarshal(value, &env.Request); err != nil {
+			return Envelope{}, fmt.Errorf("invalid mirrored request: %w", err)
+		}
+	}
+	if env.Request.Method == "" && env.Request.Path == "" {
+		return Envelope{}, errors.New("invalid mirrored request: no method or path")
+	}
+	return env, nil
+}
+
+// Encode returns the message value for an envelope
+func Encode(env Envelope) ([]byte, error) {
+	value, err := json.Marshal(env)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mirrored request: %w", err)
+	}
+	return value, nil
+}
+
+// FromMessage decodes a message read from a stream topic
+func FromMessage(msg broker.Message) (Record, error) {
+	env, err := Decode(msg.Value)
+	if err != nil {
+		return Record{}, fmt.Errorf("partition %d offset %d: %w", msg.Partition, msg.Offset, err)
+	}
+	return Record{Partition: msg.Partition, Offset: msg.Offset, Time: msg.Time, Envelope: env}, nil
+}
+
+// URL returns the request's path and query
+func (r Request) URL() string {
+	if r.Query == "" {
+		return r.Path
+	}
+	return r.Path + "?" + strings.TrimPrefix(r.Query, "?")
+}
+
+// Header returns the value of a header, matching its name case-insensitively
+func (r Request) Header(name string) (string, bool) {
+	return lookupHeader(r.Headers, name)
+}
+
+// Status returns the captured response status, or 0 without a response
+func (r Request) Status() int {
+	if r.Response == nil {
+		return 0
+	}
+	return r.Response.Status
+}
+
+// SortedHeaderNames returns the names of headers in a stable order
+func SortedHeaderNames(headers map[string]string) []string {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookupHeader(headers map[string]string, name string) (string, bool) {
+	if v, ok := headers[name]; ok {
+		return v, true
+	}
+	for k, v := range headers {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
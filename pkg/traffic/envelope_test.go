@@ -0,0 +1,69 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/frkr-io/frkr-tools/pkg/broker"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	t.Run("envelope", func(t *testing.T) {
+		env, err := Decode([]byte(`{"stream_id": "test-replay", "request": {"request_id": "req-1", "method": "POST", "path": "/one"}}`))
+		require.NoError(t, err)
+		require.Equal(t, "test-replay", env.StreamID)
+		require.Equal(t, Request{RequestID: "req-1", Method: "POST", Path: "/one"}, env.Request)
+	})
+
+	t.Run("bare request", func(t *testing.T) {
+		env, err := Decode([]byte(`{"method": "GET", "path": "/health", "response": {"status": 204}}`))
+		require.NoError(t, err)
+		require.Equal(t, "GET", env.Request.Method)
+		require.Equal(t, 204, env.Request.Status())
+	})
+
+	t.Run("not a request", func(t *testing.T) {
+		_, err := Decode([]byte(`{"hello": "world"}`))
+		require.Error(t, err)
+
+		_, err = Decode([]byte(`not json`))
+		require.Error(t, err)
+	})
+
+	t.Run("round trip", func(t *testing.T) {
+		env := Envelope{StreamID: "s", Request: Request{Method: "PUT", Path: "/x", Headers: map[string]string{"A": "b"}}}
+		value, err := Encode(env)
+		require.NoError(t, err)
+		decoded, err := Decode(value)
+		require.NoError(t, err)
+		require.Equal(t, env, decoded)
+	})
+}
+
+func TestFromMessage(t *testing.T) {
+	now := time.Now()
+	rec, err := FromMessage(broker.Message{Partition: 1, Offset: 42, Time: now, Value: []byte(`{"request": {"method": "GET", "path": "/a"}}`)})
+	require.NoError(t, err)
+	require.Equal(t, 1, rec.Partition)
+	require.Equal(t, int64(42), rec.Offset)
+	require.Equal(t, now, rec.Time)
+	require.Equal(t, "/a", rec.Request.Path)
+
+	_, err = FromMessage(broker.Message{Partition: 1, Offset: 43, Value: []byte(`{}`)})
+	require.ErrorContains(t, err, "partition 1 offset 43")
+}
+
+func TestRequestHelpers(t *testing.T) {
+	r := Request{Path: "/search", Query: "q=1", Headers: map[string]string{"Content-Type": "application/json"}}
+	require.Equal(t, "/search?q=1", r.URL())
+	require.Equal(t, "/search", Request{Path: "/search"}.URL())
+
+	v, ok := r.Header("content-type")
+	require.True(t, ok)
+	require.Equal(t, "application/json", v)
+	_, ok = r.Header("Authorization")
+	require.False(t, ok)
+
+	require.Equal(t, 0, r.Status())
+}
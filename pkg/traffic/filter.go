@@ -0,0 +1,96 @@
+package traffic
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Filter matches requests on one field against a glob pattern, where * matches
+// any run of characters (including /) and ? matches a single character
+type Filter struct {
+	Field   string
+	Pattern string
+	re      *regexp.Regexp
+}
+
+// filterFields are the fields a Filter can match besides header.<name>
+var filterFields = []string{"method", "path", "status", "request_id"}
+
+// ParseFilter parses a filter of the form field=pattern, e.g. path=/api/*.
+// field is method, path, status, request_id or header.<name>.
+func ParseFilter(expr string) (Filter, error) {
+	field, pattern, ok := strings.Cut(expr, "=")
+	if !ok || field == "" {
+		return Filter{}, fmt.Errorf("invalid filter '%s' (expected field=pattern, e.g. path=/api/*)", expr)
+	}
+	field = strings.ToLower(field)
+
+	valid := strings.HasPrefix(field, "header.") && len(field) > len("header.")
+	for _, f := range filterFields {
+		valid = valid || field == f
+	}
+	if !valid {
+		return Filter{}, fmt.Errorf("invalid filter field '%s' (expected one of %s or header.<name>)",
+			field, strings.Join(filterFields, ", "))
+	}
+
+	glob := regexp.QuoteMeta(pattern)
+	glob = strings.ReplaceAll(glob, `\*`, `.*`)
+	glob = strings.ReplaceAll(glob, `\?`, `.`)
+	if field == "method" {
+		glob = "(?i)" + glob
+	}
+
+	return Filter{Field: field, Pattern: pattern, re: regexp.MustCompile("^" + glob + "$")}, nil
+}
+
+// ParseFilters parses every expression with ParseFilter
+func ParseFilters(exprs []string) ([]Filter, error) {
+	filters := make([]Filter, 0, len(exprs))
+	for _, expr := range exprs {
+		f, err := ParseFilter(expr)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	return filters, nil
+}
+
+// Match reports whether the request's field matches the pattern. A missing
+// header or response never matches.
+func (f Filter) Match(r Request) bool {
+	var value string
+	switch f.Field {
+	case "method":
+		value = r.Method
+	case "path":
+		value = r.Path
+	case "request_id":
+		value = r.RequestID
+	case "status":
+		if r.Response == nil {
+			return false
+		}
+		value = strconv.Itoa(r.Response.Status)
+	default:
+		v, ok := r.Header(strings.TrimPrefix(f.Field, "header."))
+		if !ok {
+			return false
+		}
+		value = v
+	}
+	return f.re.MatchString(value)
+}
+
+// MatchAll reports whether the request matches every filter
+func MatchAll(filters []Filter, r Request) bool {
+	for _, f := range filters {
+		if !f.Match(r) {
+			return false
+		}
+	}
+	return true
+}
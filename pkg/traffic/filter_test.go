@@ -0,0 +1,62 @@
+package traffic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, expr := range []string{"path", "=x", "body=x", "header.=x"} {
+		_, err := ParseFilter(expr)
+		require.Error(t, err, expr)
+	}
+
+	f, err := ParseFilter("Header.Content-Type=application/*")
+	require.NoError(t, err)
+	require.Equal(t, "header.content-type", f.Field)
+}
+
+func TestFilterMatch(t *testing.T) {
+	req := Request{
+		RequestID: "req-1",
+		Method:    "POST",
+		Path:      "/api/v1/orders",
+		Headers:   map[string]string{"Content-Type": "application/json"},
+		Response:  &Response{Status: 201},
+	}
+
+	tests := []struct {
+		expr  string
+		match bool
+	}{
+		{"path=/api/*", true},
+		{"path=/api/v?/orders", true},
+		{"path=/other/*", false},
+		{"path=/api", false},
+		{"method=post", true},
+		{"method=GET", false},
+		{"status=2*", true},
+		{"status=5*", false},
+		{"request_id=req-1", true},
+		{"header.content-type=application/*", true},
+		{"header.authorization=*", false},
+		{"path=/api/v1/orders.", false},
+	}
+	for _, tt := range tests {
+		f, err := ParseFilter(tt.expr)
+		require.NoError(t, err, tt.expr)
+		require.Equal(t, tt.match, f.Match(req), tt.expr)
+	}
+
+	f, err := ParseFilter("status=*")
+	require.NoError(t, err)
+	require.False(t, f.Match(Request{Method: "GET", Path: "/"}), "no response never matches status")
+
+	filters, err := ParseFilters([]string{"path=/api/*", "method=POST"})
+	require.NoError(t, err)
+	require.True(t, MatchAll(filters, req))
+	req.Method = "GET"
+	require.False(t, MatchAll(filters, req))
+	require.True(t, MatchAll(nil, req))
+}
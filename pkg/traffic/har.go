@@ -0,0 +1,255 @@
+package traffic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HAR is an HTTP Archive (HAR 1.2) document, as written by browsers and
+// debugging proxies. Only the fields frkr reads or writes are modelled.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root object of a HAR document
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the application that wrote a HAR document
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is one request and its response
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest is the request of a HAR entry
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse is the response of a HAR entry
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARNameValue is a header, cookie or query parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData is a request body
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent is a response body
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+// HARTimings are the phases of a request, in milliseconds; -1 means unknown
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// WriteHAR writes records as a HAR document. Mirrored requests carry only a
+// path, so each URL is built from the request's Host header, or from
+// baseURL (e.g. http://localhost) without one.
+func WriteHAR(w io.Writer, records []Record, baseURL string) error {
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "frkrcfg"},
+		Entries: make([]HAREntry, 0, len(records)),
+	}}
+	for _, rec := range records {
+		har.Log.Entries = append(har.Log.Entries, toHAREntry(rec, baseURL))
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(har); err != nil {
+		return fmt.Errorf("failed to write HAR: %w", err)
+	}
+	return nil
+}
+
+func toHAREntry(rec Record, baseURL string) HAREntry {
+	req := rec.Request
+
+	base := strings.TrimSuffix(baseURL, "/")
+	if host, ok := req.Header("Host"); ok && host != "" {
+		scheme := "http"
+		if u, err := url.Parse(baseURL); err == nil && u.Scheme != "" {
+			scheme = u.Scheme
+		}
+		base = scheme + "://" + host
+	}
+
+	entry := HAREntry{
+		StartedDateTime: rec.Time.UTC(),
+		Request: HARRequest{
+			Method:      req.Method,
+			URL:         base + req.URL(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     toHARHeaders(req.Headers),
+			QueryString: []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(req.Body),
+		},
+		Response: HARResponse{
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []HARNameValue{},
+			Headers:     []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: HARTimings{Send: -1, Wait: -1, Receive: -1},
+	}
+	if req.TimestampNs != 0 {
+		entry.StartedDateTime = time.Unix(0, req.TimestampNs).UTC()
+	}
+
+	if values, err := url.ParseQuery(strings.TrimPrefix(req.Query, "?")); err == nil {
+		names := make([]string, 0, len(values))
+		for name := range values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, v := range values[name] {
+				entry.Request.QueryString = append(entry.Request.QueryString, HARNameValue{Name: name, Value: v})
+			}
+		}
+	}
+	if req.Body != "" {
+		mimeType, _ := req.Header("Content-Type")
+		entry.Request.PostData = &HARPostData{MimeType: mimeType, Text: req.Body}
+	}
+	if resp := req.Response; resp != nil {
+		entry.Response.Status = resp.Status
+		entry.Response.StatusText = http.StatusText(resp.Status)
+		entry.Response.Headers = toHARHeaders(resp.Headers)
+		mimeType, _ := lookupHeader(resp.Headers, "Content-Type")
+		entry.Response.Content = HARContent{Size: len(resp.Body), MimeType: mimeType, Text: resp.Body}
+		entry.Response.BodySize = len(resp.Body)
+	}
+	return entry
+}
+
+func toHARHeaders(headers map[string]string) []HARNameValue {
+	values := make([]HARNameValue, 0, len(headers))
+	for _, name := range SortedHeaderNames(headers) {
+		values = append(values, HARNameValue{Name: name, Value: headers[name]})
+	}
+	return values
+}
+
+// ReadHAR reads the entries of a HAR document as records. Repeated headers
+// are joined with ", " and HTTP/2 pseudo-headers are dropped.
+func ReadHAR(r io.Reader) ([]Record, error) {
+	var har HAR
+	if err := json.NewDecoder(r).Decode(&har); err != nil {
+		return nil, fmt.Errorf("invalid HAR: %w", err)
+	}
+
+	records := make([]Record, 0, len(har.Log.Entries))
+	for i, entry := range har.Log.Entries {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil || entry.Request.Method == "" {
+			return nil, fmt.Errorf("invalid HAR entry %d: no valid method and URL", i)
+		}
+
+		req := Request{
+			Method:  entry.Request.Method,
+			Path:    u.EscapedPath(),
+			Query:   u.RawQuery,
+			Headers: fromHARHeaders(entry.Request.Headers),
+		}
+		if req.Path == "" {
+			req.Path = "/"
+		}
+		if _, ok := req.Header("Host"); !ok && u.Host != "" {
+			if req.Headers == nil {
+				req.Headers = make(map[string]string)
+			}
+			req.Headers["Host"] = u.Host
+		}
+		if entry.Request.PostData != nil {
+			req.Body = entry.Request.PostData.Text
+		}
+		if !entry.StartedDateTime.IsZero() {
+			req.TimestampNs = entry.StartedDateTime.UnixNano()
+		}
+		// Browsers record status 0 for requests that never got a response
+		if entry.Response.Status > 0 {
+			req.Response = &Response{
+				Status:  entry.Response.Status,
+				Headers: fromHARHeaders(entry.Response.Headers),
+				Body:    entry.Response.Content.Text,
+			}
+		}
+
+		records = append(records, Record{Time: entry.StartedDateTime, Envelope: Envelope{Request: req}})
+	}
+	return records, nil
+}
+
+func fromHARHeaders(values []HARNameValue) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+	headers := make(map[string]string, len(values))
+	for _, h := range values {
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		name := http.CanonicalHeaderKey(h.Name)
+		if prev, ok := headers[name]; ok {
+			headers[name] = prev + ", " + h.Value
+		} else {
+			headers[name] = h.Value
+		}
+	}
+	return headers
+}
@@ -0,0 +1,88 @@
+package traffic
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteHAR(t *testing.T) {
+	captured := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	records := []Record{
+		{Time: captured, Envelope: Envelope{Request: Request{
+			Method:   "POST",
+			Path:     "/orders",
+			Query:    "b=2&a=1",
+			Headers:  map[string]string{"Host": "api.example.com", "Content-Type": "application/json"},
+			Body:     `{"id":1}`,
+			Response: &Response{Status: 201, Body: `{"ok":true}`},
+		}}},
+		{Time: captured, Envelope: Envelope{Request: Request{Method: "GET", Path: "/health"}}},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteHAR(&buf, records, "https://localhost:8443/"))
+
+	var har HAR
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &har))
+	require.Equal(t, "1.2", har.Log.Version)
+	require.Len(t, har.Log.Entries, 2)
+
+	first := har.Log.Entries[0]
+	require.Equal(t, "https://api.example.com/orders?b=2&a=1", first.Request.URL)
+	require.Equal(t, []HARNameValue{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, first.Request.QueryString)
+	require.Equal(t, &HARPostData{MimeType: "application/json", Text: `{"id":1}`}, first.Request.PostData)
+	require.Equal(t, 201, first.Response.Status)
+	require.Equal(t, "Created", first.Response.StatusText)
+
+	require.Equal(t, "https://localhost:8443/health", har.Log.Entries[1].Request.URL)
+	require.Nil(t, har.Log.Entries[1].Request.PostData)
+
+	// What frkr writes, it reads back
+	back, err := ReadHAR(&buf)
+	require.NoError(t, err)
+	require.Len(t, back, 2)
+	require.Equal(t, records[0].Request.Body, back[0].Request.Body)
+	require.Equal(t, "b=2&a=1", back[0].Request.Query)
+	require.Equal(t, 201, back[0].Request.Status())
+	require.True(t, captured.Equal(back[0].Time))
+}
+
+func TestReadHAR(t *testing.T) {
+	const doc = `{"log": {"version": "1.2", "entries": [{
+		"startedDateTime": "2024-01-02T15:00:00.000Z",
+		"request": {
+			"method": "PUT",
+			"url": "https://shop.example.com/cart/items?id=7",
+			"headers": [
+				{"name": ":authority", "value": "shop.example.com"},
+				{"name": "accept", "value": "text/html"},
+				{"name": "Accept", "value": "application/json"}
+			],
+			"postData": {"mimeType": "application/json", "text": "{\"qty\":2}"}
+		},
+		"response": {"status": 0}
+	}]}}`
+
+	records, err := ReadHAR(strings.NewReader(doc))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+
+	req := records[0].Request
+	require.Equal(t, "PUT", req.Method)
+	require.Equal(t, "/cart/items", req.Path)
+	require.Equal(t, "id=7", req.Query)
+	require.Equal(t, `{"qty":2}`, req.Body)
+	require.Equal(t, map[string]string{"Accept": "text/html, application/json", "Host": "shop.example.com"}, req.Headers)
+	require.Nil(t, req.Response, "status 0 means no response")
+	require.Equal(t, time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC).UnixNano(), req.TimestampNs)
+
+	_, err = ReadHAR(strings.NewReader(`{"log": {"entries": [{"request": {"url": "/x"}}]}}`))
+	require.Error(t, err)
+	_, err = ReadHAR(strings.NewReader(`not json`))
+	require.Error(t, err)
+}
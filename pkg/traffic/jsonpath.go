@@ -0,0 +1,204 @@
+package traffic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath selects values in a decoded JSON document. It supports the subset
+// of JSONPath needed to point at fields: $, .name, ['name'], [n], .* and [*]
+// wildcards, and ..name recursive descent, e.g. $.items[*].id or $..created_at.
+type JSONPath struct {
+	expr string
+	segs []pathSegment
+}
+
+type pathSegment struct {
+	// recursive matches at any depth below the current value (..name)
+	recursive bool
+	wildcard  bool
+	name      string
+	index     int
+	isIndex   bool
+}
+
+// ParseJSONPath parses a JSONPath expression. It must select something
+// below the root.
+func ParseJSONPath(expr string) (JSONPath, error) {
+	fail := func(reason string) (JSONPath, error) {
+		return JSONPath{}, fmt.Errorf("invalid JSONPath '%s': %s", expr, reason)
+	}
+
+	rest, ok := strings.CutPrefix(expr, "$")
+	if !ok {
+		return fail("must start with $")
+	}
+
+	var segs []pathSegment
+	for rest != "" {
+		var seg pathSegment
+		switch {
+		case strings.HasPrefix(rest, ".."):
+			seg.recursive = true
+			rest = rest[2:]
+			if strings.HasPrefix(rest, "[") {
+				return fail("use ..name or ..*")
+			}
+			seg, rest = parseDotName(seg, rest)
+		case strings.HasPrefix(rest, "."):
+			seg, rest = parseDotName(seg, rest[1:])
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return fail("unclosed [")
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			switch {
+			case inner == "*":
+				seg.wildcard = true
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				seg.name = inner[1 : len(inner)-1]
+			default:
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return fail("expected [n], [*] or ['name']")
+				}
+				seg.index, seg.isIndex = n, true
+			}
+		default:
+			return fail("expected . or [")
+		}
+		if !seg.wildcard && !seg.isIndex && seg.name == "" {
+			return fail("empty name")
+		}
+		segs = append(segs, seg)
+	}
+	if len(segs) == 0 {
+		return fail("must select a field below $")
+	}
+
+	return JSONPath{expr: expr, segs: segs}, nil
+}
+
+// parseDotName parses the name after a dot, up to the next . or [
+func parseDotName(seg pathSegment, rest string) (pathSegment, string) {
+	end := strings.IndexAny(rest, ".[")
+	if end < 0 {
+		end = len(rest)
+	}
+	name := rest[:end]
+	if name == "*" {
+		seg.wildcard = true
+	} else {
+		seg.name = name
+	}
+	return seg, rest[end:]
+}
+
+// String returns the expression the path was parsed from
+func (p JSONPath) String() string { return p.expr }
+
+// Delete removes every selected object member. Selected array elements are
+// set to null, so the indexes of the others do not move.
+func (p JSONPath) Delete(doc interface{}) {
+	p.apply(doc, p.segs, func(parent interface{}, key interface{}) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			delete(c, key.(string))
+		case []interface{}:
+			c[key.(int)] = nil
+		}
+	})
+}
+
+// Set replaces every selected value with value. Nothing is created: a path
+// that selects nothing leaves the document unchanged.
+func (p JSONPath) Set(doc interface{}, value interface{}) {
+	p.apply(doc, p.segs, func(parent interface{}, key interface{}) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			c[key.(string)] = value
+		case []interface{}:
+			c[key.(int)] = value
+		}
+	})
+}
+
+// apply calls fn with the container and key of every value selected by segs
+// below node
+func (p JSONPath) apply(node interface{}, segs []pathSegment, fn func(parent, key interface{})) {
+	if len(segs) == 0 {
+		return
+	}
+	seg, rest := segs[0], segs[1:]
+
+	if seg.recursive {
+		here := seg
+		here.recursive = false
+		p.apply(node, append([]pathSegment{here}, rest...), fn)
+		for _, child := range children(node) {
+			p.apply(child, segs, fn)
+		}
+		return
+	}
+
+	visit := func(parent, key, child interface{}) {
+		if len(rest) == 0 {
+			fn(parent, key)
+			return
+		}
+		p.apply(child, rest, fn)
+	}
+
+	switch c := node.(type) {
+	case map[string]interface{}:
+		if seg.isIndex {
+			return
+		}
+		if !seg.wildcard {
+			if child, ok := c[seg.name]; ok {
+				visit(c, seg.name, child)
+			}
+			return
+		}
+		for _, k := range sortedMapKeys(c) {
+			visit(c, k, c[k])
+		}
+	case []interface{}:
+		switch {
+		case seg.wildcard:
+			for i, child := range c {
+				visit(c, i, child)
+			}
+		case seg.isIndex && seg.index < len(c):
+			visit(c, seg.index, c[seg.index])
+		}
+	}
+}
+
+// children returns the members or elements of an object or array
+func children(node interface{}) []interface{} {
+	switch c := node.(type) {
+	case map[string]interface{}:
+		values := make([]interface{}, 0, len(c))
+		for _, k := range sortedMapKeys(c) {
+			values = append(values, c[k])
+		}
+		return values
+	case []interface{}:
+		return c
+	}
+	return nil
+}
+
+func sortedMapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
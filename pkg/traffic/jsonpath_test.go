@@ -0,0 +1,67 @@
+package traffic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func decodeDoc(t *testing.T, doc string) interface{} {
+	t.Helper()
+	var v interface{}
+	require.NoError(t, json.Unmarshal([]byte(doc), &v))
+	return v
+}
+
+func encodeDoc(t *testing.T, v interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestParseJSONPath(t *testing.T) {
+	for _, expr := range []string{"$.a", "$['a b']", "$.items[0].id", "$.items[*]", "$..id", "$..*", "$.*"} {
+		p, err := ParseJSONPath(expr)
+		require.NoError(t, err, expr)
+		require.Equal(t, expr, p.String())
+	}
+	for _, expr := range []string{"", "$", "a.b", "$.", "$[", "$[-1]", "$[x]", "$..[0]", "$.a..", "$a"} {
+		_, err := ParseJSONPath(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestJSONPathDelete(t *testing.T) {
+	const doc = `{"id": 1, "name": "x", "items": [{"id": 2, "v": 1}, {"id": 3, "v": 2}], "meta": {"id": 4, "at": "now"}}`
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"$.id", `{"items":[{"id":2,"v":1},{"id":3,"v":2}],"meta":{"at":"now","id":4},"name":"x"}`},
+		{"$.items[*].id", `{"id":1,"items":[{"v":1},{"v":2}],"meta":{"at":"now","id":4},"name":"x"}`},
+		{"$.items[1]", `{"id":1,"items":[{"id":2,"v":1},null],"meta":{"at":"now","id":4},"name":"x"}`},
+		{"$..id", `{"items":[{"v":1},{"v":2}],"meta":{"at":"now"},"name":"x"}`},
+		{"$['meta'].*", `{"id":1,"items":[{"id":2,"v":1},{"id":3,"v":2}],"meta":{},"name":"x"}`},
+		{"$.missing.id", `{"id":1,"items":[{"id":2,"v":1},{"id":3,"v":2}],"meta":{"at":"now","id":4},"name":"x"}`},
+		{"$.items[9]", `{"id":1,"items":[{"id":2,"v":1},{"id":3,"v":2}],"meta":{"at":"now","id":4},"name":"x"}`},
+	}
+	for _, tt := range tests {
+		p, err := ParseJSONPath(tt.expr)
+		require.NoError(t, err, tt.expr)
+		v := decodeDoc(t, doc)
+		p.Delete(v)
+		require.Equal(t, tt.want, encodeDoc(t, v), tt.expr)
+	}
+}
+
+func TestJSONPathSet(t *testing.T) {
+	p, err := ParseJSONPath("$..password")
+	require.NoError(t, err)
+
+	v := decodeDoc(t, `{"user": {"password": "secret"}, "list": [{"password": "x"}], "other": 1}`)
+	p.Set(v, "REDACTED")
+	require.Equal(t, `{"list":[{"password":"REDACTED"}],"other":1,"user":{"password":"REDACTED"}}`, encodeDoc(t, v))
+}
@@ -0,0 +1,204 @@
+package traffic
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// IngestPath is the ingest gateway's endpoint for mirrored requests
+const IngestPath = "/ingest"
+
+// Ingester publishes mirrored requests through an ingest gateway
+type Ingester struct {
+	target *Target
+	// authorization returns the Authorization header for a request
+	authorization func(ctx context.Context) (string, error)
+}
+
+// NewIngester creates an Ingester for the gateway at gatewayURL. Requests
+// are unauthenticated until WithBasicAuth or WithToken is called.
+func NewIngester(gatewayURL string, timeout time.Duration) (*Ingester, error) {
+	target, err := NewTarget(gatewayURL, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ingest gateway URL '%s' (expected e.g. http://localhost:8082)", gatewayURL)
+	}
+	return &Ingester{target: target}, nil
+}
+
+// WithBasicAuth authenticates as a user
+func (i *Ingester) WithBasicAuth(username, password string) *Ingester {
+	header := "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password))
+	i.authorization = func(context.Context) (string, error) { return header, nil }
+	return i
+}
+
+// WithToken authenticates with bearer tokens from source
+func (i *Ingester) WithToken(source TokenSource) *Ingester {
+	i.authorization = func(ctx context.Context) (string, error) {
+		token, err := source.Token(ctx)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+	return i
+}
+
+// Send posts one envelope to the gateway
+func (i *Ingester) Send(ctx context.Context, env Envelope) Result {
+	body, err := Encode(env)
+	if err != nil {
+		return Result{Err: err}
+	}
+	req := Request{
+		Method:  http.MethodPost,
+		Path:    IngestPath,
+		Headers: map[string]string{"Content-Type": "application/json"},
+		Body:    string(body),
+	}
+	if i.authorization != nil {
+		auth, err := i.authorization(ctx)
+		if err != nil {
+			return Result{Err: err}
+		}
+		req.Headers["Authorization"] = auth
+	}
+	return i.target.Do(ctx, req)
+}
+
+// LoadOptions controls the pacing and length of a load run
+type LoadOptions struct {
+	// Rate is the number of requests started per second; 0 sends as fast as
+	// Concurrency allows
+	Rate float64
+	// Duration stops the run after this long; 0 means no limit
+	Duration time.Duration
+	// Requests stops the run after this many requests; 0 means no limit
+	Requests int
+	// Concurrency is the number of requests in flight at most. When every
+	// slot is busy, the next request waits and the achieved rate drops
+	// below Rate.
+	Concurrency int
+}
+
+// LoadReport collects the results of a load run. It is safe for
+// concurrent use.
+type LoadReport struct {
+	Summary
+	// Elapsed is the time from the first request to the last response
+	Elapsed time.Duration
+
+	latenciesMu sync.Mutex
+	latencies   []time.Duration
+}
+
+// Add records the result of one request
+func (r *LoadReport) Add(result Result) {
+	r.Summary.Add(result)
+	if result.Err != nil {
+		return
+	}
+	r.latenciesMu.Lock()
+	defer r.latenciesMu.Unlock()
+	r.latencies = append(r.latencies, result.Latency)
+}
+
+// Throughput returns the responses received per second
+func (r *LoadReport) Throughput() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Sent()-r.Failed()) / r.Elapsed.Seconds()
+}
+
+// Percentile returns the latency that p percent of the responses were
+// faster than or equal to, e.g. Percentile(99). It is 0 without responses.
+func (r *LoadReport) Percentile(p float64) time.Duration {
+	r.latenciesMu.Lock()
+	defer r.latenciesMu.Unlock()
+	if len(r.latencies) == 0 {
+		return 0
+	}
+
+	sorted := append([]time.Duration(nil), r.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	// Nearest rank
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	rank = min(max(rank, 0), len(sorted)-1)
+	return sorted[rank]
+}
+
+// RunLoad calls send for requests 0, 1, 2, ... at opts.Rate until
+// opts.Duration has passed, opts.Requests were sent or ctx is cancelled,
+// then waits for the requests in flight. send is called from several
+// goroutines at once. Requests cut short by cancelling ctx are not
+// recorded.
+func RunLoad(ctx context.Context, opts LoadOptions, send func(ctx context.Context, n int) Result) *LoadReport {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	report := &LoadReport{}
+	start := time.Now()
+	runCtx := ctx
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithDeadline(ctx, start.Add(opts.Duration))
+		defer cancel()
+	}
+
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for n := 0; opts.Requests == 0 || n < opts.Requests; n++ {
+		if opts.Rate > 0 {
+			due := start.Add(time.Duration(float64(n) / opts.Rate * float64(time.Second)))
+			if !sleepUntil(runCtx, due) {
+				break
+			}
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-runCtx.Done():
+		}
+		if runCtx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(n int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			// Requests in flight when the duration ends may still finish
+			result := send(ctx, n)
+			if ctx.Err() != nil {
+				return
+			}
+			report.Add(result)
+		}(n)
+	}
+	wg.Wait()
+
+	report.Elapsed = time.Since(start)
+	return report
+}
+
+// sleepUntil waits until t. It returns false if ctx is done first.
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	wait := time.Until(t)
+	if wait <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
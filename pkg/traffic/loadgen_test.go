@@ -0,0 +1,105 @@
+package traffic
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngesterRunLoad(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []Envelope
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if r.URL.Path != IngestPath || !ok || user != "load" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		env, err := Decode(body)
+		require.NoError(t, err)
+		mu.Lock()
+		received = append(received, env)
+		mu.Unlock()
+		if env.Request.Path == "/reject" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer srv.Close()
+
+	ingester, err := NewIngester(srv.URL, time.Second)
+	require.NoError(t, err)
+	ingester.WithBasicAuth("load", "secret")
+
+	report := RunLoad(context.Background(), LoadOptions{Requests: 20, Concurrency: 4}, func(ctx context.Context, n int) Result {
+		path := "/ok"
+		if n%5 == 0 {
+			path = "/reject"
+		}
+		return ingester.Send(ctx, Envelope{StreamID: "my-api", Request: Request{Method: "GET", Path: path}})
+	})
+
+	require.Equal(t, 20, report.Sent())
+	require.Equal(t, 0, report.Failed())
+	require.Equal(t, map[int]int{202: 16, 429: 4}, report.Statuses())
+	require.Len(t, received, 20)
+	require.Equal(t, "my-api", received[0].StreamID)
+	require.Greater(t, report.Throughput(), 0.0)
+	require.Greater(t, report.Percentile(99), time.Duration(0))
+
+	_, err = NewIngester("localhost:8082", time.Second)
+	require.ErrorContains(t, err, "invalid ingest gateway URL")
+}
+
+func TestRunLoadPacing(t *testing.T) {
+	// 50/s for 90ms starts 5 requests, at 0, 20, 40, 60 and 80ms
+	var count int
+	var mu sync.Mutex
+	report := RunLoad(context.Background(), LoadOptions{Rate: 50, Duration: 90 * time.Millisecond, Concurrency: 2}, func(ctx context.Context, n int) Result {
+		mu.Lock()
+		count++
+		mu.Unlock()
+		return Result{Status: 200, Latency: time.Millisecond}
+	})
+	require.Equal(t, 5, count)
+	require.Equal(t, 5, report.Sent())
+	require.GreaterOrEqual(t, report.Elapsed, 80*time.Millisecond)
+
+	// Cancelling stops the run without recording requests cut short
+	ctx, cancel := context.WithCancel(context.Background())
+	report = RunLoad(ctx, LoadOptions{Concurrency: 1}, func(ctx context.Context, n int) Result {
+		if n == 3 {
+			cancel()
+			<-ctx.Done()
+			return Result{Err: ctx.Err()}
+		}
+		return Result{Status: 200}
+	})
+	require.Equal(t, 3, report.Sent())
+	require.Equal(t, 0, report.Failed())
+}
+
+func TestLoadReportPercentile(t *testing.T) {
+	var report LoadReport
+	require.Zero(t, report.Percentile(50))
+	for i := 1; i <= 100; i++ {
+		report.Add(Result{Status: 200, Latency: time.Duration(i) * time.Millisecond})
+	}
+	report.Add(Result{Err: context.DeadlineExceeded})
+
+	require.Equal(t, 50*time.Millisecond, report.Percentile(50))
+	require.Equal(t, 95*time.Millisecond, report.Percentile(95))
+	require.Equal(t, 99*time.Millisecond, report.Percentile(99))
+	require.Equal(t, 100*time.Millisecond, report.Percentile(100))
+	require.Equal(t, 1, report.Failed())
+}
@@ -0,0 +1,50 @@
+package traffic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxNDJSONLine bounds a single line of NDJSON input, i.e. one request
+const maxNDJSONLine = 16 << 20
+
+// WriteNDJSON writes a record as one line of JSON, in the form printed by
+// 'frkrcfg stream tail -o json'
+func WriteNDJSON(w io.Writer, rec Record) error {
+	return json.NewEncoder(w).Encode(rec)
+}
+
+// ReadNDJSON reads records written by WriteNDJSON. Lines holding just an
+// envelope or a bare request are accepted too; blank lines are skipped.
+func ReadNDJSON(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+
+	var records []Record
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		env, err := Decode(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		var pos struct {
+			Time time.Time `json:"time"`
+		}
+		// A line without a valid time is imported without one
+		_ = json.Unmarshal(line, &pos)
+
+		records = append(records, Record{Time: pos.Time, Envelope: env})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON: %w", err)
+	}
+	return records, nil
+}
@@ -0,0 +1,36 @@
+package traffic
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNDJSON(t *testing.T) {
+	captured := time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC)
+	rec := Record{Partition: 2, Offset: 7, Time: captured, Envelope: Envelope{
+		StreamID: "my-api",
+		Request:  Request{Method: "GET", Path: "/a"},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteNDJSON(&buf, rec))
+	buf.WriteString("\n")
+	buf.WriteString(`{"stream_id": "my-api", "request": {"method": "POST", "path": "/b"}}` + "\n")
+	buf.WriteString(`{"method": "DELETE", "path": "/c"}`)
+
+	records, err := ReadNDJSON(&buf)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	require.True(t, captured.Equal(records[0].Time))
+	require.Equal(t, rec.Envelope, records[0].Envelope)
+	require.Equal(t, "POST", records[1].Request.Method)
+	require.Equal(t, "DELETE", records[2].Request.Method)
+	require.True(t, records[2].Time.IsZero())
+
+	_, err = ReadNDJSON(strings.NewReader("{\"method\": \"GET\", \"path\": \"/\"}\n{}\n"))
+	require.ErrorContains(t, err, "line 2")
+}
@@ -0,0 +1,249 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// droppedHeaders are not replayed: they describe the original connection,
+// or are set by the HTTP client for the new one. Accept-Encoding is dropped
+// so that responses arrive decoded and can be compared.
+var droppedHeaders = map[string]bool{
+	"Host":              true,
+	"Content-Length":    true,
+	"Connection":        true,
+	"Keep-Alive":        true,
+	"Proxy-Connection":  true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+	"Accept-Encoding":   true,
+}
+
+// Target re-issues mirrored requests against a base URL
+type Target struct {
+	BaseURL *url.URL
+	Client  *http.Client
+}
+
+// NewTarget creates a Target for an http or https base URL. Redirects are
+// not followed, so the status the target returns is the one reported.
+func NewTarget(baseURL string, timeout time.Duration) (*Target, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid target URL '%s' (expected e.g. http://localhost:3000)", baseURL)
+	}
+	return &Target{
+		BaseURL: u,
+		Client: &http.Client{
+			Timeout: timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// Result is the outcome of sending one request
+type Result struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Latency time.Duration
+	Err     error
+}
+
+// Do sends a request to the target and reads the whole response
+func (t *Target) Do(ctx context.Context, req Request) Result {
+	u := *t.BaseURL
+	u.Path = strings.TrimSuffix(u.Path, "/") + req.Path
+	u.RawPath = ""
+	u.RawQuery = strings.TrimPrefix(req.Query, "?")
+
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, u.String(), body)
+	if err != nil {
+		return Result{Err: fmt.Errorf("invalid request: %w", err)}
+	}
+	for name, value := range req.Headers {
+		if !droppedHeaders[http.CanonicalHeaderKey(name)] {
+			httpReq.Header.Set(name, value)
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.Client.Do(httpReq)
+	if err != nil {
+		return Result{Latency: time.Since(start), Err: err}
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	result := Result{Status: resp.StatusCode, Header: resp.Header, Body: respBody, Latency: time.Since(start)}
+	if err != nil {
+		result.Err = fmt.Errorf("failed to read response: %w", err)
+	}
+	return result
+}
+
+// ReplayOptions controls the pacing and parallelism of a Replayer
+type ReplayOptions struct {
+	// Speed scales the gaps between the captured requests: 1 replays in
+	// real time and 2 twice as fast. 0 sends as fast as possible.
+	Speed float64
+	// Concurrency is the number of requests in flight at most
+	Concurrency int
+}
+
+// Replayer paces records by the time they were captured and hands them to a
+// pool of workers
+type Replayer struct {
+	opts  ReplayOptions
+	work  chan Record
+	wg    sync.WaitGroup
+	first time.Time
+	start time.Time
+}
+
+// NewReplayer starts opts.Concurrency workers that call handle for every
+// submitted record. handle is called from several goroutines at once.
+func NewReplayer(ctx context.Context, opts ReplayOptions, handle func(context.Context, Record)) *Replayer {
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	r := &Replayer{opts: opts, work: make(chan Record)}
+	for i := 0; i < opts.Concurrency; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for rec := range r.work {
+				handle(ctx, rec)
+			}
+		}()
+	}
+	return r
+}
+
+// Submit waits until a record is due and a worker is free, then hands it
+// over. It returns early with ctx's error if ctx is cancelled.
+func (r *Replayer) Submit(ctx context.Context, rec Record) error {
+	if r.start.IsZero() {
+		r.first, r.start = rec.Time, time.Now()
+	}
+
+	if r.opts.Speed > 0 {
+		offset := time.Duration(float64(rec.Time.Sub(r.first)) / r.opts.Speed)
+		if wait := time.Until(r.start.Add(offset)); wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+	}
+
+	select {
+	case r.work <- rec:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait waits for every submitted record to be handled. No records may be
+// submitted afterwards.
+func (r *Replayer) Wait() {
+	close(r.work)
+	r.wg.Wait()
+}
+
+// Summary counts the results of a replay. It is safe for concurrent use.
+type Summary struct {
+	mu       sync.Mutex
+	sent     int
+	statuses map[int]int
+	errors   map[string]int
+}
+
+// Add records the result of one request
+func (s *Summary) Add(result Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent++
+	if result.Err != nil {
+		if s.errors == nil {
+			s.errors = make(map[string]int)
+		}
+		s.errors[result.Err.Error()]++
+		return
+	}
+	if s.statuses == nil {
+		s.statuses = make(map[int]int)
+	}
+	s.statuses[result.Status]++
+}
+
+// Sent returns the number of requests sent
+func (s *Summary) Sent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent
+}
+
+// Statuses returns how many responses had each status code
+func (s *Summary) Statuses() map[int]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyCounts(s.statuses)
+}
+
+// Errors returns how many requests failed with each error, without a response
+func (s *Summary) Errors() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return copyCounts(s.errors)
+}
+
+// Failed returns the number of requests that got no response
+func (s *Summary) Failed() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, count := range s.errors {
+		n += count
+	}
+	return n
+}
+
+// SortedKeys returns the keys of a count map in ascending order
+func SortedKeys[K int | string](counts map[K]int) []K {
+	keys := make([]K, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func copyCounts[K comparable](counts map[K]int) map[K]int {
+	c := make(map[K]int, len(counts))
+	for k, v := range counts {
+		c[k] = v
+	}
+	return c
+}
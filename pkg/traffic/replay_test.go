@@ -0,0 +1,119 @@
+package traffic
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewTarget(t *testing.T) {
+	for _, bad := range []string{"", "localhost:3000", "ftp://host", "http://"} {
+		_, err := NewTarget(bad, time.Second)
+		require.Error(t, err, bad)
+	}
+}
+
+func TestTargetDo(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.Header().Set("X-Reply", "yes")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer srv.Close()
+
+	target, err := NewTarget(srv.URL+"/base/", time.Second)
+	require.NoError(t, err)
+
+	result := target.Do(context.Background(), Request{
+		Method:  "POST",
+		Path:    "/orders",
+		Query:   "dry_run=1",
+		Body:    `{"id":1}`,
+		Headers: map[string]string{"Content-Type": "application/json", "Host": "prod.example.com", "Content-Length": "999"},
+	})
+	require.NoError(t, result.Err)
+	require.Equal(t, http.StatusCreated, result.Status)
+	require.Equal(t, "yes", result.Header.Get("X-Reply"))
+	require.Equal(t, `{"ok":true}`, string(result.Body))
+
+	require.Equal(t, "POST", got.Method)
+	require.Equal(t, "/base/orders", got.URL.Path)
+	require.Equal(t, "dry_run=1", got.URL.RawQuery)
+	require.Equal(t, "application/json", got.Header.Get("Content-Type"))
+	require.NotEqual(t, "prod.example.com", got.Host)
+	require.Equal(t, `{"id":1}`, gotBody)
+
+	srv.Close()
+	result = target.Do(context.Background(), Request{Method: "GET", Path: "/"})
+	require.Error(t, result.Err)
+}
+
+func TestReplayer(t *testing.T) {
+	base := time.Now()
+	records := []Record{
+		{Offset: 0, Time: base},
+		{Offset: 1, Time: base.Add(100 * time.Millisecond)},
+		{Offset: 2, Time: base.Add(200 * time.Millisecond)},
+	}
+
+	t.Run("max speed hands every record over", func(t *testing.T) {
+		var mu sync.Mutex
+		var handled []int64
+		r := NewReplayer(context.Background(), ReplayOptions{Concurrency: 2}, func(_ context.Context, rec Record) {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, rec.Offset)
+		})
+		for _, rec := range records {
+			require.NoError(t, r.Submit(context.Background(), rec))
+		}
+		r.Wait()
+		require.ElementsMatch(t, []int64{0, 1, 2}, handled)
+	})
+
+	t.Run("speed keeps the scaled gaps", func(t *testing.T) {
+		r := NewReplayer(context.Background(), ReplayOptions{Speed: 2}, func(context.Context, Record) {})
+		start := time.Now()
+		for _, rec := range records {
+			require.NoError(t, r.Submit(context.Background(), rec))
+		}
+		r.Wait()
+		require.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond)
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		r := NewReplayer(context.Background(), ReplayOptions{Speed: 1}, func(context.Context, Record) {})
+		ctx, cancel := context.WithCancel(context.Background())
+		require.NoError(t, r.Submit(ctx, Record{Time: base}))
+		cancel()
+		err := r.Submit(ctx, Record{Time: base.Add(time.Hour)})
+		require.ErrorIs(t, err, context.Canceled)
+		r.Wait()
+	})
+}
+
+func TestSummary(t *testing.T) {
+	var s Summary
+	s.Add(Result{Status: 200})
+	s.Add(Result{Status: 200})
+	s.Add(Result{Status: 503})
+	s.Add(Result{Err: errors.New("connection refused")})
+
+	require.Equal(t, 4, s.Sent())
+	require.Equal(t, 1, s.Failed())
+	require.Equal(t, map[int]int{200: 2, 503: 1}, s.Statuses())
+	require.Equal(t, map[string]int{"connection refused": 1}, s.Errors())
+	require.Equal(t, []int{200, 503}, SortedKeys(s.Statuses()))
+}
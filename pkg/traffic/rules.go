@@ -0,0 +1,326 @@
+package traffic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RedactedValue replaces the JSON fields selected by a redact rule
+const RedactedValue = "[REDACTED]"
+
+// AllStreams is the key of the rules in a RuleSet that apply to every stream
+const AllStreams = "*"
+
+// RuleSet is a rules file: request rewriting rules for each stream
+type RuleSet struct {
+	// Streams maps a stream name, or * for every stream, to its rules
+	Streams map[string]*Rules `yaml:"streams"`
+}
+
+// Rules rewrite a captured request before it is replayed or exported
+type Rules struct {
+	Headers HeaderRules `yaml:"headers,omitempty"`
+	// Paths are tried in order; the first that matches rewrites the path
+	Paths []PathRule `yaml:"paths,omitempty"`
+	// Auth replaces the captured credentials with fresh ones
+	Auth *AuthRule `yaml:"auth,omitempty"`
+	// Redact lists JSONPaths of request and response body fields to redact
+	Redact []string `yaml:"redact,omitempty"`
+}
+
+// HeaderRules remove and set request headers. Names are case-insensitive.
+type HeaderRules struct {
+	Remove []string          `yaml:"remove,omitempty"`
+	Set    map[string]string `yaml:"set,omitempty"`
+}
+
+// PathRule rewrites paths matching a regular expression. Replace may refer
+// to capture groups as $1 or ${name}.
+type PathRule struct {
+	Match   string `yaml:"match"`
+	Replace string `yaml:"replace"`
+}
+
+// AuthRule sets a header, Authorization by default, to a bearer token from
+// exactly one source
+type AuthRule struct {
+	Header            string                 `yaml:"header,omitempty"`
+	TokenEnv          string                 `yaml:"token_env,omitempty"`
+	TokenFile         string                 `yaml:"token_file,omitempty"`
+	TokenCommand      string                 `yaml:"token_command,omitempty"`
+	ClientCredentials *ClientCredentialsRule `yaml:"client_credentials,omitempty"`
+}
+
+// ClientCredentialsRule mints tokens with the OAuth 2.0 client credentials
+// grant. The client secret is read from an environment variable or a file.
+type ClientCredentialsRule struct {
+	TokenURL         string `yaml:"token_url"`
+	ClientID         string `yaml:"client_id"`
+	ClientSecretEnv  string `yaml:"client_secret_env,omitempty"`
+	ClientSecretFile string `yaml:"client_secret_file,omitempty"`
+	Scope            string `yaml:"scope,omitempty"`
+}
+
+// LoadRules reads a rules file
+func LoadRules(path string) (*RuleSet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rules file: %w", err)
+	}
+	defer f.Close()
+
+	var rs RuleSet
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(&rs); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &rs, nil
+}
+
+// Rewriter applies the compiled rules of one stream to requests
+type Rewriter struct {
+	removeHeaders []string
+	setHeaders    map[string]string
+	paths         []compiledPathRule
+	redact        []JSONPath
+	authHeader    string
+	token         TokenSource
+}
+
+type compiledPathRule struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+// Rewriter compiles the rules for a stream: the rules for every stream (*)
+// first, then the stream's own. It returns nil if no rules apply, or if rs
+// is nil.
+func (rs *RuleSet) Rewriter(stream string) (*Rewriter, error) {
+	if rs == nil {
+		return nil, nil
+	}
+
+	var applicable []*Rules
+	for _, key := range []string{AllStreams, stream} {
+		if rules := rs.Streams[key]; rules != nil {
+			applicable = append(applicable, rules)
+		}
+	}
+	if len(applicable) == 0 {
+		return nil, nil
+	}
+
+	rw := &Rewriter{setHeaders: make(map[string]string)}
+	for _, rules := range applicable {
+		if err := rw.add(rules); err != nil {
+			return nil, err
+		}
+	}
+	return rw, nil
+}
+
+// add compiles one set of rules into the rewriter. An auth rule replaces
+// any earlier one.
+func (rw *Rewriter) add(rules *Rules) error {
+	rw.removeHeaders = append(rw.removeHeaders, rules.Headers.Remove...)
+	for name, value := range rules.Headers.Set {
+		rw.setHeaders[http.CanonicalHeaderKey(name)] = value
+	}
+
+	for _, p := range rules.Paths {
+		re, err := regexp.Compile(p.Match)
+		if err != nil {
+			return fmt.Errorf("invalid path rule '%s': %w", p.Match, err)
+		}
+		rw.paths = append(rw.paths, compiledPathRule{re: re, replace: p.Replace})
+	}
+
+	for _, expr := range rules.Redact {
+		path, err := ParseJSONPath(expr)
+		if err != nil {
+			return err
+		}
+		rw.redact = append(rw.redact, path)
+	}
+
+	if rules.Auth != nil {
+		token, err := rules.Auth.tokenSource()
+		if err != nil {
+			return err
+		}
+		rw.token = token
+		rw.authHeader = http.CanonicalHeaderKey(rules.Auth.Header)
+		if rw.authHeader == "" {
+			rw.authHeader = "Authorization"
+		}
+	}
+	return nil
+}
+
+// tokenSource returns the token source of an auth rule
+func (a *AuthRule) tokenSource() (TokenSource, error) {
+	var sources []TokenSource
+	if a.TokenEnv != "" {
+		token := os.Getenv(a.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("auth token_env: %s is not set", a.TokenEnv)
+		}
+		sources = append(sources, StaticToken(token))
+	}
+	if a.TokenFile != "" {
+		token, err := readFirstLine(a.TokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("auth token_file: %w", err)
+		}
+		sources = append(sources, StaticToken(token))
+	}
+	if a.TokenCommand != "" {
+		sources = append(sources, &CommandToken{Command: a.TokenCommand})
+	}
+	if cc := a.ClientCredentials; cc != nil {
+		if cc.TokenURL == "" || cc.ClientID == "" {
+			return nil, fmt.Errorf("auth client_credentials requires token_url and client_id")
+		}
+		secret, err := cc.clientSecret()
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, &ClientCredentials{
+			TokenURL:     cc.TokenURL,
+			ClientID:     cc.ClientID,
+			ClientSecret: secret,
+			Scope:        cc.Scope,
+		})
+	}
+
+	if len(sources) != 1 {
+		return nil, fmt.Errorf("auth needs exactly one of token_env, token_file, token_command or client_credentials")
+	}
+	return sources[0], nil
+}
+
+func (cc *ClientCredentialsRule) clientSecret() (string, error) {
+	switch {
+	case cc.ClientSecretEnv != "" && cc.ClientSecretFile != "":
+		return "", fmt.Errorf("auth client_credentials: set client_secret_env or client_secret_file, not both")
+	case cc.ClientSecretEnv != "":
+		secret := os.Getenv(cc.ClientSecretEnv)
+		if secret == "" {
+			return "", fmt.Errorf("auth client_credentials: %s is not set", cc.ClientSecretEnv)
+		}
+		return secret, nil
+	case cc.ClientSecretFile != "":
+		secret, err := readFirstLine(cc.ClientSecretFile)
+		if err != nil {
+			return "", fmt.Errorf("auth client_credentials: %w", err)
+		}
+		return secret, nil
+	default:
+		return "", fmt.Errorf("auth client_credentials requires client_secret_env or client_secret_file")
+	}
+}
+
+// readFirstLine reads the first line of a file, which must not be empty
+func readFirstLine(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line, _, _ := strings.Cut(string(data), "\n")
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return line, nil
+}
+
+// Apply rewrites a request in place: headers are removed and set, the path
+// is mapped, body fields are redacted and the credentials replaced, in that
+// order. A nil Rewriter leaves the request unchanged.
+func (rw *Rewriter) Apply(ctx context.Context, req *Request) error {
+	if rw == nil {
+		return nil
+	}
+
+	headers := make(map[string]string, len(req.Headers))
+	for name, value := range req.Headers {
+		headers[name] = value
+	}
+	for _, name := range rw.removeHeaders {
+		deleteHeader(headers, name)
+	}
+	for name, value := range rw.setHeaders {
+		deleteHeader(headers, name)
+		headers[name] = value
+	}
+
+	for _, p := range rw.paths {
+		if p.re.MatchString(req.Path) {
+			req.Path = p.re.ReplaceAllString(req.Path, p.replace)
+			break
+		}
+	}
+
+	if len(rw.redact) > 0 {
+		req.Body = rw.redactBody(req.Body)
+		if req.Response != nil {
+			resp := *req.Response
+			resp.Body = rw.redactBody(resp.Body)
+			req.Response = &resp
+		}
+	}
+
+	if rw.token != nil {
+		token, err := rw.token.Token(ctx)
+		if err != nil {
+			return err
+		}
+		deleteHeader(headers, rw.authHeader)
+		if rw.authHeader == "Authorization" {
+			token = "Bearer " + token
+		}
+		headers[rw.authHeader] = token
+	}
+
+	req.Headers = headers
+	return nil
+}
+
+// redactBody replaces the redacted fields of a JSON body. Other bodies are
+// returned unchanged.
+func (rw *Rewriter) redactBody(body string) string {
+	doc, err := decodeJSON([]byte(body))
+	if err != nil {
+		return body
+	}
+	for _, path := range rw.redact {
+		path.Set(doc, RedactedValue)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return body
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func deleteHeader(headers map[string]string, name string) {
+	for k := range headers {
+		if strings.EqualFold(k, name) {
+			delete(headers, k)
+		}
+	}
+}
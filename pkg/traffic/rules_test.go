@@ -0,0 +1,162 @@
+package traffic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeRules(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestRewriter(t *testing.T) {
+	t.Setenv("REPLAY_TOKEN", "fresh")
+	rs, err := LoadRules(writeRules(t, `
+streams:
+  "*":
+    headers:
+      remove: [cookie]
+  my-api:
+    headers:
+      remove: [X-Forwarded-For]
+      set:
+        x-replay: "true"
+    paths:
+      - match: ^/v1/(.*)$
+        replace: /v2/$1
+      - match: ^/v2/
+        replace: /never/
+    auth:
+      token_env: REPLAY_TOKEN
+    redact: [$.password, $..email]
+`))
+	require.NoError(t, err)
+
+	req := Request{
+		Method: "POST",
+		Path:   "/v1/users",
+		Headers: map[string]string{
+			"Authorization":   "Bearer stale",
+			"Cookie":          "session=1",
+			"X-Forwarded-For": "10.0.0.1",
+			"X-Replay":        "false",
+			"Content-Type":    "application/json",
+		},
+		Body:     `{"name":"ann","password":"hunter2","contact":{"email":"ann@example.com"}}`,
+		Response: &Response{Status: 201, Body: `{"id":1,"email":"ann@example.com"}`},
+	}
+	original := req.Headers
+
+	rw, err := rs.Rewriter("my-api")
+	require.NoError(t, err)
+	require.NoError(t, rw.Apply(context.Background(), &req))
+
+	require.Equal(t, "/v2/users", req.Path)
+	require.Equal(t, map[string]string{
+		"Authorization": "Bearer fresh",
+		"X-Replay":      "true",
+		"Content-Type":  "application/json",
+	}, req.Headers)
+	require.JSONEq(t, `{"name":"ann","password":"[REDACTED]","contact":{"email":"[REDACTED]"}}`, req.Body)
+	require.JSONEq(t, `{"id":1,"email":"[REDACTED]"}`, req.Response.Body)
+	require.Equal(t, "session=1", original["Cookie"], "the captured headers are not modified")
+
+	// Other streams only get the rules for every stream
+	other, err := rs.Rewriter("other")
+	require.NoError(t, err)
+	req = Request{Path: "/v1/x", Headers: map[string]string{"Cookie": "a", "Authorization": "Bearer stale"}, Body: "not json"}
+	require.NoError(t, other.Apply(context.Background(), &req))
+	require.Equal(t, "/v1/x", req.Path)
+	require.Equal(t, map[string]string{"Authorization": "Bearer stale"}, req.Headers)
+	require.Equal(t, "not json", req.Body)
+
+	// No rules leave requests unchanged
+	none, err := (&RuleSet{}).Rewriter("my-api")
+	require.NoError(t, err)
+	require.Nil(t, none)
+	var nilRules *RuleSet
+	none, err = nilRules.Rewriter("my-api")
+	require.NoError(t, err)
+	require.NoError(t, none.Apply(context.Background(), &req))
+}
+
+func TestRulesErrors(t *testing.T) {
+	_, err := LoadRules(writeRules(t, "streams:\n  my-api:\n    header: {}\n"))
+	require.ErrorContains(t, err, "field header not found")
+
+	cases := []struct {
+		name  string
+		rules string
+		err   string
+	}{
+		{"bad regexp", "paths: [{match: '(', replace: x}]", "invalid path rule"},
+		{"bad jsonpath", "redact: [password]", "invalid JSONPath"},
+		{"no token source", "auth: {header: X-Token}", "exactly one of"},
+		{"two token sources", "auth: {token_env: HOME, token_command: echo x}", "exactly one of"},
+		{"unset env", "auth: {token_env: FRKR_TEST_UNSET_TOKEN}", "FRKR_TEST_UNSET_TOKEN is not set"},
+		{"no client secret", "auth: {client_credentials: {token_url: http://idp/token, client_id: frkr}}", "client_secret_env or client_secret_file"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs, err := LoadRules(writeRules(t, "streams:\n  my-api: {"+tc.rules+"}\n"))
+			require.NoError(t, err)
+			_, err = rs.Rewriter("my-api")
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestClientCredentials(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "frkr" || secret != "s3cret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "replay", r.PostForm.Get("scope"))
+		fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, calls, 3600)
+	}))
+	defer srv.Close()
+
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("s3cret\n"), 0o600))
+	rs, err := LoadRules(writeRules(t, fmt.Sprintf(`
+streams:
+  my-api:
+    auth:
+      header: X-Api-Token
+      client_credentials:
+        token_url: %s
+        client_id: frkr
+        client_secret_file: %s
+        scope: replay
+`, srv.URL, secretFile)))
+	require.NoError(t, err)
+	rw, err := rs.Rewriter("my-api")
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		req := Request{Headers: map[string]string{"x-api-token": "stale"}}
+		require.NoError(t, rw.Apply(context.Background(), &req))
+		require.Equal(t, map[string]string{"X-Api-Token": "token-1"}, req.Headers)
+	}
+	require.Equal(t, 1, calls, "the token is cached until it expires")
+
+	bad := &ClientCredentials{TokenURL: srv.URL, ClientID: "frkr", ClientSecret: "wrong"}
+	_, err = bad.Token(context.Background())
+	require.ErrorContains(t, err, "token endpoint returned 401")
+}
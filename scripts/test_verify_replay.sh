@@ -107,6 +107,7 @@ if ! curl -s http://localhost:8082/health/ready | grep -q 'healthy'; then
 fi
 
 echo "🔧 Configuring Stream and User..."
+"$TOOLS_DIR/bin/frkrcfg" tenant create default --db-url="postgres://root@localhost:26257/frkrdb?sslmode=disable" || true
 "$TOOLS_DIR/bin/frkrcfg" stream create test-replay --db-url="postgres://root@localhost:26257/frkrdb?sslmode=disable" || true
 "$TOOLS_DIR/bin/frkrcfg" user create replay-user --db-url="postgres://root@localhost:26257/frkrdb?sslmode=disable" --password="replay-pass" || true
 
@@ -202,4 +203,28 @@ if grep -q "POST /three" "$FORWARDED_LOG"; then
     exit 1
 fi
 
+# Reset Log
+> "$FORWARDED_LOG"
+
+# 3. Same window, replayed by frkrcfg straight from the topic (Should see req-2 ONLY)
+echo "👉 Scenario 3: frkrcfg stream replay --from $T2 --to $T3"
+"$TOOLS_DIR/bin/frkrcfg" stream replay test-replay \
+  --db-url="postgres://root@localhost:26257/frkrdb?sslmode=disable" \
+  --broker-url="localhost:19092" \
+  --from "$T2" \
+  --to "$T3" \
+  --speed max \
+  --target http://localhost:9999 > "$SCRIPTS_DIR/output_replay_3.log" 2>&1
+
+echo "📄 Scenario 3 Captured Logs:"
+cat "$FORWARDED_LOG"
+
+if grep -q "POST /two" "$FORWARDED_LOG" && ! grep -q "POST /three" "$FORWARDED_LOG"; then
+    echo "✅ Scenario 3 Passed: Received req-2 only"
+else
+    echo "❌ Scenario 3 Failed"
+    cat "$SCRIPTS_DIR/output_replay_3.log"
+    exit 1
+fi
+
 echo "🎉 All Scenarios Passed!"